@@ -0,0 +1,94 @@
+package orm
+
+import (
+	"context"
+	"errors"
+	"github.com/KNICEX/go-orm/internal/errs"
+)
+
+// FindInBatches 按主键升序分批查询，每批最多 size 行
+// 使用 WHERE pk > 上一批最后的主键 LIMIT size 的方式翻页，不会使用 OFFSET
+// 保留 With, Where, Select, GroupBy, Having, Unscoped, Preload 等设置，OrderBy, Offset, Limit 会被忽略
+// fn 返回 error 时停止并返回该 error
+func (s *Selector[T]) FindInBatches(ctx context.Context, size int, fn func(batch []*T) error) error {
+	m, err := s.r.Get(new(T))
+	if err != nil {
+		return err
+	}
+	if len(m.PrimaryKeys) == 0 {
+		return errs.ErrNoPrimaryKey
+	}
+	if len(m.PrimaryKeys) > 1 {
+		return errs.ErrCompositePrimaryKey
+	}
+	if size <= 0 {
+		return errs.NewErrInvalidBatchSize(size)
+	}
+	pk := m.PrimaryKeys[0]
+
+	// 需要读取主键作为下一批的起点
+	columns := withFields(s.columns, pk.GoName)
+
+	var last any
+	for {
+		batchSelector := s.derive(columns)
+		if last != nil {
+			batchSelector.where = append(batchSelector.where, Col(pk.GoName).Gt(last))
+		}
+		batch, err := batchSelector.OrderBy(Col(pk.GoName).Asc()).Limit(size).GetMulti(ctx)
+		if errors.Is(err, ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err = fn(batch); err != nil {
+			return err
+		}
+		if len(batch) < size {
+			return nil
+		}
+		last, err = s.creator(batchSelector.model, batch[len(batch)-1]).Field(pk.GoName)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// derive 基于当前的表、条件、查询列等创建新的 Selector，用于需要多次执行的查询
+func (s *Selector[T]) derive(columns []Selectable) *Selector[T] {
+	res := NewSelector[T](s.sess)
+	res.table = s.table
+	res.columns = columns
+	res.where = append(res.where, s.where...)
+	res.groupBys = s.groupBys
+	res.having = s.having
+	res.ctes = s.ctes
+	res.unscoped = s.unscoped
+	res.preloads = s.preloads
+	res.lock = s.lock
+	return res
+}
+
+// withFields 指定了查询列时，追加缺少的字段
+func withFields(columns []Selectable, names ...string) []Selectable {
+	if len(columns) == 0 {
+		return nil
+	}
+	res := append(make([]Selectable, 0, len(columns)+len(names)), columns...)
+	for _, name := range names {
+		if !selectsField(columns, name) {
+			res = append(res, Col(name))
+		}
+	}
+	return res
+}
+
+func selectsField(columns []Selectable, name string) bool {
+	for _, col := range columns {
+		if c, ok := col.(Column); ok && c.name == name && c.alias == "" {
+			return true
+		}
+	}
+	return false
+}
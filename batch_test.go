@@ -0,0 +1,89 @@
+package orm
+
+import (
+	"context"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/KNICEX/go-orm/internal/errs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestSelector_FindInBatches(t *testing.T) {
+	mockDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	db, err := OpenDB(mockDB, DBWithDialect(DialectMySQL))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	mock.ExpectQuery("SELECT * FROM `test_model` WHERE `first_name` = ? ORDER BY `id` ASC LIMIT 2;").
+		WithArgs("tom").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "first_name"}).AddRow(1, "tom").AddRow(3, "tom"))
+	mock.ExpectQuery("SELECT * FROM `test_model` WHERE (`first_name` = ?) AND (`id` > ?) ORDER BY `id` ASC LIMIT 2;").
+		WithArgs("tom", int64(3)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "first_name"}).AddRow(7, "tom").AddRow(9, "tom"))
+	mock.ExpectQuery("SELECT * FROM `test_model` WHERE (`first_name` = ?) AND (`id` > ?) ORDER BY `id` ASC LIMIT 2;").
+		WithArgs("tom", int64(9)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "first_name"}).AddRow(10, "tom"))
+	var batches [][]int64
+	err = NewSelector[TestModel](db).Where(Col("FirstName").Eq("tom")).
+		FindInBatches(ctx, 2, func(batch []*TestModel) error {
+			ids := make([]int64, 0, len(batch))
+			for _, tm := range batch {
+				ids = append(ids, tm.Id)
+			}
+			batches = append(batches, ids)
+			return nil
+		})
+	require.NoError(t, err)
+	assert.Equal(t, [][]int64{{1, 3}, {7, 9}, {10}}, batches)
+
+	// 最后一批正好满，多查询一次空结果
+	mock.ExpectQuery("SELECT `first_name`,`id` FROM `test_model` ORDER BY `id` ASC LIMIT 1;").
+		WillReturnRows(sqlmock.NewRows([]string{"first_name", "id"}).AddRow("tom", 1))
+	mock.ExpectQuery("SELECT `first_name`,`id` FROM `test_model` WHERE `id` > ? ORDER BY `id` ASC LIMIT 1;").
+		WithArgs(int64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"first_name", "id"}))
+	cnt := 0
+	err = NewSelector[TestModel](db).Select(Col("FirstName")).FindInBatches(ctx, 1, func(batch []*TestModel) error {
+		cnt += len(batch)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, cnt)
+
+	// 回调返回错误时停止
+	stopErr := errors.New("stop")
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT * FROM `test_model` ORDER BY `id` ASC LIMIT 1;").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectRollback()
+	err = db.DoTx(ctx, func(ctx context.Context, tx *Tx) error {
+		return NewSelector[TestModel](tx).FindInBatches(ctx, 1, func(batch []*TestModel) error {
+			return stopErr
+		})
+	}, nil)
+	assert.Equal(t, stopErr, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSelector_FindInBatches_Invalid(t *testing.T) {
+	db, err := OpenDB(nil, DBWithDialect(DialectMySQL))
+	require.NoError(t, err)
+	type CompositeKey struct {
+		UserId int64 `orm:"pk"`
+		RoleId int64 `orm:"pk"`
+	}
+	type NoKey struct {
+		Name string
+	}
+	noop := func(batch []*TestModel) error { return nil }
+
+	err = NewSelector[CompositeKey](db).FindInBatches(context.Background(), 10, func(batch []*CompositeKey) error { return nil })
+	assert.Equal(t, errs.ErrCompositePrimaryKey, err)
+	err = NewSelector[NoKey](db).FindInBatches(context.Background(), 10, func(batch []*NoKey) error { return nil })
+	assert.Equal(t, errs.ErrNoPrimaryKey, err)
+	err = NewSelector[TestModel](db).FindInBatches(context.Background(), 0, noop)
+	assert.Equal(t, errs.NewErrInvalidBatchSize(0), err)
+}
@@ -5,12 +5,29 @@ import (
 	"strings"
 )
 
+// nestedBuilder 可以嵌套在其他语句中的构造器
+// build 返回的 SQL 未经方言改写占位符，由最外层语句统一改写
+type nestedBuilder interface {
+	build() (*Query, error)
+}
+
 type builder struct {
 	*core
 	args []any
 	sb   strings.Builder
 
 	quoter byte
+	// 查询列，GROUP BY, HAVING, ORDER BY 中可以通过别名引用
+	selected []Selectable
+	// 正在构造 HAVING，方言不支持别名时展开别名对应的表达式
+	inHaving bool
+	// WITH 中定义的公用表表达式，用于解析 CTETable 的列名
+	ctes []cte
+	// 不为 nil 时，赋值语句中的表达式改为从关联的表中查询
+	correlated *multiTable
+	// 多表语句中目标表的别名或表名，不为空时所有列都带上表名或别名，
+	// 没有指定表的列属于目标表
+	qualifier string
 }
 
 func (b *builder) quote(name string) {
@@ -56,6 +73,14 @@ func (b *builder) buildColumns(cols []Selectable) error {
 		case RawExpr:
 			b.sb.WriteString(c.raw)
 			b.addArgs(c.args...)
+		case MathExpr, FuncExpr, CaseExpr, WindowFunc:
+			if err := b.buildExpression(c.(Expression)); err != nil {
+				return err
+			}
+			if alias := c.selectedAlias(); alias != "" {
+				b.sb.WriteString(" AS ")
+				b.quote(alias)
+			}
 		}
 
 		if i != len(cols)-1 {
@@ -81,6 +106,9 @@ func (b *builder) buildExpression(expr Expression) error {
 	case nil:
 		return nil
 	case Predicate:
+		if exp.op == opILike && !b.dialect.supportILike() {
+			exp = Predicate{left: Lower(exp.left), op: opLike, right: Lower(exp.right)}
+		}
 
 		// 如果左边也是一个表达式，那么需要加括号
 		_, ok := exp.left.(Predicate)
@@ -98,6 +126,10 @@ func (b *builder) buildExpression(expr Expression) error {
 		if exp.op != "" {
 			b.sb.WriteByte(' ')
 			b.sb.WriteString(exp.op.String())
+			// 后缀操作符，例如 IS NULL
+			if exp.right == nil {
+				return nil
+			}
 			b.sb.WriteByte(' ')
 		}
 
@@ -113,6 +145,9 @@ func (b *builder) buildExpression(expr Expression) error {
 			b.sb.WriteByte(')')
 		}
 	case Column:
+		if sel, ok := b.selectedByAlias(exp); ok {
+			return b.buildAlias(sel)
+		}
 		// 条件表达式不允许列别名
 		exp.alias = ""
 		return b.buildColumn(exp)
@@ -124,6 +159,17 @@ func (b *builder) buildExpression(expr Expression) error {
 		b.sb.WriteString(exp.raw)
 		b.sb.WriteByte(')')
 		b.addArgs(exp.args...)
+	case rowValue:
+		b.sb.WriteByte('(')
+		for i, e := range exp.exprs {
+			if i > 0 {
+				b.sb.WriteByte(',')
+			}
+			if err := b.buildExpression(e); err != nil {
+				return err
+			}
+		}
+		b.sb.WriteByte(')')
 	case Aggregate:
 		b.sb.WriteString(exp.fn)
 		b.sb.WriteByte('(')
@@ -132,12 +178,238 @@ func (b *builder) buildExpression(expr Expression) error {
 			return err
 		}
 		b.sb.WriteByte(')')
+	case valueList:
+		b.sb.WriteByte('(')
+		for i, v := range exp.vals {
+			if i > 0 {
+				b.sb.WriteByte(',')
+			}
+			b.sb.WriteByte('?')
+			b.addArgs(v)
+		}
+		b.sb.WriteByte(')')
+	case betweenRange:
+		if err := b.buildExpression(exp.low); err != nil {
+			return err
+		}
+		b.sb.WriteString(" AND ")
+		return b.buildExpression(exp.high)
+	case subQueryExpr:
+		return b.buildSubQueryExpr(exp.s)
+	case prefixExpr:
+		b.sb.WriteString(exp.op.String())
+		b.sb.WriteByte(' ')
+		return b.buildExpression(exp.right)
+	case MathExpr:
+		if err := b.buildOperand(exp.left); err != nil {
+			return err
+		}
+		b.sb.WriteByte(' ')
+		b.sb.WriteString(exp.op.String())
+		b.sb.WriteByte(' ')
+		return b.buildOperand(exp.right)
+	case FuncExpr:
+		return b.dialect.buildFunc(b, exp)
+	case CaseExpr:
+		return b.buildCase(exp)
+	case WindowFunc:
+		return b.buildWindowFunc(exp)
 	default:
 		return errs.NewErrUnsupportedExpression(expr)
 	}
 	return nil
 }
 
+// buildOperand 构造算术表达式的操作数，嵌套的算术表达式需要加括号
+func (b *builder) buildOperand(expr Expression) error {
+	if _, ok := expr.(MathExpr); !ok {
+		return b.buildExpression(expr)
+	}
+	b.sb.WriteByte('(')
+	if err := b.buildExpression(expr); err != nil {
+		return err
+	}
+	b.sb.WriteByte(')')
+	return nil
+}
+
+// buildAssignValue 构造赋值语句右侧的值，表达式直接展开，其余作为参数
+// 用法： Assign("Stock", Col("Stock").Sub(1)) 构造 `stock` = `stock` - ?
+func (b *builder) buildAssignValue(val any) error {
+	if e, ok := val.(Expression); ok {
+		if b.correlated != nil {
+			return b.buildCorrelated(e)
+		}
+		return b.buildExpression(e)
+	}
+	b.sb.WriteByte('?')
+	b.addArgs(val)
+	return nil
+}
+
+// buildOrderBy 构造 ORDER BY 子句，设置了别名的表达式和查询列中的别名直接使用别名排序
+func (b *builder) buildOrderBy(orderBys []OrderAble) error {
+	if len(orderBys) == 0 {
+		return nil
+	}
+	b.sb.WriteString(" ORDER BY ")
+	return b.buildOrderItems(orderBys)
+}
+
+// buildOrderItems 构造排序列，用于 ORDER BY 和窗口中的排序
+func (b *builder) buildOrderItems(orderBys []OrderAble) error {
+	for i, ob := range orderBys {
+		if i > 0 {
+			b.sb.WriteByte(',')
+		}
+		var desc bool
+		switch o := ob.(type) {
+		case Column:
+			desc = o.desc
+			if _, ok := b.selectedByAlias(o); ok {
+				b.quote(o.name)
+				break
+			}
+			if o.table != nil {
+				if err := b.buildColumn(Column{table: o.table, name: o.name}); err != nil {
+					return err
+				}
+				break
+			}
+			fd, ok := b.model.FieldMap[o.name]
+			if !ok {
+				return errs.NewErrUnknownField(o.name)
+			}
+			b.quote(fd.ColName)
+		case MathExpr:
+			desc = o.desc
+			if err := b.buildAliasOrExpr(o, o.alias); err != nil {
+				return err
+			}
+		case FuncExpr:
+			desc = o.desc
+			if err := b.buildAliasOrExpr(o, o.alias); err != nil {
+				return err
+			}
+		case CaseExpr:
+			desc = o.desc
+			if err := b.buildAliasOrExpr(o, o.alias); err != nil {
+				return err
+			}
+		case WindowFunc:
+			desc = o.desc
+			if err := b.buildAliasOrExpr(o, o.alias); err != nil {
+				return err
+			}
+		case RawExpr:
+			b.sb.WriteByte('(')
+			b.sb.WriteString(o.raw)
+			b.sb.WriteByte(')')
+			continue
+		default:
+			return errs.NewErrUnsupportedExpression(ob)
+		}
+		if desc {
+			b.sb.WriteString(" DESC")
+		} else {
+			b.sb.WriteString(" ASC")
+		}
+	}
+	return nil
+}
+
+// buildAliasOrExpr 有别名时引用别名，否则展开表达式
+func (b *builder) buildAliasOrExpr(expr Expression, alias string) error {
+	if alias != "" {
+		b.quote(alias)
+		return nil
+	}
+	return b.buildExpression(expr)
+}
+
+// buildGroupBy 构造 GROUP BY 和 HAVING 子句
+// 设置了别名的表达式使用别名分组，避免展开后参数不同导致和查询列不一致
+func (b *builder) buildGroupBy(groupBys []GroupAble, having []Predicate) error {
+	if len(groupBys) == 0 {
+		return nil
+	}
+	b.sb.WriteString(" GROUP BY ")
+	for i, gb := range groupBys {
+		if i > 0 {
+			b.sb.WriteByte(',')
+		}
+		switch g := gb.(type) {
+		case Column:
+			if _, ok := b.selectedByAlias(g); ok {
+				b.quote(g.name)
+				break
+			}
+			if err := b.buildColumn(g); err != nil {
+				return err
+			}
+		case MathExpr:
+			if err := b.buildAliasOrExpr(g, g.alias); err != nil {
+				return err
+			}
+		case FuncExpr:
+			if err := b.buildAliasOrExpr(g, g.alias); err != nil {
+				return err
+			}
+		case CaseExpr:
+			if err := b.buildAliasOrExpr(g, g.alias); err != nil {
+				return err
+			}
+		case RawExpr:
+			b.sb.WriteString(g.raw)
+		default:
+			return errs.NewErrUnsupportedExpression(gb)
+		}
+	}
+
+	if len(having) > 0 {
+		b.sb.WriteString(" HAVING ")
+		b.inHaving = true
+		defer func() {
+			b.inHaving = false
+		}()
+		if err := b.buildPredicate(having); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// selectedByAlias 没有指定表的列如果是查询列的别名，返回对应的查询列
+func (b *builder) selectedByAlias(c Column) (Selectable, bool) {
+	if c.table != nil {
+		return nil, false
+	}
+	for _, sel := range b.selected {
+		if alias := sel.selectedAlias(); alias != "" && alias == c.name {
+			return sel, true
+		}
+	}
+	return nil, false
+}
+
+// buildAlias 引用查询列的别名，HAVING 中方言不支持别名时展开为表达式
+func (b *builder) buildAlias(sel Selectable) error {
+	if b.inHaving && !b.dialect.aliasInHaving() {
+		switch c := sel.(type) {
+		case Column:
+			c.alias = ""
+			return b.buildColumn(c)
+		case Aggregate:
+			c.alias = ""
+			return b.buildExpression(c)
+		case Expression:
+			return b.buildExpression(c)
+		}
+	}
+	b.quote(sel.selectedAlias())
+	return nil
+}
+
 func (b *builder) buildTable(table TableReference) error {
 	switch t := table.(type) {
 	case nil:
@@ -161,6 +433,8 @@ func (b *builder) buildTable(table TableReference) error {
 		if err := b.buildSubQuery(t); err != nil {
 			return err
 		}
+	case CTETable:
+		b.buildCTETable(t)
 	default:
 		return errs.NewErrUnsupportedTable(table)
 	}
@@ -204,17 +478,33 @@ func (b *builder) buildJoin(j Join) error {
 	return nil
 }
 
-func (b *builder) buildSubQuery(s SubQuery) error {
+// buildSubQueryExpr 构造 (SELECT ...)，子查询的参数追加到当前位置
+func (b *builder) buildSubQueryExpr(s SqlBuilder) error {
 	b.sb.WriteByte('(')
-	q, err := s.s.Build()
+	q, err := b.buildNested(s)
 	if err != nil {
 		return err
 	}
-	b.sb.WriteString(q.SQL[:len(q.SQL)-1]) // 去掉分号
+	b.sb.WriteString(strings.TrimSuffix(q.SQL, ";"))
 	b.sb.WriteByte(')')
 	if len(q.Args) > 0 {
 		b.addArgs(q.Args...)
 	}
+	return nil
+}
+
+// buildNested 构造嵌套语句，优先使用未改写占位符的 SQL
+func (b *builder) buildNested(s SqlBuilder) (*Query, error) {
+	if nb, ok := s.(nestedBuilder); ok {
+		return nb.build()
+	}
+	return s.Build()
+}
+
+func (b *builder) buildSubQuery(s SubQuery) error {
+	if err := b.buildSubQueryExpr(s.s); err != nil {
+		return err
+	}
 	if s.alias != "" {
 		b.sb.WriteString(" AS ")
 		b.quote(s.alias)
@@ -223,10 +513,10 @@ func (b *builder) buildSubQuery(s SubQuery) error {
 }
 
 func (b *builder) buildColumn(c Column) error {
-	var alias string
 	table := c.table
-	if table != nil {
-		alias = table.tableAlias()
+	alias, err := b.colQualifier(table)
+	if err != nil {
+		return err
 	}
 	if alias != "" {
 		b.quote(alias)
@@ -245,6 +535,25 @@ func (b *builder) buildColumn(c Column) error {
 	return nil
 }
 
+// colQualifier 列的限定名，多表语句中没有别名的表使用表名
+func (b *builder) colQualifier(table TableReference) (string, error) {
+	if table == nil {
+		return b.qualifier, nil
+	}
+	alias := table.tableAlias()
+	if alias != "" || b.qualifier == "" {
+		return alias, nil
+	}
+	if t, ok := table.(Table); ok {
+		m, err := b.r.Get(t.entity)
+		if err != nil {
+			return "", err
+		}
+		return m.TableName, nil
+	}
+	return "", nil
+}
+
 // colName 获取列名
 func (b *builder) colName(table TableReference, fd string) (string, error) {
 	switch tab := table.(type) {
@@ -279,12 +588,18 @@ func (b *builder) colName(table TableReference, fd string) (string, error) {
 				}
 
 				if col.fieldName() == fd {
-					return b.colName(col.target(), fd)
+					target := col.target()
+					if target == nil {
+						target = tab.table
+					}
+					return b.colName(target, fd)
 				}
 			}
 			return "", errs.NewErrUnknownField(fd)
 		}
 		return b.colName(tab.table, fd)
+	case CTETable:
+		return b.cteColName(tab, fd)
 	default:
 		return "", errs.NewErrUnsupportedTable(table)
 	}
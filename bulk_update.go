@@ -0,0 +1,435 @@
+package orm
+
+import (
+	"context"
+	"github.com/KNICEX/go-orm/internal/errs"
+	"github.com/KNICEX/go-orm/model"
+	"slices"
+	"strings"
+)
+
+var _ Executor = (*BulkUpdater[any])(nil)
+
+// BulkUpdater 按主键批量更新多个实体，每行使用实体中各自的值
+// MySQL 和 SQLite 使用 CASE pk WHEN ... THEN ... END，Postgres 使用 UPDATE ... FROM (VALUES ...)
+type BulkUpdater[T any] struct {
+	entities []*T
+	fields   []string
+	// 不追加软删除过滤条件
+	unscoped bool
+	// 单条语句的最大参数数量，0 时使用方言的限制
+	maxParams int
+
+	builder
+	sess Session
+}
+
+// bulkUpdate 传给方言构造的批量更新语句
+type bulkUpdate struct {
+	// 更新的字段，不包括主键
+	fields []*model.Field
+	// 每个实体的主键值和字段值
+	pks  [][]any
+	vals [][]any
+	// 所有行统一赋值的列，例如自动更新时间
+	assigns []Assignment
+	// 主键以外的条件，例如软删除过滤条件
+	where []Predicate
+}
+
+func BulkUpdate[T any](sess Session) *BulkUpdater[T] {
+	c := sess.getCore()
+	return &BulkUpdater[T]{
+		sess: sess,
+		builder: builder{
+			core:   c,
+			quoter: c.dialect.quoter(),
+		},
+	}
+}
+
+func (u *BulkUpdater[T]) Entities(entities ...*T) *BulkUpdater[T] {
+	u.entities = append(u.entities, entities...)
+	return u
+}
+
+// Fields 需要更新的字段，不指定时更新除主键和创建时间以外的所有字段
+func (u *BulkUpdater[T]) Fields(fields ...string) *BulkUpdater[T] {
+	u.fields = fields
+	return u
+}
+
+// Unscoped 更新包括已软删除的行
+func (u *BulkUpdater[T]) Unscoped() *BulkUpdater[T] {
+	u.unscoped = true
+	return u
+}
+
+// MaxParams 覆盖方言中单条语句的最大参数数量，超出时 Exec 会拆分为多条语句
+func (u *BulkUpdater[T]) MaxParams(n int) *BulkUpdater[T] {
+	u.maxParams = n
+	return u
+}
+
+// Build 构造更新所有实体的语句，不会拆分
+func (u *BulkUpdater[T]) Build() (*Query, error) {
+	if len(u.entities) == 0 {
+		return nil, errs.ErrUpdateNoSet
+	}
+	m, err := u.r.Get(new(T))
+	if err != nil {
+		return nil, err
+	}
+	u.model = m
+	if len(m.PrimaryKeys) == 0 {
+		return nil, errs.ErrNoPrimaryKey
+	}
+	fields, err := u.updateFields(m)
+	if err != nil {
+		return nil, err
+	}
+
+	bu := &bulkUpdate{
+		fields: fields,
+		pks:    make([][]any, 0, len(u.entities)),
+		vals:   make([][]any, 0, len(u.entities)),
+	}
+	for _, e := range u.entities {
+		val := u.creator(m, e)
+		pk := make([]any, 0, len(m.PrimaryKeys))
+		for _, fd := range m.PrimaryKeys {
+			arg, err := val.Field(fd.GoName)
+			if err != nil {
+				return nil, err
+			}
+			pk = append(pk, arg)
+		}
+		vals := make([]any, 0, len(fields))
+		for _, fd := range fields {
+			arg, err := val.Field(fd.GoName)
+			if err != nil {
+				return nil, err
+			}
+			vals = append(vals, arg)
+		}
+		bu.pks = append(bu.pks, pk)
+		bu.vals = append(bu.vals, vals)
+	}
+	if fd := m.AutoUpdateTime; fd != nil && !slices.Contains(fields, fd) {
+		bu.assigns = append(bu.assigns, Assign(fd.GoName, timeValueOf(fd, u.now())))
+	}
+	bu.where = u.whereWithScope(nil, nil, u.unscoped)
+
+	u.sb.WriteString("UPDATE ")
+	u.quote(m.TableName)
+	if err = u.dialect.buildBulkUpdate(&u.builder, bu); err != nil {
+		return nil, err
+	}
+	u.sb.WriteByte(';')
+	return &Query{
+		SQL:  u.dialect.rebind(u.sb.String()),
+		Args: u.args,
+	}, nil
+}
+
+// updateFields 更新的字段，主键不能更新
+func (u *BulkUpdater[T]) updateFields(m *model.Model) ([]*model.Field, error) {
+	if len(u.fields) == 0 {
+		fields := make([]*model.Field, 0, len(m.Fields))
+		for _, fd := range m.Fields {
+			if fd.PrimaryKey || fd == m.AutoCreateTime || fd == m.AutoUpdateTime || fd == m.SoftDelete {
+				continue
+			}
+			fields = append(fields, fd)
+		}
+		if len(fields) == 0 {
+			return nil, errs.ErrUpdateNoSet
+		}
+		return fields, nil
+	}
+	fields := make([]*model.Field, 0, len(u.fields))
+	for _, name := range u.fields {
+		fd, ok := m.FieldMap[name]
+		if !ok {
+			return nil, errs.NewErrUnknownField(name)
+		}
+		if fd.PrimaryKey {
+			return nil, errs.NewErrUpdatePrimaryKey(name)
+		}
+		fields = append(fields, fd)
+	}
+	return fields, nil
+}
+
+// Exec 执行批量更新，参数数量超过 MaxParams 或者方言的限制时拆分为多条语句
+// 拆分后不在事务中时会开启一个事务，返回结果的 RowsAffected 为各条语句之和
+func (u *BulkUpdater[T]) Exec(ctx context.Context) ExecResult {
+	var hc *Context
+	for _, e := range u.entities {
+		h, ok := any(e).(BeforeUpdateHook)
+		if !ok {
+			continue
+		}
+		if hc == nil {
+			var err error
+			if hc, err = hookContext(ctx, u.core, UPDATE, e); err != nil {
+				return ExecResult{
+					err: err,
+				}
+			}
+		}
+		if err := h.BeforeUpdate(ctx, hc); err != nil {
+			return ExecResult{
+				err: err,
+			}
+		}
+	}
+
+	chunks, err := u.chunks()
+	if err != nil {
+		return ExecResult{
+			err: err,
+		}
+	}
+	if len(chunks) <= 1 {
+		return execWithHandler(ctx, u, u.core, UPDATE, u.updateHandler)
+	}
+	return execChunks(ctx, u.sess, len(chunks), func(ctx context.Context, sess Session, idx int) ExecResult {
+		c := BulkUpdate[T](sess).Entities(chunks[idx]...).Fields(u.fields...)
+		c.unscoped = u.unscoped
+		return execWithHandler(ctx, c, c.core, UPDATE, c.updateHandler)
+	})
+}
+
+// updateHandler 执行成功后调用 AfterUpdate 钩子
+func (u *BulkUpdater[T]) updateHandler(ctx *Context) *Result {
+	res := execHandler(ctx, u.sess)
+	if res.Err != nil {
+		return res
+	}
+	for _, e := range u.entities {
+		if h, ok := any(e).(AfterUpdateHook); ok {
+			if err := h.AfterUpdate(ctx.Ctx, ctx); err != nil {
+				return resultWithErr(res, err)
+			}
+		}
+	}
+	return res
+}
+
+// chunks 按参数数量限制将实体分组
+// 按 CASE WHEN 的形式估算，每行占用 主键数 * (字段数 + 1) + 字段数 个参数
+func (u *BulkUpdater[T]) chunks() ([][]*T, error) {
+	limit := u.maxParams
+	if limit <= 0 {
+		limit = u.dialect.maxParams()
+	}
+	if len(u.entities) == 0 || limit <= 0 {
+		return nil, nil
+	}
+	m, err := u.r.Get(new(T))
+	if err != nil {
+		return nil, err
+	}
+	fields, err := u.updateFields(m)
+	if err != nil {
+		return nil, err
+	}
+	// 自动更新时间占用一个参数
+	size := max((limit-1)/(len(m.PrimaryKeys)*(len(fields)+1)+len(fields)), 1)
+	return chunk(u.entities, size), nil
+}
+
+// buildBulkUpdate 使用 CASE WHEN 为每行设置不同的值
+func (s *standardSQL) buildBulkUpdate(b *builder, bu *bulkUpdate) error {
+	b.sb.WriteString(" SET ")
+	pks := b.model.PrimaryKeys
+	for i, fd := range bu.fields {
+		if i > 0 {
+			b.sb.WriteByte(',')
+		}
+		b.quote(fd.ColName)
+		b.sb.WriteString(" = CASE")
+		if len(pks) == 1 {
+			b.sb.WriteByte(' ')
+			b.quote(pks[0].ColName)
+		}
+		for j, pk := range bu.pks {
+			b.sb.WriteString(" WHEN ")
+			if len(pks) == 1 {
+				b.sb.WriteByte('?')
+				b.addArgs(pk[0])
+			} else {
+				b.buildPKMatch(pk)
+			}
+			b.sb.WriteString(" THEN ?")
+			b.addArgs(bu.vals[j][i])
+		}
+		b.sb.WriteString(" END")
+	}
+	if err := b.buildBulkAssigns(bu.assigns); err != nil {
+		return err
+	}
+
+	b.sb.WriteString(" WHERE ")
+	if len(bu.where) > 0 {
+		b.sb.WriteByte('(')
+	}
+	if len(pks) == 1 {
+		b.quote(pks[0].ColName)
+		b.sb.WriteString(" IN (")
+		for j, pk := range bu.pks {
+			if j > 0 {
+				b.sb.WriteByte(',')
+			}
+			b.sb.WriteByte('?')
+			b.addArgs(pk[0])
+		}
+		b.sb.WriteByte(')')
+	} else {
+		for j, pk := range bu.pks {
+			if j > 0 {
+				b.sb.WriteString(" OR ")
+			}
+			b.sb.WriteByte('(')
+			b.buildPKMatch(pk)
+			b.sb.WriteByte(')')
+		}
+	}
+	if len(bu.where) > 0 {
+		b.sb.WriteString(") AND (")
+		if err := b.buildPredicate(bu.where); err != nil {
+			return err
+		}
+		b.sb.WriteByte(')')
+	}
+	return nil
+}
+
+// buildBulkUpdate 使用 UPDATE ... FROM (VALUES ...) 关联每行的值
+// VALUES 中的参数没有类型，第一行按列类型显式转换
+func (p *postgresDialect) buildBulkUpdate(b *builder, bu *bulkUpdate) error {
+	const alias = "_v"
+	pks := b.model.PrimaryKeys
+	b.sb.WriteString(" SET ")
+	for i, fd := range bu.fields {
+		if i > 0 {
+			b.sb.WriteByte(',')
+		}
+		b.quote(fd.ColName)
+		b.sb.WriteString(" = ")
+		b.quote(alias)
+		b.sb.WriteByte('.')
+		b.quote(fd.ColName)
+	}
+	if err := b.buildBulkAssigns(bu.assigns); err != nil {
+		return err
+	}
+
+	b.sb.WriteString(" FROM (VALUES ")
+	for j := range bu.pks {
+		if j > 0 {
+			b.sb.WriteByte(',')
+		}
+		b.sb.WriteByte('(')
+		args := append(append(make([]any, 0, len(pks)+len(bu.fields)), bu.pks[j]...), bu.vals[j]...)
+		for k, arg := range args {
+			if k > 0 {
+				b.sb.WriteByte(',')
+			}
+			b.sb.WriteByte('?')
+			if j == 0 {
+				fd := bu.fields[max(k-len(pks), 0)]
+				if k < len(pks) {
+					fd = pks[k]
+				}
+				if typ := castType(p.DataTypeOf(fd)); typ != "" {
+					b.sb.WriteString("::")
+					b.sb.WriteString(typ)
+				}
+			}
+			b.addArgs(arg)
+		}
+		b.sb.WriteByte(')')
+	}
+	b.sb.WriteString(") AS ")
+	b.quote(alias)
+	b.sb.WriteByte('(')
+	for k, fd := range pks {
+		if k > 0 {
+			b.sb.WriteByte(',')
+		}
+		b.quote(fd.ColName)
+	}
+	for _, fd := range bu.fields {
+		b.sb.WriteByte(',')
+		b.quote(fd.ColName)
+	}
+	b.sb.WriteString(") WHERE ")
+	for k, fd := range pks {
+		if k > 0 {
+			b.sb.WriteString(" AND ")
+		}
+		b.quote(b.model.TableName)
+		b.sb.WriteByte('.')
+		b.quote(fd.ColName)
+		b.sb.WriteString(" = ")
+		b.quote(alias)
+		b.sb.WriteByte('.')
+		b.quote(fd.ColName)
+	}
+	if len(bu.where) > 0 {
+		b.sb.WriteString(" AND (")
+		if err := b.buildPredicate(bu.where); err != nil {
+			return err
+		}
+		b.sb.WriteByte(')')
+	}
+	return nil
+}
+
+// buildPKMatch 构造复合主键的匹配条件 a = ? AND b = ?
+func (b *builder) buildPKMatch(pk []any) {
+	for k, fd := range b.model.PrimaryKeys {
+		if k > 0 {
+			b.sb.WriteString(" AND ")
+		}
+		b.quote(fd.ColName)
+		b.sb.WriteString(" = ?")
+		b.addArgs(pk[k])
+	}
+}
+
+// buildBulkAssigns 构造所有行统一赋值的列
+func (b *builder) buildBulkAssigns(assigns []Assignment) error {
+	for _, a := range assigns {
+		fd, ok := b.model.FieldMap[a.name]
+		if !ok {
+			return errs.NewErrUnknownField(a.name)
+		}
+		b.sb.WriteByte(',')
+		b.quote(fd.ColName)
+		b.sb.WriteString(" = ")
+		if err := b.buildAssignValue(a.val); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// castType 去掉长度和精度，显式转换为 VARCHAR(n) 会截断超长的值而不是报错
+// CHAR 和 BIT 不带长度时长度为 1，改用不限长度的 BPCHAR 和 VARBIT
+func castType(typ string) string {
+	if i := strings.IndexByte(typ, '('); i >= 0 {
+		if j := strings.IndexByte(typ[i:], ')'); j >= 0 {
+			typ = strings.TrimSpace(typ[:i]) + typ[i+j+1:]
+		}
+	}
+	switch strings.ToUpper(typ) {
+	case "CHAR", "CHARACTER":
+		return "BPCHAR"
+	case "BIT":
+		return "VARBIT"
+	}
+	return typ
+}
@@ -0,0 +1,271 @@
+package orm
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/KNICEX/go-orm/internal/errs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+	"time"
+)
+
+type BulkTypedModel struct {
+	Id   int64
+	Code string `orm:"type=CHAR(2)"`
+	Body string `orm:"type=TEXT"`
+	Name string
+}
+
+type BulkCompositeModel struct {
+	TenantId int64  `orm:"pk"`
+	Code     string `orm:"pk"`
+	Position int64
+}
+
+func TestBulkUpdater_Build(t *testing.T) {
+	// 超过默认长度 255 的值不能被截断
+	longName := strings.Repeat("a", 300)
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	mysql, err := OpenDB(nil, DBWithDialect(DialectMySQL), DBWithClock(func() time.Time {
+		return now
+	}))
+	require.NoError(t, err)
+	pg, err := OpenDB(nil, DBWithDialect(DialectPostgres), DBWithClock(func() time.Time {
+		return now
+	}))
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name      string
+		builder   SqlBuilder
+		wantQuery *Query
+		wantErr   error
+	}{
+		{
+			name: "case when",
+			builder: BulkUpdate[TestModel](mysql).Entities(
+				&TestModel{Id: 1, FirstName: "a", LastName: "b"},
+				&TestModel{Id: 2, FirstName: "c", LastName: "d"},
+			),
+			wantQuery: &Query{
+				SQL: "UPDATE `test_model` SET " +
+					"`first_name` = CASE `id` WHEN ? THEN ? WHEN ? THEN ? END," +
+					"`last_name` = CASE `id` WHEN ? THEN ? WHEN ? THEN ? END " +
+					"WHERE `id` IN (?,?);",
+				Args: []any{int64(1), "a", int64(2), "c", int64(1), "b", int64(2), "d", int64(1), int64(2)},
+			},
+		},
+		{
+			name: "fields",
+			builder: BulkUpdate[TestModel](mysql).Entities(
+				&TestModel{Id: 1, FirstName: "a", LastName: "b"},
+				&TestModel{Id: 2, FirstName: "c", LastName: "d"},
+			).Fields("LastName"),
+			wantQuery: &Query{
+				SQL:  "UPDATE `test_model` SET `last_name` = CASE `id` WHEN ? THEN ? WHEN ? THEN ? END WHERE `id` IN (?,?);",
+				Args: []any{int64(1), "b", int64(2), "d", int64(1), int64(2)},
+			},
+		},
+		{
+			name: "composite primary key",
+			builder: BulkUpdate[BulkCompositeModel](mysql).Entities(
+				&BulkCompositeModel{TenantId: 1, Code: "a", Position: 3},
+				&BulkCompositeModel{TenantId: 1, Code: "b", Position: 4},
+			),
+			wantQuery: &Query{
+				SQL: "UPDATE `bulk_composite_model` SET `position` = CASE " +
+					"WHEN `tenant_id` = ? AND `code` = ? THEN ? WHEN `tenant_id` = ? AND `code` = ? THEN ? END " +
+					"WHERE (`tenant_id` = ? AND `code` = ?) OR (`tenant_id` = ? AND `code` = ?);",
+				Args: []any{int64(1), "a", int64(3), int64(1), "b", int64(4), int64(1), "a", int64(1), "b"},
+			},
+		},
+		{
+			name: "soft delete and auto update time",
+			builder: BulkUpdate[TimestampModel](mysql).Entities(
+				&TimestampModel{Id: 1, Name: "a"},
+			).Fields("Name"),
+			wantQuery: &Query{
+				SQL:  "UPDATE `timestamp_model` SET `name` = CASE `id` WHEN ? THEN ? END,`updated_at` = ? WHERE `id` IN (?);",
+				Args: []any{int64(1), "a", &now, int64(1)},
+			},
+		},
+		{
+			name:    "soft delete",
+			builder: BulkUpdate[SoftDeleteModel](mysql).Entities(&SoftDeleteModel{Id: 1, Name: "a"}),
+			wantQuery: &Query{
+				SQL:  "UPDATE `soft_delete_model` SET `name` = CASE `id` WHEN ? THEN ? END WHERE (`id` IN (?)) AND (`deleted_at` IS NULL);",
+				Args: []any{int64(1), "a", int64(1)},
+			},
+		},
+		{
+			name: "postgres",
+			builder: BulkUpdate[TestModel](pg).Entities(
+				&TestModel{Id: 1, FirstName: "a", LastName: "b"},
+				&TestModel{Id: 2, FirstName: "c", LastName: "d"},
+			),
+			wantQuery: &Query{
+				SQL: `UPDATE "test_model" SET "first_name" = "_v"."first_name","last_name" = "_v"."last_name" ` +
+					`FROM (VALUES ($1::BIGINT,$2::VARCHAR,$3::VARCHAR),($4,$5,$6)) AS "_v"("id","first_name","last_name") ` +
+					`WHERE "test_model"."id" = "_v"."id";`,
+				Args: []any{int64(1), "a", "b", int64(2), "c", "d"},
+			},
+		},
+		{
+			name: "postgres without length",
+			builder: BulkUpdate[BulkTypedModel](pg).Entities(
+				&BulkTypedModel{Id: 1, Code: "cn", Body: "body", Name: longName},
+			),
+			wantQuery: &Query{
+				SQL: `UPDATE "bulk_typed_model" SET "code" = "_v"."code","body" = "_v"."body","name" = "_v"."name" ` +
+					`FROM (VALUES ($1::BIGINT,$2::BPCHAR,$3::TEXT,$4::VARCHAR)) AS "_v"("id","code","body","name") ` +
+					`WHERE "bulk_typed_model"."id" = "_v"."id";`,
+				Args: []any{int64(1), "cn", "body", longName},
+			},
+		},
+		{
+			name: "postgres soft delete",
+			builder: BulkUpdate[SoftDeleteModel](pg).Entities(
+				&SoftDeleteModel{Id: 1, Name: "a"},
+			),
+			wantQuery: &Query{
+				SQL: `UPDATE "soft_delete_model" SET "name" = "_v"."name" ` +
+					`FROM (VALUES ($1::BIGINT,$2::VARCHAR)) AS "_v"("id","name") ` +
+					`WHERE "soft_delete_model"."id" = "_v"."id" AND ("deleted_at" IS NULL);`,
+				Args: []any{int64(1), "a"},
+			},
+		},
+		{
+			name:    "no entities",
+			builder: BulkUpdate[TestModel](mysql),
+			wantErr: errs.ErrUpdateNoSet,
+		},
+		{
+			name:    "unknown field",
+			builder: BulkUpdate[TestModel](mysql).Entities(&TestModel{Id: 1}).Fields("Unknown"),
+			wantErr: errs.NewErrUnknownField("Unknown"),
+		},
+		{
+			name:    "primary key",
+			builder: BulkUpdate[TestModel](mysql).Entities(&TestModel{Id: 1}).Fields("Id"),
+			wantErr: errs.NewErrUpdatePrimaryKey("Id"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			q, err := tc.builder.Build()
+			assert.Equal(t, tc.wantErr, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.wantQuery, q)
+		})
+	}
+}
+
+func TestBulkUpdater_Exec(t *testing.T) {
+	t.Run("chunk", func(t *testing.T) {
+		mockDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		require.NoError(t, err)
+		db, err := OpenDB(mockDB, DBWithDialect(DialectMySQL))
+		require.NoError(t, err)
+
+		// 每行占用 3 个参数
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE `test_model` SET `first_name` = CASE `id` WHEN ? THEN ? WHEN ? THEN ? END WHERE `id` IN (?,?);").
+			WithArgs(int64(1), "a", int64(2), "b", int64(1), int64(2)).
+			WillReturnResult(driver.RowsAffected(2))
+		mock.ExpectExec("UPDATE `test_model` SET `first_name` = CASE `id` WHEN ? THEN ? END WHERE `id` IN (?);").
+			WithArgs(int64(3), "c", int64(3)).
+			WillReturnResult(driver.RowsAffected(1))
+		mock.ExpectCommit()
+
+		res := BulkUpdate[TestModel](db).Entities(
+			&TestModel{Id: 1, FirstName: "a"},
+			&TestModel{Id: 2, FirstName: "b"},
+			&TestModel{Id: 3, FirstName: "c"},
+		).Fields("FirstName").MaxParams(7).Exec(context.Background())
+		affected, err := res.RowsAffected()
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), affected)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("rollback", func(t *testing.T) {
+		mockDB, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		db, err := OpenDB(mockDB, DBWithDialect(DialectMySQL))
+		require.NoError(t, err)
+
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE `test_model` .*").WillReturnResult(driver.RowsAffected(1))
+		mock.ExpectExec("UPDATE `test_model` .*").WillReturnError(errors.New("db err"))
+		mock.ExpectRollback()
+
+		res := BulkUpdate[TestModel](db).Entities(&TestModel{Id: 1}, &TestModel{Id: 2}).
+			Fields("FirstName").MaxParams(4).Exec(context.Background())
+		assert.Equal(t, errors.New("db err"), res.Err())
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("hooks", func(t *testing.T) {
+		mockDB, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		db, err := OpenDB(mockDB, DBWithDialect(DialectMySQL))
+		require.NoError(t, err)
+
+		mock.ExpectExec("UPDATE `hook_model` .*").
+			WithArgs(int64(1), "TOM", int64(1)).
+			WillReturnResult(driver.RowsAffected(1))
+		entity := &HookModel{Id: 1, Name: "tom"}
+		require.NoError(t, BulkUpdate[HookModel](db).Entities(entity).Exec(context.Background()).Err())
+		assert.Equal(t, []string{"BeforeUpdate", "AfterUpdate"}, entity.calls)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("sqlite", func(t *testing.T) {
+		db := memoryWithDB("bulk_update", t, DBWithDialect(DialectSQLite3))
+		ctx := context.Background()
+		require.NoError(t, db.AutoMigrate(ctx, &BulkCompositeModel{}))
+		vals := []*BulkCompositeModel{
+			{TenantId: 1, Code: "a", Position: 1},
+			{TenantId: 1, Code: "b", Position: 2},
+			{TenantId: 2, Code: "a", Position: 3},
+		}
+		require.NoError(t, NewInserter[BulkCompositeModel](db).Values(vals...).Exec(ctx).Err())
+
+		vals[0].Position, vals[1].Position = 20, 10
+		res := BulkUpdate[BulkCompositeModel](db).Entities(vals[:2]...).MaxParams(6).Exec(ctx)
+		affected, err := res.RowsAffected()
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), affected)
+
+		list, err := NewSelector[BulkCompositeModel](db).
+			OrderBy(Col("TenantId"), Col("Code")).GetMulti(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []*BulkCompositeModel{
+			{TenantId: 1, Code: "a", Position: 20},
+			{TenantId: 1, Code: "b", Position: 10},
+			{TenantId: 2, Code: "a", Position: 3},
+		}, list)
+	})
+}
+
+func TestCastType(t *testing.T) {
+	testCases := map[string]string{
+		"VARCHAR(255)":                "VARCHAR",
+		"NUMERIC(10,2)":               "NUMERIC",
+		"TIMESTAMP(3) WITH TIME ZONE": "TIMESTAMP WITH TIME ZONE",
+		"CHAR(2)":                     "BPCHAR",
+		"BIT(8)":                      "VARBIT",
+		"TEXT":                        "TEXT",
+		"":                            "",
+	}
+	for typ, want := range testCases {
+		assert.Equal(t, want, castType(typ), typ)
+	}
+}
@@ -0,0 +1,99 @@
+package orm
+
+import "github.com/KNICEX/go-orm/internal/errs"
+
+// CaseExpr CASE WHEN 表达式
+// 用法： Case().When(Col("Amount").Gt(100), "big").Else("small").As("bucket")
+// 作为 Updater.Set 的参数时，To 指定更新的字段，例如 Set(Case().When(...).Else(...).To("Status"))
+type CaseExpr struct {
+	whens []caseWhen
+	els   Expression
+	alias string
+	desc  bool
+	// field 作为 SET 的列时更新的字段
+	field string
+}
+
+type caseWhen struct {
+	cond Predicate
+	val  Expression
+}
+
+func Case() CaseExpr {
+	return CaseExpr{}
+}
+
+func (c CaseExpr) expr()                  {}
+func (c CaseExpr) selectedAlias() string  { return c.alias }
+func (c CaseExpr) target() TableReference { return nil }
+func (c CaseExpr) fieldName() string      { return "" }
+func (c CaseExpr) orderAble()             {}
+func (c CaseExpr) groupAble()             {}
+func (c CaseExpr) setAble()               {}
+
+// When 满足条件时的值，val 可以是值或者表达式
+func (c CaseExpr) When(cond Predicate, val any) CaseExpr {
+	whens := make([]caseWhen, 0, len(c.whens)+1)
+	whens = append(whens, c.whens...)
+	c.whens = append(whens, caseWhen{cond: cond, val: valueOf(val)})
+	return c
+}
+
+// Else 所有条件都不满足时的值，不指定时为 NULL
+func (c CaseExpr) Else(val any) CaseExpr {
+	c.els = valueOf(val)
+	return c
+}
+
+func (c CaseExpr) As(alias string) CaseExpr {
+	c.alias = alias
+	return c
+}
+
+// To 作为 Updater.Set 的参数时更新的字段
+func (c CaseExpr) To(field string) CaseExpr {
+	c.field = field
+	return c
+}
+
+func (c CaseExpr) Asc() CaseExpr {
+	c.desc = false
+	return c
+}
+
+func (c CaseExpr) Desc() CaseExpr {
+	c.desc = true
+	return c
+}
+
+func (c CaseExpr) Eq(arg any) Predicate { return compare(c, opEq, arg) }
+func (c CaseExpr) Gt(arg any) Predicate { return compare(c, opGt, arg) }
+func (c CaseExpr) Lt(arg any) Predicate { return compare(c, opLt, arg) }
+func (c CaseExpr) Ge(arg any) Predicate { return compare(c, opGe, arg) }
+func (c CaseExpr) Le(arg any) Predicate { return compare(c, opLe, arg) }
+
+// buildCase 构造 CASE WHEN cond THEN val ... ELSE val END
+func (b *builder) buildCase(c CaseExpr) error {
+	if len(c.whens) == 0 {
+		return errs.ErrCaseNoWhen
+	}
+	b.sb.WriteString("CASE")
+	for _, w := range c.whens {
+		b.sb.WriteString(" WHEN ")
+		if err := b.buildExpression(w.cond); err != nil {
+			return err
+		}
+		b.sb.WriteString(" THEN ")
+		if err := b.buildExpression(w.val); err != nil {
+			return err
+		}
+	}
+	if c.els != nil {
+		b.sb.WriteString(" ELSE ")
+		if err := b.buildExpression(c.els); err != nil {
+			return err
+		}
+	}
+	b.sb.WriteString(" END")
+	return nil
+}
@@ -0,0 +1,156 @@
+package orm
+
+import (
+	"context"
+	"github.com/KNICEX/go-orm/internal/errs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestCase_Build(t *testing.T) {
+	db, err := OpenDB(nil, DBWithDialect(DialectMySQL))
+	require.NoError(t, err)
+	pg, err := OpenDB(nil, DBWithDialect(DialectPostgres))
+	require.NoError(t, err)
+	bucket := Case().When(Col("Amount").Gt(100), "big").When(Col("Amount").Gt(10), "medium").Else("small")
+
+	testCases := []struct {
+		name      string
+		builder   SqlBuilder
+		wantQuery *Query
+		wantErr   error
+	}{
+		{
+			name:    "select",
+			builder: NewSelector[PredicateOrder](db).Select(Col("Id"), bucket.As("bucket")),
+			wantQuery: &Query{
+				SQL: "SELECT `id`,CASE WHEN `amount` > ? THEN ? WHEN `amount` > ? THEN ? ELSE ? END AS `bucket` " +
+					"FROM `predicate_order`;",
+				Args: []any{100, "big", 10, "medium", "small"},
+			},
+		},
+		{
+			name: "group by having order by alias",
+			builder: NewSelector[PredicateOrder](db).
+				Select(bucket.As("bucket"), Count("Id").As("cnt")).
+				Where(Col("UserId").Eq(1)).
+				GroupBy(Col("bucket")).
+				Having(Col("cnt").Gt(1).And(Col("bucket").Neq("small"))).
+				OrderBy(Col("cnt").Desc(), bucket.As("bucket")),
+			wantQuery: &Query{
+				SQL: "SELECT CASE WHEN `amount` > ? THEN ? WHEN `amount` > ? THEN ? ELSE ? END AS `bucket`,COUNT(`id`) AS `cnt` " +
+					"FROM `predicate_order` WHERE `user_id` = ? GROUP BY `bucket` " +
+					"HAVING (`cnt` > ?) AND (`bucket` <> ?) ORDER BY `cnt` DESC,`bucket` ASC;",
+				Args: []any{100, "big", 10, "medium", "small", 1, 1, "small"},
+			},
+		},
+		{
+			name: "postgres having expands alias",
+			builder: NewSelector[PredicateOrder](pg).
+				Select(Col("UserId"), Sum("Amount").As("total")).
+				GroupBy(Col("UserId")).
+				Having(Col("total").Gt(100)).
+				OrderBy(Col("total").Desc()),
+			wantQuery: &Query{
+				SQL: `SELECT "user_id",SUM("amount") AS "total" FROM "predicate_order" GROUP BY "user_id" ` +
+					`HAVING SUM("amount") > $1 ORDER BY "total" DESC;`,
+				Args: []any{100},
+			},
+		},
+		{
+			name: "where",
+			builder: NewSelector[PredicateOrder](db).
+				Where(Case().When(Col("UserId").Eq(0), Col("Amount")).Else(Col("Amount").Mul(2)).Gt(10)),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `predicate_order` WHERE CASE WHEN `user_id` = ? THEN `amount` ELSE `amount` * ? END > ?;",
+				Args: []any{0, 2, 10},
+			},
+		},
+		{
+			name: "update set",
+			builder: NewUpdater[PredicateOrder](db).
+				Set(Case().When(Col("Amount").Lt(0), 0).Else(Col("Amount")).To("Amount")).
+				Where(Col("UserId").Eq(1)),
+			wantQuery: &Query{
+				SQL:  "UPDATE `predicate_order` SET `amount` = CASE WHEN `amount` < ? THEN ? ELSE `amount` END WHERE `user_id` = ?;",
+				Args: []any{0, 0, 1},
+			},
+		},
+		{
+			name: "update set with alias",
+			builder: NewUpdater[PredicateOrder](db).
+				Set(Case().When(Col("UserId").Eq(1), 10).As("x").To("Amount")),
+			wantQuery: &Query{
+				SQL:  "UPDATE `predicate_order` SET `amount` = CASE WHEN `user_id` = ? THEN ? END;",
+				Args: []any{1, 10},
+			},
+		},
+		{
+			name: "update assign",
+			builder: NewUpdater[PredicateOrder](pg).
+				Set(Assign("Amount", Case().When(Col("UserId").Eq(1), 10))),
+			wantQuery: &Query{
+				SQL:  `UPDATE "predicate_order" SET "amount" = CASE WHEN "user_id" = $1 THEN $2 END;`,
+				Args: []any{1, 10},
+			},
+		},
+		{
+			name:    "update without field",
+			builder: NewUpdater[PredicateOrder](db).Set(Case().When(Col("UserId").Eq(1), 10).As("Amount")),
+			wantErr: errs.NewErrUnknownField(""),
+		},
+		{
+			name:    "no when",
+			builder: NewSelector[PredicateOrder](db).Select(Case().Else(1).As("x")),
+			wantErr: errs.ErrCaseNoWhen,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			q, err := tc.builder.Build()
+			assert.Equal(t, tc.wantErr, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.wantQuery, q)
+		})
+	}
+}
+
+func TestCase_SQLite(t *testing.T) {
+	db := memoryWithDB("case", t, DBWithDialect(DialectSQLite3))
+	ctx := context.Background()
+	require.NoError(t, db.AutoMigrate(ctx, &PredicateOrder{}))
+	require.NoError(t, NewInserter[PredicateOrder](db).Values(
+		&PredicateOrder{Id: 1, UserId: 1, Amount: 500},
+		&PredicateOrder{Id: 2, UserId: 1, Amount: 50},
+		&PredicateOrder{Id: 3, UserId: 2, Amount: 5},
+		&PredicateOrder{Id: 4, UserId: 2, Amount: 1},
+	).Exec(ctx).Err())
+
+	type bucketCount struct {
+		Bucket string
+		Cnt    int64
+	}
+	q, err := NewSelector[PredicateOrder](db).
+		Select(Case().When(Col("Amount").Gt(10), "big").Else("small").As("bucket"), Count("Id").As("cnt")).
+		GroupBy(Col("bucket")).
+		Having(Col("cnt").Ge(1)).
+		OrderBy(Col("cnt").Desc(), Col("bucket")).Build()
+	require.NoError(t, err)
+	res, err := RawQuery[bucketCount](db, q.SQL, q.Args...).GetMulti(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []*bucketCount{{Bucket: "big", Cnt: 2}, {Bucket: "small", Cnt: 2}}, res)
+
+	require.NoError(t, NewUpdater[PredicateOrder](db).
+		Set(Case().When(Col("Amount").Lt(10), 10).Else(Col("Amount")).To("Amount")).Exec(ctx).Err())
+	list, err := NewSelector[PredicateOrder](db).OrderBy(Col("Id")).GetMulti(ctx)
+	require.NoError(t, err)
+	amounts := make([]int64, 0, len(list))
+	for _, o := range list {
+		amounts = append(amounts, o.Amount)
+	}
+	assert.Equal(t, []int64{500, 50, 10, 10}, amounts)
+}
@@ -1,5 +1,7 @@
 package orm
 
+import "reflect"
+
 const (
 	asc  = "ASC"
 	desc = "DESC"
@@ -106,19 +108,108 @@ func (c Column) Like(arg any) Predicate {
 	}
 }
 
+// In 参数展开为 IN (?,?,?)，只传入一个切片时展开切片，列表为空时条件恒为假
+// 用法： Col("Id").In(1, 2, 3), Col("Id").In(ids)
 func (c Column) In(args ...any) Predicate {
+	vals := flatten(args)
+	if len(vals) == 0 {
+		return Raw("1 = 0").AsPredicate()
+	}
 	return Predicate{
 		left:  c,
 		op:    opIn,
-		right: valueOf(args),
+		right: valueList{vals: vals},
 	}
 }
 
+// NotIn 列表为空时条件恒为真
 func (c Column) NotIn(args ...any) Predicate {
+	vals := flatten(args)
+	if len(vals) == 0 {
+		return Raw("1 = 1").AsPredicate()
+	}
+	return Predicate{
+		left:  c,
+		op:    opNotIn,
+		right: valueList{vals: vals},
+	}
+}
+
+// InQuery 子查询的参数按出现的位置合并到外层语句
+// 用法： Col("Id").InQuery(NewSelector[Order](db).Select(Col("UserId")))
+func (c Column) InQuery(sub SqlBuilder) Predicate {
+	return Predicate{
+		left:  c,
+		op:    opIn,
+		right: subQueryExpr{s: sub},
+	}
+}
+
+func (c Column) NotInQuery(sub SqlBuilder) Predicate {
 	return Predicate{
 		left:  c,
 		op:    opNotIn,
-		right: valueOf(args),
+		right: subQueryExpr{s: sub},
+	}
+}
+
+// Neq 不等于，使用 <>
+func (c Column) Neq(arg any) Predicate {
+	return Predicate{
+		left:  c,
+		op:    opNeq,
+		right: valueOf(arg),
+	}
+}
+
+func (c Column) NotLike(arg any) Predicate {
+	return Predicate{
+		left:  c,
+		op:    opNotLike,
+		right: valueOf(arg),
+	}
+}
+
+// ILike 忽略大小写的 LIKE，不支持 ILIKE 的方言使用 LOWER(col) LIKE LOWER(?)
+func (c Column) ILike(arg any) Predicate {
+	return Predicate{
+		left:  c,
+		op:    opILike,
+		right: valueOf(arg),
+	}
+}
+
+// Between 闭区间 BETWEEN ? AND ?
+// 用法： Col("Age").Between(18, 30)
+func (c Column) Between(low, high any) Predicate {
+	return Predicate{
+		left:  c,
+		op:    opBetween,
+		right: betweenRange{low: valueOf(low), high: valueOf(high)},
+	}
+}
+
+func (c Column) NotBetween(low, high any) Predicate {
+	return Predicate{
+		left:  c,
+		op:    opNotBetween,
+		right: betweenRange{low: valueOf(low), high: valueOf(high)},
+	}
+}
+
+// IsNull
+// 用法： Col("DeletedAt").IsNull()
+func (c Column) IsNull() Predicate {
+	return Predicate{
+		left: c,
+		op:   opIsNull,
+	}
+}
+
+func (c Column) IsNotNull() Predicate {
+	return Predicate{
+		left: c,
+		op:   opIsNotNull,
 	}
 }
 
@@ -136,3 +227,22 @@ func (c Column) Desc() Column {
 		table: c.table,
 	}
 }
+
+// flatten 只有一个参数并且是切片时，展开为切片中的元素，[]byte 作为一个值
+func flatten(args []any) []any {
+	if len(args) != 1 {
+		return args
+	}
+	if _, ok := args[0].([]byte); ok {
+		return args
+	}
+	val := reflect.ValueOf(args[0])
+	if val.Kind() != reflect.Slice {
+		return args
+	}
+	res := make([]any, 0, val.Len())
+	for i := 0; i < val.Len(); i++ {
+		res = append(res, val.Index(i).Interface())
+	}
+	return res
+}
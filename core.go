@@ -3,6 +3,7 @@ package orm
 import (
 	"github.com/KNICEX/go-orm/internal/valuer"
 	"github.com/KNICEX/go-orm/model"
+	"time"
 )
 
 type core struct {
@@ -10,6 +11,10 @@ type core struct {
 	dialect Dialect
 	creator valuer.Creator
 	r       model.Registry
+	// 时钟，用于自动维护时间字段
+	clock func() time.Time
+	// 分页游标的签名密钥
+	cursorSecret []byte
 
 	middlewares []Middleware
 }
@@ -0,0 +1,137 @@
+package orm
+
+import "github.com/KNICEX/go-orm/internal/errs"
+
+// cte WITH 子句中的一个公用表表达式
+type cte struct {
+	name      string
+	s         SqlBuilder
+	recursive bool
+}
+
+// subQuerySource 可以提供查询列的语句，用于解析 CTE 的列名
+type subQuerySource interface {
+	AsSubQuery(alias string) SubQuery
+}
+
+// With 添加公用表表达式，之后可以通过 CTE(name) 在 From 和 Join 中引用
+// 用法： NewSelector[Order](db).With("big", sub).From(CTE("big"))
+func (s *Selector[T]) With(name string, sub SqlBuilder) *Selector[T] {
+	s.ctes = append(s.ctes, cte{name: name, s: sub})
+	return s
+}
+
+// WithRecursive 添加递归的公用表表达式，sub 中可以通过 CTE(name) 引用自身
+func (s *Selector[T]) WithRecursive(name string, sub SqlBuilder) *Selector[T] {
+	s.ctes = append(s.ctes, cte{name: name, s: sub, recursive: true})
+	return s
+}
+
+// CTETable 按名字引用 WITH 中定义的公用表表达式
+// 列名按 CTE 语句的查询列解析，无法解析时按当前模型的字段解析
+type CTETable struct {
+	name  string
+	alias string
+}
+
+func CTE(name string) CTETable {
+	return CTETable{name: name}
+}
+
+// tableAlias 没有别名时使用 CTE 的名字限定列
+func (c CTETable) tableAlias() string {
+	if c.alias != "" {
+		return c.alias
+	}
+	return c.name
+}
+
+func (c CTETable) Col(name string) Column {
+	return Column{
+		table: c,
+		name:  name,
+	}
+}
+
+func (c CTETable) As(alias string) CTETable {
+	c.alias = alias
+	return c
+}
+
+func (c CTETable) Join(right TableReference) *JoinBuilder {
+	return &JoinBuilder{
+		left:  c,
+		right: right,
+		typ:   innerJoin,
+	}
+}
+
+func (c CTETable) LeftJoin(right TableReference) *JoinBuilder {
+	return &JoinBuilder{
+		left:  c,
+		right: right,
+		typ:   leftJoin,
+	}
+}
+
+func (c CTETable) RightJoin(right TableReference) *JoinBuilder {
+	return &JoinBuilder{
+		left:  c,
+		right: right,
+		typ:   rightJoin,
+	}
+}
+
+// buildWith 构造 WITH [RECURSIVE] name AS (...)，参数排在主查询之前
+func (b *builder) buildWith(ctes []cte) error {
+	if len(ctes) == 0 {
+		return nil
+	}
+	if !b.dialect.supportCTE() {
+		return errs.ErrUnsupportedCTE
+	}
+	b.ctes = ctes
+	b.sb.WriteString("WITH ")
+	for _, c := range ctes {
+		// RECURSIVE 作用于整个 WITH 子句
+		if c.recursive {
+			b.sb.WriteString("RECURSIVE ")
+			break
+		}
+	}
+	for i, c := range ctes {
+		if i > 0 {
+			b.sb.WriteByte(',')
+		}
+		b.quote(c.name)
+		b.sb.WriteString(" AS ")
+		if err := b.buildSubQueryExpr(c.s); err != nil {
+			return err
+		}
+	}
+	b.sb.WriteByte(' ')
+	return nil
+}
+
+func (b *builder) buildCTETable(t CTETable) {
+	b.quote(t.name)
+	if t.alias != "" {
+		b.sb.WriteString(" AS ")
+		b.quote(t.alias)
+	}
+}
+
+// cteColName 按 CTE 语句的查询列解析列名
+func (b *builder) cteColName(t CTETable, fd string) (string, error) {
+	for _, c := range b.ctes {
+		if c.name != t.name {
+			continue
+		}
+		if src, ok := c.s.(subQuerySource); ok {
+			return b.colName(src.AsSubQuery(t.name), fd)
+		}
+		break
+	}
+	// 递归 CTE 内部引用自身，或者语句没有查询列
+	return b.colName(nil, fd)
+}
@@ -0,0 +1,177 @@
+package orm
+
+import (
+	"context"
+	"github.com/KNICEX/go-orm/internal/errs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+type CteCategory struct {
+	Id       int64
+	ParentId int64
+	Name     string
+}
+
+func TestCTE_Build(t *testing.T) {
+	db, err := OpenDB(nil, DBWithDialect(DialectMySQL))
+	require.NoError(t, err)
+	pg, err := OpenDB(nil, DBWithDialect(DialectPostgres))
+	require.NoError(t, err)
+	mysql57, err := OpenDB(nil, DBWithDialect(DialectMySQL57))
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name      string
+		builder   SqlBuilder
+		wantQuery *Query
+		wantErr   error
+	}{
+		{
+			name: "from cte",
+			builder: NewSelector[PredicateOrder](db).
+				With("big", NewSelector[PredicateOrder](db).Where(Col("Amount").Gt(100))).
+				From(CTE("big")).Where(CTE("big").Col("UserId").Eq(1)),
+			wantQuery: &Query{
+				SQL: "WITH `big` AS (SELECT * FROM `predicate_order` WHERE `amount` > ?) " +
+					"SELECT * FROM `big` WHERE `big`.`user_id` = ?;",
+				Args: []any{100, 1},
+			},
+		},
+		{
+			name: "select alias",
+			builder: NewSelector[PredicateOrder](db).
+				With("total", NewSelector[PredicateOrder](db).
+					Select(Col("UserId"), Sum("Amount").As("amt")).GroupBy(Col("UserId"))).
+				Select(CTE("total").Col("UserId"), CTE("total").Col("amt")).
+				From(CTE("total")).Where(CTE("total").Col("amt").Ge(10)),
+			wantQuery: &Query{
+				SQL: "WITH `total` AS (SELECT `user_id`,SUM(`amount`) AS `amt` FROM `predicate_order` GROUP BY `user_id`) " +
+					"SELECT `total`.`user_id`,`total`.`amt` FROM `total` WHERE `total`.`amt` >= ?;",
+				Args: []any{10},
+			},
+		},
+		{
+			name: "join cte",
+			builder: func() SqlBuilder {
+				big := CTE("big").As("b")
+				o := TableOf(&PredicateOrder{}).As("o")
+				return NewSelector[PredicateOrder](pg).
+					With("big", NewSelector[PredicateOrder](pg).Select(Col("UserId")).Where(Col("Amount").Gt(100))).
+					Select(o.Col("Id")).
+					From(o.Join(big).On(o.Col("UserId").Eq(big.Col("UserId")))).
+					Where(o.Col("Amount").Lt(10))
+			}(),
+			wantQuery: &Query{
+				SQL: `WITH "big" AS (SELECT "user_id" FROM "predicate_order" WHERE "amount" > $1) ` +
+					`SELECT "o"."id" FROM ("predicate_order" AS "o" INNER JOIN "big" AS "b" ON "o"."user_id" = "b"."user_id") ` +
+					`WHERE "o"."amount" < $2;`,
+				Args: []any{100, 10},
+			},
+		},
+		{
+			name: "multiple recursive",
+			builder: NewSelector[CteCategory](pg).
+				With("root", NewSelector[CteCategory](pg).Where(Col("ParentId").Eq(0))).
+				WithRecursive("tree", RawQuery[CteCategory](pg,
+					`SELECT * FROM "root" UNION ALL SELECT c.* FROM "cte_category" c JOIN "tree" t ON c.parent_id = t.id`)).
+				From(CTE("tree")).Where(CTE("tree").Col("Name").Like("a%")),
+			wantQuery: &Query{
+				SQL: `WITH RECURSIVE "root" AS (SELECT * FROM "cte_category" WHERE "parent_id" = $1),` +
+					`"tree" AS (SELECT * FROM "root" UNION ALL SELECT c.* FROM "cte_category" c JOIN "tree" t ON c.parent_id = t.id) ` +
+					`SELECT * FROM "tree" WHERE "tree"."name" LIKE $2;`,
+				Args: []any{0, "a%"},
+			},
+		},
+		{
+			name: "unknown field",
+			builder: NewSelector[PredicateOrder](db).
+				With("big", NewSelector[PredicateOrder](db).Select(Col("Id"))).
+				From(CTE("big")).Where(CTE("big").Col("Amount").Gt(1)),
+			wantErr: errs.NewErrUnknownField("Amount"),
+		},
+		{
+			name: "unsupported dialect",
+			builder: NewSelector[PredicateOrder](mysql57).
+				With("big", NewSelector[PredicateOrder](mysql57)).From(CTE("big")),
+			wantErr: errs.ErrUnsupportedCTE,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			q, err := tc.builder.Build()
+			assert.Equal(t, tc.wantErr, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.wantQuery, q)
+		})
+	}
+}
+
+func TestCTE_SQLite(t *testing.T) {
+	db := memoryWithDB("cte", t, DBWithDialect(DialectSQLite3))
+	ctx := context.Background()
+	require.NoError(t, db.AutoMigrate(ctx, &CteCategory{}))
+	require.NoError(t, NewInserter[CteCategory](db).Values(
+		&CteCategory{Id: 1, ParentId: 0, Name: "root"},
+		&CteCategory{Id: 2, ParentId: 1, Name: "a"},
+		&CteCategory{Id: 3, ParentId: 2, Name: "b"},
+		&CteCategory{Id: 4, ParentId: 3, Name: "c"},
+		&CteCategory{Id: 5, ParentId: 0, Name: "other"},
+	).Exec(ctx).Err())
+
+	// 查询 id = 2 的所有子孙节点
+	tree := CTE("tree")
+	list, err := NewSelector[CteCategory](db).
+		WithRecursive("tree", RawQuery[CteCategory](db,
+			"SELECT * FROM `cte_category` WHERE `id` = ? "+
+				"UNION ALL SELECT c.* FROM `cte_category` c JOIN `tree` t ON c.parent_id = t.id", 2)).
+		From(tree).Where(tree.Col("Id").Neq(2)).OrderBy(tree.Col("Id")).GetMulti(ctx)
+	require.NoError(t, err)
+	names := make([]string, 0, len(list))
+	for _, c := range list {
+		names = append(names, c.Name)
+	}
+	assert.Equal(t, []string{"b", "c"}, names)
+}
+
+func TestCTE_Batches(t *testing.T) {
+	db := memoryWithDB("cte_batches", t, DBWithDialect(DialectSQLite3))
+	ctx := context.Background()
+	require.NoError(t, db.AutoMigrate(ctx, &CteCategory{}))
+	require.NoError(t, NewInserter[CteCategory](db).Values(
+		&CteCategory{Id: 1, ParentId: 0, Name: "root"},
+		&CteCategory{Id: 2, ParentId: 1, Name: "a"},
+		&CteCategory{Id: 3, ParentId: 1, Name: "b"},
+		&CteCategory{Id: 4, ParentId: 1, Name: "c"},
+		&CteCategory{Id: 5, ParentId: 0, Name: "other"},
+	).Exec(ctx).Err())
+
+	children := func() *Selector[CteCategory] {
+		return NewSelector[CteCategory](db).
+			With("children", NewSelector[CteCategory](db).Where(Col("ParentId").Eq(1))).
+			From(CTE("children"))
+	}
+
+	var names []string
+	err := children().FindInBatches(ctx, 2, func(batch []*CteCategory) error {
+		for _, c := range batch {
+			names = append(names, c.Name)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c"}, names)
+
+	page, err := children().Paginate(ctx, "", 2)
+	require.NoError(t, err)
+	require.Len(t, page.Items, 2)
+	assert.Equal(t, "a", page.Items[0].Name)
+	page, err = children().Paginate(ctx, page.Next, 2)
+	require.NoError(t, err)
+	require.Len(t, page.Items, 1)
+	assert.Equal(t, "c", page.Items[0].Name)
+}
@@ -2,6 +2,7 @@ package orm
 
 import (
 	"context"
+	"crypto/rand"
 	"database/sql"
 	"database/sql/driver"
 	"errors"
@@ -35,9 +36,11 @@ func Open(driver, dsn string, ops ...DBOption) (*DB, error) {
 func OpenDB(db *sql.DB, opts ...DBOption) (*DB, error) {
 	res := &DB{
 		core: core{
-			creator: valuer.NewUnsafeValue,
-			r:       model.NewRegistry(),
-			dialect: &standardSQL{},
+			creator:      valuer.NewUnsafeValue,
+			r:            model.NewRegistry(),
+			dialect:      &standardSQL{},
+			clock:        time.Now,
+			cursorSecret: randomSecret(),
 		},
 		db: db,
 	}
@@ -47,6 +50,14 @@ func OpenDB(db *sql.DB, opts ...DBOption) (*DB, error) {
 	return res, nil
 }
 
+// DBWithCursorSecret 指定分页游标的签名密钥
+// 默认使用随机密钥，多个实例共享游标或者重启后游标仍然有效时需要指定
+func DBWithCursorSecret(secret []byte) DBOption {
+	return func(db *DB) {
+		db.cursorSecret = secret
+	}
+}
+
 func DBUseReflect() DBOption {
 	return func(db *DB) {
 		db.creator = valuer.NewReflectValue
@@ -71,6 +82,19 @@ func DBWithDialect(d Dialect) DBOption {
 	}
 }
 
+// DBWithClock 指定自动维护 CreatedAt, UpdatedAt, DeletedAt 等时间字段时使用的时钟
+func DBWithClock(clock func() time.Time) DBOption {
+	return func(db *DB) {
+		db.clock = clock
+	}
+}
+
+func randomSecret() []byte {
+	secret := make([]byte, 32)
+	_, _ = rand.Read(secret)
+	return secret
+}
+
 func MustOpen(driver, dsn string, ops ...DBOption) *DB {
 	db, err := Open(driver, dsn, ops...)
 	if err != nil {
@@ -86,6 +110,7 @@ func (db *DB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*Tx, error) {
 	}
 	return &Tx{
 		tx: tx,
+		DB: db,
 	}, nil
 }
 
@@ -103,16 +128,18 @@ func (db *DB) queryRowContext(ctx context.Context, query string, args ...any) *s
 
 func (db *DB) getCore() *core {
 	return &core{
-		dialect:     db.dialect,
-		creator:     db.creator,
-		r:           db.r,
-		middlewares: db.middlewares,
+		dialect:      db.dialect,
+		creator:      db.creator,
+		r:            db.r,
+		clock:        db.clock,
+		cursorSecret: db.cursorSecret,
+		middlewares:  db.middlewares,
 	}
 }
 
 func (db *DB) DoTx(ctx context.Context,
 	fn func(ctx context.Context, tx *Tx) error,
-	opts *sql.TxOptions) error {
+	opts *sql.TxOptions) (err error) {
 	tx, err := db.BeginTx(ctx, opts)
 	if err != nil {
 		return err
@@ -120,8 +147,9 @@ func (db *DB) DoTx(ctx context.Context,
 	panicked := true
 	defer func() {
 		if panicked || err != nil {
-			e := tx.Rollback()
-			err = errs.NewErrFailedToRollback(err, e)
+			if e := tx.Rollback(); e != nil {
+				err = errs.NewErrFailedToRollback(err, e)
+			}
 		} else {
 			err = tx.Commit()
 		}
@@ -2,11 +2,23 @@ package orm
 
 import (
 	"context"
+	"github.com/KNICEX/go-orm/internal/errs"
 )
 
 type Deleter[T any] struct {
 	table string
 	where []Predicate
+	// 模型有软删除字段时，强制物理删除
+	hardDelete bool
+	// 软删除时不追加软删除过滤条件
+	unscoped bool
+	// 携带的实体，用于调用删除钩子
+	entity *T
+	// 多表删除，JOIN 最左边的表是被删除的表
+	using *Join
+	// 只删除排序后的前 limit 行
+	orderBys []OrderAble
+	limit    int
 
 	sess Session
 	builder
@@ -32,7 +44,26 @@ func (d *Deleter[T]) Build() (*Query, error) {
 	}
 	d.model = m
 
-	d.sb.WriteString("DELETE FROM ")
+	// 模型有软删除字段时，删除改为更新软删除字段
+	softDelete := m.SoftDelete != nil && !d.hardDelete
+	if d.using != nil {
+		if len(d.orderBys) > 0 || d.limit > 0 {
+			return nil, errs.ErrMultiTableLimit
+		}
+		if err = d.buildUsing(softDelete); err != nil {
+			return nil, err
+		}
+		d.sb.WriteByte(';')
+		return &Query{
+			SQL:  d.dialect.rebind(d.sb.String()),
+			Args: d.args,
+		}, nil
+	}
+	if softDelete {
+		d.sb.WriteString("UPDATE ")
+	} else {
+		d.sb.WriteString("DELETE FROM ")
+	}
 	// 表名 如果没有指定表名，则使用类型名
 	if d.table == "" {
 		d.quote(m.TableName)
@@ -41,33 +72,121 @@ func (d *Deleter[T]) Build() (*Query, error) {
 		d.sb.WriteString(d.table)
 	}
 
-	// 条件构造
-	if len(d.where) > 0 {
-		d.sb.WriteString(" WHERE ")
-
-		if err := d.buildPredicate(d.where); err != nil {
-			return nil, err
-		}
+	where := d.where
+	// 物理删除时包括已软删除的行
+	unscoped := true
+	if softDelete {
+		d.sb.WriteString(" SET ")
+		d.quote(m.SoftDelete.ColName)
+		d.sb.WriteString(" = ?")
+		d.addArgs(softDeleteValue(m.SoftDelete, d.now()))
+		where = d.whereWithScope(d.where, nil, d.unscoped)
+		unscoped = d.unscoped
+	}
 
+	// 条件构造
+	err = d.buildWhereLimit(where, d.orderBys, d.limit, func(pks []Selectable) SqlBuilder {
+		return limitSelector[T](d.sess, pks, d.where, d.orderBys, d.limit, unscoped)
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	d.sb.WriteByte(';')
 	return &Query{
-		SQL:  d.sb.String(),
+		SQL:  d.dialect.rebind(d.sb.String()),
 		Args: d.args,
 	}, nil
 }
 
+// buildUsing 多表删除，软删除时改为多表更新
+func (d *Deleter[T]) buildUsing(softDelete bool) error {
+	mt, err := d.newMultiTable(*d.using)
+	if err != nil {
+		return err
+	}
+	if !softDelete {
+		mt.where = d.where
+		return d.dialect.buildDeleteJoin(&d.builder, mt)
+	}
+	mt.where = d.whereWithScope(d.where, mt.target, d.unscoped)
+	mt.set = func(qualifier string) error {
+		d.buildSetColumn(qualifier, d.model.SoftDelete.ColName)
+		d.sb.WriteByte('?')
+		d.addArgs(softDeleteValue(d.model.SoftDelete, d.now()))
+		return nil
+	}
+	return d.dialect.buildUpdateJoin(&d.builder, mt)
+}
+
 func (d *Deleter[T]) From(table string) *Deleter[T] {
 	d.table = table
 	return d
 }
 
+// Using 多表删除，JOIN 最左边的表是被删除的表，其余的表用于过滤
+// 用法： Using(o.Join(usr).On(o.Col("UserId").Eq(usr.Col("Id")))).Where(usr.Col("Banned").Eq(true))
+func (d *Deleter[T]) Using(join Join) *Deleter[T] {
+	d.using = &join
+	return d
+}
+
 func (d *Deleter[T]) Where(p Predicate) *Deleter[T] {
 	d.where = append(d.where, p)
 	return d
 }
 
+// OrderBy 和 Limit 一起使用，只删除排序后的前 limit 行
+func (d *Deleter[T]) OrderBy(orderBys ...OrderAble) *Deleter[T] {
+	d.orderBys = orderBys
+	return d
+}
+
+// Limit 最多删除 limit 行，方言不支持时改写为 WHERE pk IN (SELECT pk ... LIMIT limit)
+func (d *Deleter[T]) Limit(limit int) *Deleter[T] {
+	d.limit = limit
+	return d
+}
+
+// HardDelete 物理删除，忽略模型的软删除字段
+func (d *Deleter[T]) HardDelete() *Deleter[T] {
+	d.hardDelete = true
+	return d
+}
+
+// Unscoped 软删除时包括已软删除的行
+func (d *Deleter[T]) Unscoped() *Deleter[T] {
+	d.unscoped = true
+	return d
+}
+
 func (d *Deleter[T]) Exec(ctx context.Context) ExecResult {
-	return exec(ctx, d, d.sess, d.core, DELETE)
+	if d.entity == nil {
+		return exec(ctx, d, d.sess, d.core, DELETE)
+	}
+	if h, ok := any(d.entity).(BeforeDeleteHook); ok {
+		hc, err := hookContext(ctx, d.core, DELETE, d.entity)
+		if err != nil {
+			return ExecResult{
+				err: err,
+			}
+		}
+		if err = h.BeforeDelete(ctx, hc); err != nil {
+			return ExecResult{
+				err: err,
+			}
+		}
+	}
+	return execWithHandler(ctx, d, d.core, DELETE, func(ctx *Context) *Result {
+		res := execHandler(ctx, d.sess)
+		if res.Err != nil {
+			return res
+		}
+		if h, ok := any(d.entity).(AfterDeleteHook); ok {
+			if err := h.AfterDelete(ctx.Ctx, ctx); err != nil {
+				return resultWithErr(res, err)
+			}
+		}
+		return res
+	})
 }
@@ -1,15 +1,22 @@
 package orm
 
 import (
+	"database/sql"
 	"github.com/KNICEX/go-orm/internal/errs"
+	"github.com/KNICEX/go-orm/model"
+	"hash/fnv"
 	"reflect"
+	"regexp"
 	"strconv"
+	"strings"
 )
 
 var (
 	DialectMySQL    = &mysqlDialect{}
 	DialectPostgres = &postgresDialect{}
 	DialectSQLite3  = &sqlite3Dialect{}
+	// DialectMySQL57 MySQL 5.7，不支持窗口函数
+	DialectMySQL57 = &mysqlDialect{v57: true}
 )
 
 var dialectMap = map[string]Dialect{
@@ -23,8 +30,52 @@ type Dialect interface {
 	quoter() byte
 	buildUpsert(sb *builder, upsert *Upsert) error
 	buildOffsetLimit(sb *builder, offset, limit int) error
-	DataTypeOf(typ reflect.Value) string
-	// TableExistSQL 生成的SQL查询的结果为表名，不存在则应该返回空集
+	// rebind 将 SQL 中的 ? 占位符改写为方言对应的占位符
+	rebind(query string) string
+	// supportReturning 是否支持 INSERT ... RETURNING
+	supportReturning() bool
+	// DataTypeOf 字段对应的列类型，无法推断时返回空字符串
+	DataTypeOf(fd *model.Field) string
+	// autoIncrement 自增列的类型和附加定义，inlinePK 为 true 时主键在列定义中声明
+	autoIncrement(fd *model.Field) (typ string, extra string, inlinePK bool)
+	// tableExistSQL 生成的SQL查询的结果为表名，不存在则应该返回空集
+	tableExistSQL(table string) *Query
+	// columnsSQL 按定义顺序查询表的列，结果为 列名, 类型, 是否允许 NULL
+	columnsSQL(table string) *Query
+	// indexesSQL 查询表除主键以外的索引，结果为 索引名, 是否唯一, 列名，同一索引的列按索引中的顺序排列
+	indexesSQL(table string) *Query
+	// normalizeType 将列类型统一为可比较的形式，用于对比模型和数据库中的类型
+	normalizeType(typ string) string
+	// transactionalDDL DDL 是否可以在事务中执行并回滚
+	transactionalDDL() bool
+	// supportRowValues 是否支持 (a, b) > (?, ?) 这样的行值比较
+	supportRowValues() bool
+	// advisoryLock 获取和释放会话级别锁的语句，获取成功时查询结果为 1，不支持时返回 nil
+	advisoryLock(name string) (lock *Query, unlock *Query)
+	// maxParams 单条语句允许的最大参数数量，0 表示不限制
+	maxParams() int
+	// buildBulkUpdate 构造 UPDATE 表名之后的部分，按主键为每行设置不同的值
+	buildBulkUpdate(b *builder, bu *bulkUpdate) error
+	// buildFunc 构造函数表达式，方言可以覆盖函数名或者改用运算符
+	buildFunc(b *builder, f FuncExpr) error
+	// supportILike 是否支持 ILIKE，不支持时使用 LOWER(col) LIKE LOWER(?)
+	supportILike() bool
+	// aliasInHaving HAVING 中是否可以引用查询列的别名，不支持时展开为表达式
+	aliasInHaving() bool
+	// supportWindow 是否支持窗口函数
+	supportWindow() bool
+	// supportCTE 是否支持 WITH 子句
+	supportCTE() bool
+	// supportSetOp 是否支持集合运算 op，all 表示保留重复行
+	supportSetOp(op setOp, all bool) bool
+	// buildUpdateJoin 构造多表 UPDATE
+	buildUpdateJoin(b *builder, mt *multiTable) error
+	// buildDeleteJoin 构造多表 DELETE
+	buildDeleteJoin(b *builder, mt *multiTable) error
+	// supportDMLLimit UPDATE 和 DELETE 是否支持 ORDER BY 和 LIMIT
+	supportDMLLimit() bool
+	// buildLock 构造行锁子句 FOR UPDATE 等
+	buildLock(b *builder, l rowLock) error
 }
 
 type standardSQL struct {
@@ -50,12 +101,175 @@ func (s *standardSQL) buildOffsetLimit(b *builder, offset, limit int) error {
 	return nil
 }
 
-func (s *standardSQL) DataTypeOf(typ reflect.Value) string {
-	panic("not implemented")
+func (s *standardSQL) rebind(query string) string {
+	return query
+}
+
+func (s *standardSQL) supportReturning() bool {
+	return false
+}
+
+func (s *standardSQL) DataTypeOf(fd *model.Field) string {
+	if fd.SQLType != "" {
+		return fd.SQLType
+	}
+	typ := columnType(fd.Typ)
+	switch typ.Kind() {
+	case reflect.Bool:
+		return "BOOLEAN"
+	case reflect.Int8:
+		return "TINYINT"
+	case reflect.Int16:
+		return "SMALLINT"
+	case reflect.Int32:
+		return "INT"
+	case reflect.Int, reflect.Int64:
+		return "BIGINT"
+	case reflect.Uint8:
+		return "TINYINT UNSIGNED"
+	case reflect.Uint16:
+		return "SMALLINT UNSIGNED"
+	case reflect.Uint32:
+		return "INT UNSIGNED"
+	case reflect.Uint, reflect.Uint64:
+		return "BIGINT UNSIGNED"
+	case reflect.Float32:
+		return "FLOAT"
+	case reflect.Float64:
+		return "DOUBLE"
+	case reflect.String:
+		return "VARCHAR(" + strconv.Itoa(sizeOf(fd)) + ")"
+	case reflect.Slice:
+		if typ.Elem().Kind() == reflect.Uint8 {
+			if fd.Size > 0 {
+				return "VARBINARY(" + strconv.Itoa(fd.Size) + ")"
+			}
+			return "BLOB"
+		}
+	case reflect.Struct:
+		if typ == timeType {
+			return "DATETIME"
+		}
+	}
+	return ""
+}
+
+func (s *standardSQL) autoIncrement(fd *model.Field) (string, string, bool) {
+	return s.DataTypeOf(fd), "AUTO_INCREMENT", false
+}
+
+func (s *standardSQL) tableExistSQL(table string) *Query {
+	return &Query{
+		SQL:  "SELECT TABLE_NAME FROM information_schema.TABLES WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ?;",
+		Args: []any{table},
+	}
+}
+
+func (s *standardSQL) columnsSQL(table string) *Query {
+	return &Query{
+		SQL: "SELECT COLUMN_NAME, COLUMN_TYPE, IS_NULLABLE = 'YES' FROM information_schema.COLUMNS " +
+			"WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? ORDER BY ORDINAL_POSITION;",
+		Args: []any{table},
+	}
+}
+
+func (s *standardSQL) indexesSQL(table string) *Query {
+	return &Query{
+		SQL: "SELECT INDEX_NAME, NON_UNIQUE = 0, COLUMN_NAME FROM information_schema.STATISTICS " +
+			"WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? AND INDEX_NAME <> 'PRIMARY' ORDER BY INDEX_NAME, SEQ_IN_INDEX;",
+		Args: []any{table},
+	}
+}
+
+func (s *standardSQL) supportRowValues() bool {
+	return false
+}
+
+func (s *standardSQL) maxParams() int {
+	return 0
+}
+
+func (s *standardSQL) supportILike() bool {
+	return false
+}
+
+func (s *standardSQL) aliasInHaving() bool {
+	return true
+}
+
+func (s *standardSQL) supportWindow() bool {
+	return false
+}
+
+func (s *standardSQL) supportCTE() bool {
+	return false
+}
+
+func (s *standardSQL) supportDMLLimit() bool {
+	return false
+}
+
+// transactionalDDL MySQL 执行 DDL 会隐式提交事务
+func (s *standardSQL) transactionalDDL() bool {
+	return false
+}
+
+func (s *standardSQL) advisoryLock(name string) (*Query, *Query) {
+	return &Query{
+		SQL:  "SELECT GET_LOCK(?, -1);",
+		Args: []any{name},
+	}, &Query{
+		SQL:  "SELECT RELEASE_LOCK(?);",
+		Args: []any{name},
+	}
+}
+
+var (
+	mysqlIntWidth = regexp.MustCompile(`^(tinyint|smallint|mediumint|int|bigint)\(\d+\)`)
+	spaces        = regexp.MustCompile(`\s+`)
+)
+
+// normalizeType MySQL 中 BOOLEAN 实际为 tinyint(1)，5.7 会为整数类型带上显示宽度
+func (s *standardSQL) normalizeType(typ string) string {
+	typ = strings.ToLower(spaces.ReplaceAllString(strings.TrimSpace(typ), " "))
+	switch typ {
+	case "bool", "boolean", "tinyint(1)":
+		return "tinyint(1)"
+	case "double precision", "real":
+		return "double"
+	}
+	typ = strings.Replace(typ, "integer", "int", 1)
+	return mysqlIntWidth.ReplaceAllString(typ, "$1")
 }
 
 type mysqlDialect struct {
 	standardSQL
+	// MySQL 8.0 之前的版本
+	v57 bool
+}
+
+func (s *mysqlDialect) supportRowValues() bool {
+	return true
+}
+
+// supportWindow MySQL 8.0 开始支持窗口函数
+func (s *mysqlDialect) supportWindow() bool {
+	return !s.v57
+}
+
+// supportCTE MySQL 8.0 开始支持 WITH 子句
+func (s *mysqlDialect) supportCTE() bool {
+	return !s.v57
+}
+
+// supportDMLLimit MySQL 的 IN 子查询不支持 LIMIT，只能使用单表 UPDATE 和 DELETE 的 LIMIT
+func (s *mysqlDialect) supportDMLLimit() bool {
+	return true
+}
+
+// maxParams 预处理语句的占位符数量上限
+func (s *mysqlDialect) maxParams() int {
+	return 65535
 }
 
 func (s *mysqlDialect) buildUpsert(b *builder, upsert *Upsert) error {
@@ -71,8 +285,10 @@ func (s *mysqlDialect) buildUpsert(b *builder, upsert *Upsert) error {
 				return errs.NewErrUnknownField(a.name)
 			}
 			b.quote(fd.ColName)
-			b.sb.WriteString(" = ?")
-			b.addArgs(a.val)
+			b.sb.WriteString(" = ")
+			if err := b.buildAssignValue(a.val); err != nil {
+				return err
+			}
 		case Column:
 			fd, ok := b.model.FieldMap[a.name]
 			if !ok {
@@ -94,6 +310,96 @@ type sqlite3Dialect struct {
 	standardSQL
 }
 
+// supportRowValues SQLite 3.15 开始支持行值
+func (s *sqlite3Dialect) supportRowValues() bool {
+	return true
+}
+
+// supportWindow SQLite 3.25 开始支持窗口函数
+func (s *sqlite3Dialect) supportWindow() bool {
+	return true
+}
+
+func (s *sqlite3Dialect) supportCTE() bool {
+	return true
+}
+
+// maxParams SQLITE_MAX_VARIABLE_NUMBER，3.32 之前的版本为 999
+func (s *sqlite3Dialect) maxParams() int {
+	return 32766
+}
+
+// supportReturning SQLite 3.35 开始支持 RETURNING
+func (s *sqlite3Dialect) supportReturning() bool {
+	return true
+}
+
+func (s *sqlite3Dialect) DataTypeOf(fd *model.Field) string {
+	if fd.SQLType != "" {
+		return fd.SQLType
+	}
+	typ := columnType(fd.Typ)
+	switch typ.Kind() {
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "INTEGER"
+	case reflect.Float32, reflect.Float64:
+		return "REAL"
+	case reflect.String:
+		return "TEXT"
+	case reflect.Slice:
+		if typ.Elem().Kind() == reflect.Uint8 {
+			return "BLOB"
+		}
+	case reflect.Struct:
+		if typ == timeType {
+			return "DATETIME"
+		}
+	}
+	return ""
+}
+
+// autoIncrement SQLite 只有 INTEGER PRIMARY KEY 才能声明 AUTOINCREMENT
+func (s *sqlite3Dialect) autoIncrement(fd *model.Field) (string, string, bool) {
+	return "INTEGER", "PRIMARY KEY AUTOINCREMENT", true
+}
+
+func (s *sqlite3Dialect) tableExistSQL(table string) *Query {
+	return &Query{
+		SQL:  "SELECT name FROM sqlite_master WHERE type = 'table' AND name = ?;",
+		Args: []any{table},
+	}
+}
+
+func (s *sqlite3Dialect) columnsSQL(table string) *Query {
+	return &Query{
+		SQL:  `SELECT name, type, "notnull" = 0 FROM pragma_table_info(?) ORDER BY cid;`,
+		Args: []any{table},
+	}
+}
+
+func (s *sqlite3Dialect) indexesSQL(table string) *Query {
+	return &Query{
+		SQL: `SELECT il.name, il."unique", ii.name FROM pragma_index_list(?) AS il, pragma_index_info(il.name) AS ii ` +
+			`WHERE il.origin <> 'pk' ORDER BY il.name, ii.seqno;`,
+		Args: []any{table},
+	}
+}
+
+func (s *sqlite3Dialect) transactionalDDL() bool {
+	return true
+}
+
+// advisoryLock SQLite 没有会话级别的锁，写事务本身是串行的
+func (s *sqlite3Dialect) advisoryLock(name string) (*Query, *Query) {
+	return nil, nil
+}
+
+// normalizeType SQLite 原样保存建表时声明的类型
+func (s *sqlite3Dialect) normalizeType(typ string) string {
+	return strings.ToUpper(spaces.ReplaceAllString(strings.TrimSpace(typ), " "))
+}
+
 func (s *sqlite3Dialect) buildUpsert(b *builder, upsert *Upsert) error {
 	b.sb.WriteString(" ON CONFLICT(")
 	for i, col := range upsert.conflictColumns {
@@ -117,8 +423,10 @@ func (s *sqlite3Dialect) buildUpsert(b *builder, upsert *Upsert) error {
 				return errs.NewErrUnknownField(a.name)
 			}
 			b.quote(fd.ColName)
-			b.sb.WriteString(" = ?")
-			b.addArgs(a.val)
+			b.sb.WriteString(" = ")
+			if err := b.buildAssignValue(a.val); err != nil {
+				return err
+			}
 		case Column:
 			fd, ok := b.model.FieldMap[a.name]
 			if !ok {
@@ -138,3 +446,260 @@ func (s *sqlite3Dialect) buildUpsert(b *builder, upsert *Upsert) error {
 type postgresDialect struct {
 	standardSQL
 }
+
+func (p *postgresDialect) quoter() byte {
+	return '"'
+}
+
+func (p *postgresDialect) supportReturning() bool {
+	return true
+}
+
+func (p *postgresDialect) supportRowValues() bool {
+	return true
+}
+
+func (p *postgresDialect) supportILike() bool {
+	return true
+}
+
+func (p *postgresDialect) aliasInHaving() bool {
+	return false
+}
+
+func (p *postgresDialect) supportWindow() bool {
+	return true
+}
+
+func (p *postgresDialect) supportCTE() bool {
+	return true
+}
+
+// maxParams 协议中参数数量用 uint16 表示
+func (p *postgresDialect) maxParams() int {
+	return 65535
+}
+
+func (p *postgresDialect) buildUpsert(b *builder, upsert *Upsert) error {
+	if len(upsert.conflictColumns) == 0 {
+		return errs.ErrUpsertNoConflictColumns
+	}
+	b.sb.WriteString(" ON CONFLICT (")
+	for i, col := range upsert.conflictColumns {
+		if i > 0 {
+			b.sb.WriteByte(',')
+		}
+		if err := b.buildColumn(Column{name: col}); err != nil {
+			return err
+		}
+	}
+	b.sb.WriteString(") DO UPDATE SET ")
+
+	for i, assign := range upsert.assigns {
+		if i > 0 {
+			b.sb.WriteByte(',')
+		}
+		switch a := assign.(type) {
+		case Assignment:
+			fd, ok := b.model.FieldMap[a.name]
+			if !ok {
+				return errs.NewErrUnknownField(a.name)
+			}
+			b.quote(fd.ColName)
+			b.sb.WriteString(" = ")
+			if err := b.buildAssignValue(a.val); err != nil {
+				return err
+			}
+		case Column:
+			fd, ok := b.model.FieldMap[a.name]
+			if !ok {
+				return errs.NewErrUnknownField(a.name)
+			}
+			b.quote(fd.ColName)
+			b.sb.WriteString(" = EXCLUDED.")
+			b.quote(fd.ColName)
+		default:
+			return errs.NewErrUnsupportedAssignable(a)
+		}
+	}
+	return nil
+}
+
+func (p *postgresDialect) DataTypeOf(fd *model.Field) string {
+	if fd.SQLType != "" {
+		return fd.SQLType
+	}
+	typ := columnType(fd.Typ)
+	switch typ.Kind() {
+	case reflect.Bool:
+		return "BOOLEAN"
+	case reflect.Int8, reflect.Int16, reflect.Uint8:
+		return "SMALLINT"
+	case reflect.Int32, reflect.Uint16:
+		return "INTEGER"
+	case reflect.Int, reflect.Int64, reflect.Uint32, reflect.Uint, reflect.Uint64:
+		return "BIGINT"
+	case reflect.Float32:
+		return "REAL"
+	case reflect.Float64:
+		return "DOUBLE PRECISION"
+	case reflect.String:
+		return "VARCHAR(" + strconv.Itoa(sizeOf(fd)) + ")"
+	case reflect.Slice:
+		if typ.Elem().Kind() == reflect.Uint8 {
+			return "BYTEA"
+		}
+	case reflect.Struct:
+		if typ == timeType {
+			return "TIMESTAMPTZ"
+		}
+	}
+	return ""
+}
+
+// autoIncrement Postgres 使用 SERIAL 系列类型
+func (p *postgresDialect) autoIncrement(fd *model.Field) (string, string, bool) {
+	switch p.DataTypeOf(fd) {
+	case "SMALLINT":
+		return "SMALLSERIAL", "", false
+	case "INTEGER":
+		return "SERIAL", "", false
+	default:
+		return "BIGSERIAL", "", false
+	}
+}
+
+func (p *postgresDialect) tableExistSQL(table string) *Query {
+	return &Query{
+		SQL:  "SELECT table_name FROM information_schema.tables WHERE table_schema = current_schema() AND table_name = ?;",
+		Args: []any{table},
+	}
+}
+
+func (p *postgresDialect) columnsSQL(table string) *Query {
+	return &Query{
+		SQL: "SELECT column_name, CASE " +
+			"WHEN character_maximum_length IS NOT NULL THEN data_type || '(' || character_maximum_length || ')' " +
+			"WHEN data_type = 'numeric' AND numeric_precision IS NOT NULL THEN data_type || '(' || numeric_precision || ',' || numeric_scale || ')' " +
+			"ELSE data_type END, is_nullable = 'YES' FROM information_schema.columns " +
+			"WHERE table_schema = current_schema() AND table_name = ? ORDER BY ordinal_position;",
+		Args: []any{table},
+	}
+}
+
+func (p *postgresDialect) indexesSQL(table string) *Query {
+	return &Query{
+		SQL: "SELECT i.relname, ix.indisunique, a.attname FROM pg_index ix " +
+			"JOIN pg_class t ON t.oid = ix.indrelid JOIN pg_class i ON i.oid = ix.indexrelid " +
+			"JOIN pg_namespace n ON n.oid = t.relnamespace " +
+			"JOIN pg_attribute a ON a.attrelid = t.oid AND a.attnum = ANY(ix.indkey) " +
+			"WHERE n.nspname = current_schema() AND t.relname = ? AND NOT ix.indisprimary " +
+			"ORDER BY i.relname, array_position(ix.indkey::int2[], a.attnum);",
+		Args: []any{table},
+	}
+}
+
+func (p *postgresDialect) transactionalDDL() bool {
+	return true
+}
+
+// advisoryLock Postgres 的 advisory lock 使用整数作为键，由锁名哈希得到
+func (p *postgresDialect) advisoryLock(name string) (*Query, *Query) {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(name))
+	key := int64(h.Sum64())
+	return &Query{
+		SQL:  "SELECT 1 FROM pg_advisory_lock(?);",
+		Args: []any{key},
+	}, &Query{
+		SQL:  "SELECT pg_advisory_unlock(?);",
+		Args: []any{key},
+	}
+}
+
+var postgresTypeAlias = map[string]string{
+	"bigserial":   "bigint",
+	"int8":        "bigint",
+	"serial":      "integer",
+	"int":         "integer",
+	"int4":        "integer",
+	"smallserial": "smallint",
+	"int2":        "smallint",
+	"float8":      "double precision",
+	"float4":      "real",
+	"bool":        "boolean",
+	"timestamptz": "timestamp with time zone",
+	"timestamp":   "timestamp without time zone",
+	"decimal":     "numeric",
+	"varchar":     "character varying",
+	"char":        "character",
+}
+
+// normalizeType information_schema 中的类型为全称，比如 character varying(32)
+func (p *postgresDialect) normalizeType(typ string) string {
+	typ = strings.ToLower(spaces.ReplaceAllString(strings.TrimSpace(typ), " "))
+	name, args := typ, ""
+	if i := strings.IndexByte(typ, '('); i > 0 {
+		name, args = strings.TrimSpace(typ[:i]), typ[i:]
+	}
+	if alias, ok := postgresTypeAlias[name]; ok {
+		name = alias
+	}
+	return name + strings.ReplaceAll(args, " ", "")
+}
+
+// rebind 将 ? 按出现顺序改写为 $1..$N，跳过字符串字面量和带引号的标识符
+func (p *postgresDialect) rebind(query string) string {
+	var sb strings.Builder
+	sb.Grow(len(query) + 8)
+	n := 0
+	var quote byte
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '?':
+			n++
+			sb.WriteByte('$')
+			sb.WriteString(strconv.Itoa(n))
+			continue
+		}
+		sb.WriteByte(c)
+	}
+	return sb.String()
+}
+
+var nullTypes = map[reflect.Type]reflect.Type{
+	reflect.TypeOf(sql.NullString{}):  reflect.TypeOf(""),
+	reflect.TypeOf(sql.NullBool{}):    reflect.TypeOf(false),
+	reflect.TypeOf(sql.NullByte{}):    reflect.TypeOf(byte(0)),
+	reflect.TypeOf(sql.NullInt16{}):   reflect.TypeOf(int16(0)),
+	reflect.TypeOf(sql.NullInt32{}):   reflect.TypeOf(int32(0)),
+	reflect.TypeOf(sql.NullInt64{}):   reflect.TypeOf(int64(0)),
+	reflect.TypeOf(sql.NullFloat64{}): reflect.TypeOf(float64(0)),
+	nullTimeType:                      timeType,
+}
+
+// columnType 去掉指针和 sql.NullXXX 包装后的类型
+func columnType(typ reflect.Type) reflect.Type {
+	if typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	if t, ok := nullTypes[typ]; ok {
+		return t
+	}
+	return typ
+}
+
+// sizeOf 字符串列的长度，默认 255
+func sizeOf(fd *model.Field) int {
+	if fd.Size > 0 {
+		return fd.Size
+	}
+	return 255
+}
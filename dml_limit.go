@@ -0,0 +1,129 @@
+package orm
+
+import (
+	"context"
+	"github.com/KNICEX/go-orm/internal/errs"
+)
+
+// buildWhereLimit 构造 UPDATE 和 DELETE 的 WHERE, ORDER BY, LIMIT
+// 方言不支持 ORDER BY 和 LIMIT 时，改写为 WHERE pk IN (SELECT pk FROM ... ORDER BY ... LIMIT n)
+// where 是追加了软删除条件的过滤条件，sub 根据主键列创建改写使用的子查询
+func (b *builder) buildWhereLimit(where []Predicate, orderBys []OrderAble, limit int,
+	sub func(pks []Selectable) SqlBuilder) error {
+	if limit > 0 && !b.dialect.supportDMLLimit() {
+		p, err := b.pkInQuery(sub)
+		if err != nil {
+			return err
+		}
+		b.sb.WriteString(" WHERE ")
+		return b.buildPredicate([]Predicate{p})
+	}
+
+	if len(where) > 0 {
+		b.sb.WriteString(" WHERE ")
+		if err := b.buildPredicate(where); err != nil {
+			return err
+		}
+	}
+	// 没有 LIMIT 时排序没有意义，不支持的方言直接忽略
+	if !b.dialect.supportDMLLimit() {
+		return nil
+	}
+	if err := b.buildOrderBy(orderBys); err != nil {
+		return err
+	}
+	return b.dialect.buildOffsetLimit(b, 0, limit)
+}
+
+// pkInQuery 构造 pk IN (子查询)，复合主键使用 (a, b) IN (子查询)
+func (b *builder) pkInQuery(sub func(pks []Selectable) SqlBuilder) (Predicate, error) {
+	pks := b.model.PrimaryKeys
+	if len(pks) == 0 {
+		return Predicate{}, errs.ErrNoPrimaryKey
+	}
+	if len(pks) > 1 && !b.dialect.supportRowValues() {
+		return Predicate{}, errs.ErrCompositePrimaryKey
+	}
+	cols := make([]Selectable, 0, len(pks))
+	exprs := make([]Expression, 0, len(pks))
+	for _, pk := range pks {
+		cols = append(cols, Col(pk.GoName))
+		exprs = append(exprs, Col(pk.GoName))
+	}
+	if len(pks) == 1 {
+		return Col(pks[0].GoName).InQuery(sub(cols)), nil
+	}
+	return Predicate{
+		left:  rowValue{exprs: exprs},
+		op:    opIn,
+		right: subQueryExpr{s: sub(cols)},
+	}, nil
+}
+
+// limitSelector 改写 ORDER BY 和 LIMIT 使用的主键子查询
+func limitSelector[T any](sess Session, pks []Selectable, where []Predicate,
+	orderBys []OrderAble, limit int, unscoped bool) *Selector[T] {
+	s := NewSelector[T](sess).Select(pks...).OrderBy(orderBys...).Limit(limit)
+	s.where = where
+	s.unscoped = unscoped
+	return s
+}
+
+// DeleteInChunks 每次最多删除 size 行，直到没有行被删除，用于分批清理大量数据
+// 每批单独执行，不会在同一个事务中，RowsAffected 为各批之和
+// 软删除时已删除的行会被重复更新，所以 Unscoped 必须和 HardDelete 一起使用
+// 用法： NewDeleter[Log](db).Where(Col("CreatedAt").Lt(t)).OrderBy(Col("Id")).DeleteInChunks(ctx, 1000)
+func (d *Deleter[T]) DeleteInChunks(ctx context.Context, size int) ExecResult {
+	if size <= 0 {
+		return ExecResult{
+			err: errs.NewErrInvalidBatchSize(size),
+		}
+	}
+	m, err := d.r.Get(new(T))
+	if err != nil {
+		return ExecResult{
+			err: err,
+		}
+	}
+	if m.SoftDelete != nil && d.unscoped && !d.hardDelete {
+		return ExecResult{
+			err: errs.ErrUnscopedChunks,
+		}
+	}
+	var results multiResult
+	for {
+		if err = ctx.Err(); err != nil {
+			return ExecResult{
+				err: err,
+			}
+		}
+		res := d.derive().Limit(size).Exec(ctx)
+		if res.err != nil {
+			return res
+		}
+		results = append(results, res.res)
+		n, err := res.RowsAffected()
+		if err != nil {
+			return ExecResult{
+				err: err,
+			}
+		}
+		if n == 0 {
+			return ExecResult{
+				res: results,
+			}
+		}
+	}
+}
+
+// derive 基于当前的表、条件、排序等创建新的 Deleter，用于需要多次执行的删除
+func (d *Deleter[T]) derive() *Deleter[T] {
+	res := NewDeleter[T](d.sess)
+	res.table = d.table
+	res.where = d.where
+	res.orderBys = d.orderBys
+	res.hardDelete = d.hardDelete
+	res.unscoped = d.unscoped
+	res.using = d.using
+	return res
+}
@@ -0,0 +1,205 @@
+package orm
+
+import (
+	"context"
+	"database/sql/driver"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/KNICEX/go-orm/internal/errs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestDMLLimit_Build(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	clock := DBWithClock(func() time.Time {
+		return now
+	})
+	db, err := OpenDB(nil, DBWithDialect(DialectMySQL), clock)
+	require.NoError(t, err)
+	pg, err := OpenDB(nil, DBWithDialect(DialectPostgres), clock)
+	require.NoError(t, err)
+	sqlite, err := OpenDB(nil, DBWithDialect(DialectSQLite3))
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name      string
+		builder   SqlBuilder
+		wantQuery *Query
+		wantErr   error
+	}{
+		{
+			name: "mysql delete",
+			builder: NewDeleter[PredicateOrder](db).Where(Col("Amount").Lt(10)).
+				OrderBy(Col("Id")).Limit(1000),
+			wantQuery: &Query{
+				SQL:  "DELETE FROM `predicate_order` WHERE `amount` < ? ORDER BY `id` ASC LIMIT 1000;",
+				Args: []any{10},
+			},
+		},
+		{
+			name: "mysql update",
+			builder: NewUpdater[PredicateOrder](db).Set(Assign("Amount", 0)).
+				Where(Col("UserId").Eq(1)).OrderBy(Col("Id").Desc()).Limit(10),
+			wantQuery: &Query{
+				SQL:  "UPDATE `predicate_order` SET `amount` = ? WHERE `user_id` = ? ORDER BY `id` DESC LIMIT 10;",
+				Args: []any{0, 1},
+			},
+		},
+		{
+			name: "mysql soft delete",
+			builder: NewDeleter[SoftDeleteModel](db).Where(Col("Name").Eq("tom")).
+				OrderBy(Col("Id")).Limit(5),
+			wantQuery: &Query{
+				SQL: "UPDATE `soft_delete_model` SET `deleted_at` = ? WHERE (`name` = ?) AND (`deleted_at` IS NULL) " +
+					"ORDER BY `id` ASC LIMIT 5;",
+				Args: []any{&now, "tom"},
+			},
+		},
+		{
+			name: "postgres delete",
+			builder: NewDeleter[PredicateOrder](pg).Where(Col("Amount").Lt(10)).
+				OrderBy(Col("Id")).Limit(1000),
+			wantQuery: &Query{
+				SQL: `DELETE FROM "predicate_order" WHERE "id" IN ` +
+					`(SELECT "id" FROM "predicate_order" WHERE "amount" < $1 ORDER BY "id" ASC LIMIT 1000);`,
+				Args: []any{10},
+			},
+		},
+		{
+			name: "postgres update",
+			builder: NewUpdater[PredicateOrder](pg).Set(Assign("Amount", 0)).
+				Where(Col("UserId").Eq(1)).Limit(10),
+			wantQuery: &Query{
+				SQL: `UPDATE "predicate_order" SET "amount" = $1 WHERE "id" IN ` +
+					`(SELECT "id" FROM "predicate_order" WHERE "user_id" = $2 LIMIT 10);`,
+				Args: []any{0, 1},
+			},
+		},
+		{
+			name: "postgres soft delete",
+			builder: NewDeleter[SoftDeleteModel](pg).Where(Col("Name").Eq("tom")).
+				OrderBy(Col("Id")).Limit(5),
+			wantQuery: &Query{
+				SQL: `UPDATE "soft_delete_model" SET "deleted_at" = $1 WHERE "id" IN ` +
+					`(SELECT "id" FROM "soft_delete_model" WHERE ("name" = $2) AND ("deleted_at" IS NULL) ORDER BY "id" ASC LIMIT 5);`,
+				Args: []any{&now, "tom"},
+			},
+		},
+		{
+			name: "postgres hard delete",
+			builder: NewDeleter[SoftDeleteModel](pg).HardDelete().Where(Col("Name").Eq("tom")).
+				Limit(5),
+			wantQuery: &Query{
+				SQL: `DELETE FROM "soft_delete_model" WHERE "id" IN ` +
+					`(SELECT "id" FROM "soft_delete_model" WHERE "name" = $1 LIMIT 5);`,
+				Args: []any{"tom"},
+			},
+		},
+		{
+			name: "order by without limit",
+			builder: NewDeleter[PredicateOrder](pg).Where(Col("Amount").Lt(10)).
+				OrderBy(Col("Id")),
+			wantQuery: &Query{
+				SQL:  `DELETE FROM "predicate_order" WHERE "amount" < $1;`,
+				Args: []any{10},
+			},
+		},
+		{
+			name: "sqlite composite primary key",
+			builder: NewDeleter[BulkCompositeModel](sqlite).Where(Col("Position").Gt(1)).
+				OrderBy(Col("Position").Desc()).Limit(2),
+			wantQuery: &Query{
+				SQL: "DELETE FROM `bulk_composite_model` WHERE (`tenant_id`,`code`) IN " +
+					"(SELECT `tenant_id`,`code` FROM `bulk_composite_model` WHERE `position` > ? ORDER BY `position` DESC LIMIT 2);",
+				Args: []any{1},
+			},
+		},
+		{
+			name: "multi table",
+			builder: func() SqlBuilder {
+				o := TableOf(&JoinOrder{}).As("o")
+				usr := TableOf(&JoinUser{}).As("u")
+				return NewDeleter[JoinOrder](db).Using(o.Join(usr).On(o.Col("UserId").Eq(usr.Col("Id")))).Limit(1)
+			}(),
+			wantErr: errs.ErrMultiTableLimit,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			q, err := tc.builder.Build()
+			assert.Equal(t, tc.wantErr, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.wantQuery, q)
+		})
+	}
+}
+
+func TestDeleter_DeleteInChunks(t *testing.T) {
+	mockDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	db, err := OpenDB(mockDB, DBWithDialect(DialectMySQL))
+	require.NoError(t, err)
+
+	query := "DELETE FROM `predicate_order` WHERE `amount` < ? ORDER BY `id` ASC LIMIT 2;"
+	mock.ExpectExec(query).WithArgs(10).WillReturnResult(driver.RowsAffected(2))
+	mock.ExpectExec(query).WithArgs(10).WillReturnResult(driver.RowsAffected(1))
+	mock.ExpectExec(query).WithArgs(10).WillReturnResult(driver.RowsAffected(0))
+	affected, err := NewDeleter[PredicateOrder](db).Where(Col("Amount").Lt(10)).
+		OrderBy(Col("Id")).DeleteInChunks(context.Background(), 2).RowsAffected()
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), affected)
+
+	_, err = NewDeleter[PredicateOrder](db).DeleteInChunks(context.Background(), 0).RowsAffected()
+	assert.Equal(t, errs.NewErrInvalidBatchSize(0), err)
+
+	// 软删除时包括已删除的行，每批都会重复更新，不会结束
+	_, err = NewDeleter[SoftDeleteModel](db).Unscoped().DeleteInChunks(context.Background(), 2).RowsAffected()
+	assert.Equal(t, errs.ErrUnscopedChunks, err)
+
+	mock.ExpectExec("DELETE FROM `soft_delete_model` ORDER BY `id` ASC LIMIT 2;").
+		WillReturnResult(driver.RowsAffected(0))
+	affected, err = NewDeleter[SoftDeleteModel](db).Unscoped().HardDelete().
+		OrderBy(Col("Id")).DeleteInChunks(context.Background(), 2).RowsAffected()
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), affected)
+
+	// 每批之前检查 ctx
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = NewDeleter[PredicateOrder](db).DeleteInChunks(ctx, 2).RowsAffected()
+	assert.Equal(t, context.Canceled, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDMLLimit_SQLite(t *testing.T) {
+	db := memoryWithDB("dml_limit", t, DBWithDialect(DialectSQLite3))
+	ctx := context.Background()
+	require.NoError(t, db.AutoMigrate(ctx, &PredicateOrder{}))
+	orders := make([]*PredicateOrder, 0, 7)
+	for i := int64(1); i <= 7; i++ {
+		orders = append(orders, &PredicateOrder{Id: i, UserId: i % 2, Amount: i})
+	}
+	require.NoError(t, NewInserter[PredicateOrder](db).Values(orders...).Exec(ctx).Err())
+
+	// 只更新金额最大的两行
+	require.NoError(t, NewUpdater[PredicateOrder](db).Set(Assign("UserId", 9)).
+		OrderBy(Col("Amount").Desc()).Limit(2).Exec(ctx).Err())
+	updated, err := NewSelector[PredicateOrder](db).Where(Col("UserId").Eq(9)).OrderBy(Col("Id")).GetMulti(ctx)
+	require.NoError(t, err)
+	require.Len(t, updated, 2)
+	assert.Equal(t, int64(6), updated[0].Id)
+	assert.Equal(t, int64(7), updated[1].Id)
+
+	affected, err := NewDeleter[PredicateOrder](db).Where(Col("Amount").Le(5)).
+		OrderBy(Col("Id")).DeleteInChunks(ctx, 2).RowsAffected()
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), affected)
+	cnt, err := NewSelector[PredicateOrder](db).Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), cnt)
+}
@@ -0,0 +1,161 @@
+package orm
+
+import (
+	"context"
+	"github.com/KNICEX/go-orm/internal/errs"
+	"github.com/KNICEX/go-orm/model"
+	"reflect"
+)
+
+// FindByPK 根据主键查询，复合主键按字段定义顺序传入
+// 用法： FindByPK[User](ctx, db, 1)
+func FindByPK[T any](ctx context.Context, sess Session, pk ...any) (*T, error) {
+	m, err := sess.getCore().r.Get(new(T))
+	if err != nil {
+		return nil, err
+	}
+	if len(m.PrimaryKeys) == 0 {
+		return nil, errs.ErrNoPrimaryKey
+	}
+	if len(pk) != len(m.PrimaryKeys) {
+		return nil, errs.NewErrPrimaryKeyCount(len(m.PrimaryKeys), len(pk))
+	}
+	var p Predicate
+	for i, fd := range m.PrimaryKeys {
+		if i == 0 {
+			p = Col(fd.GoName).Eq(pk[i])
+			continue
+		}
+		p = p.And(Col(fd.GoName).Eq(pk[i]))
+	}
+	return NewSelector[T](sess).Where(p).Get(ctx)
+}
+
+// Save 记录不存在时插入，否则按主键更新除主键和创建时间以外的所有字段
+// 自增主键非零即认为记录已存在，由调用方赋值的主键会先查询数据库确认
+// 插入时自增主键会被回填到 entity 中
+func Save[T any](ctx context.Context, sess Session, entity *T) ExecResult {
+	c := sess.getCore()
+	m, err := c.r.Get(entity)
+	if err != nil {
+		return ExecResult{err: err}
+	}
+	if len(m.PrimaryKeys) == 0 {
+		return ExecResult{err: errs.ErrNoPrimaryKey}
+	}
+	exists, err := entityExists(ctx, sess, m, entity)
+	if err != nil {
+		return ExecResult{err: err}
+	}
+	if exists {
+		return UpdateEntity(ctx, sess, entity)
+	}
+
+	i := NewInserter[T](sess).Values(entity)
+	if pk := m.PrimaryKeys[0]; len(m.PrimaryKeys) == 1 && pk.AutoIncrement {
+		i = i.Returning(pk.GoName)
+	}
+	return i.Exec(ctx)
+}
+
+// UpdateEntity 按主键更新指定字段，不指定字段时更新除主键和创建时间以外的所有字段
+func UpdateEntity[T any](ctx context.Context, sess Session, entity *T, fields ...string) ExecResult {
+	c := sess.getCore()
+	m, err := c.r.Get(entity)
+	if err != nil {
+		return ExecResult{err: err}
+	}
+	p, err := pkPredicate(c, m, entity)
+	if err != nil {
+		return ExecResult{err: err}
+	}
+	if len(fields) == 0 {
+		for _, fd := range m.Fields {
+			// 创建时间和软删除字段不更新，更新时间由 Updater 自动维护
+			if fd.PrimaryKey || fd == m.AutoCreateTime || fd == m.AutoUpdateTime || fd == m.SoftDelete {
+				continue
+			}
+			fields = append(fields, fd.GoName)
+		}
+	}
+
+	// 字段的值在 Build 时读取，BeforeUpdate 钩子中的修改会生效
+	u := NewUpdater[T](sess).Where(p)
+	u.entity = entity
+	u.entityFields = fields
+	return u.Exec(ctx)
+}
+
+// DeleteEntity 按主键删除
+func DeleteEntity[T any](ctx context.Context, sess Session, entity *T) ExecResult {
+	c := sess.getCore()
+	m, err := c.r.Get(entity)
+	if err != nil {
+		return ExecResult{err: err}
+	}
+	p, err := pkPredicate(c, m, entity)
+	if err != nil {
+		return ExecResult{err: err}
+	}
+	d := NewDeleter[T](sess).Where(p)
+	d.entity = entity
+	return d.Exec(ctx)
+}
+
+// pkPredicate 根据 entity 的主键值构造条件
+func pkPredicate(c *core, m *model.Model, entity any) (Predicate, error) {
+	if len(m.PrimaryKeys) == 0 {
+		return Predicate{}, errs.ErrNoPrimaryKey
+	}
+	val := c.creator(m, entity)
+	var p Predicate
+	for i, fd := range m.PrimaryKeys {
+		arg, err := val.Field(fd.GoName)
+		if err != nil {
+			return Predicate{}, err
+		}
+		if i == 0 {
+			p = Col(fd.GoName).Eq(arg)
+			continue
+		}
+		p = p.And(Col(fd.GoName).Eq(arg))
+	}
+	return p, nil
+}
+
+// entityExists 判断 Save 时记录是否已经存在
+func entityExists[T any](ctx context.Context, sess Session, m *model.Model, entity *T) (bool, error) {
+	c := sess.getCore()
+	zero, err := pkIsZero(c, m, entity)
+	if err != nil || zero {
+		return false, err
+	}
+	if pk := m.PrimaryKeys[0]; len(m.PrimaryKeys) == 1 && pk.AutoIncrement {
+		return true, nil
+	}
+	p, err := pkPredicate(c, m, entity)
+	if err != nil {
+		return false, err
+	}
+	// 已软删除的行仍然占用主键，同样视为存在
+	cnt, err := NewSelector[T](sess).Unscoped().Where(p).Count(ctx)
+	if err != nil {
+		return false, err
+	}
+	return cnt > 0, nil
+}
+
+// pkIsZero 主键是否全为零值
+func pkIsZero(c *core, m *model.Model, entity any) (bool, error) {
+	val := c.creator(m, entity)
+	for _, fd := range m.PrimaryKeys {
+		arg, err := val.Field(fd.GoName)
+		if err != nil {
+			return false, err
+		}
+		if !reflect.ValueOf(arg).IsZero() {
+			return false, nil
+		}
+	}
+	return true, nil
+}
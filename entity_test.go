@@ -0,0 +1,152 @@
+package orm
+
+import (
+	"context"
+	"database/sql/driver"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/KNICEX/go-orm/internal/errs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestFindByPK(t *testing.T) {
+	mockDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	db, err := OpenDB(mockDB, DBWithDialect(DialectMySQL))
+	require.NoError(t, err)
+
+	type OrderItem struct {
+		OrderId int64 `orm:"pk"`
+		ItemId  int64 `orm:"pk"`
+		Amount  int
+	}
+	type NoKey struct {
+		Name string
+	}
+
+	t.Run("single key", func(t *testing.T) {
+		mock.ExpectQuery("SELECT * FROM `test_model` WHERE `id` = ? LIMIT 1;").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "first_name", "last_name"}).AddRow(1, "tom", "cat"))
+		res, err := FindByPK[TestModel](context.Background(), db, 1)
+		require.NoError(t, err)
+		assert.Equal(t, &TestModel{Id: 1, FirstName: "tom", LastName: "cat"}, res)
+	})
+
+	t.Run("composite key", func(t *testing.T) {
+		mock.ExpectQuery("SELECT * FROM `order_item` WHERE (`order_id` = ?) AND (`item_id` = ?) LIMIT 1;").
+			WithArgs(1, 2).
+			WillReturnRows(sqlmock.NewRows([]string{"order_id", "item_id", "amount"}).AddRow(1, 2, 3))
+		res, err := FindByPK[OrderItem](context.Background(), db, 1, 2)
+		require.NoError(t, err)
+		assert.Equal(t, &OrderItem{OrderId: 1, ItemId: 2, Amount: 3}, res)
+	})
+
+	t.Run("key count mismatch", func(t *testing.T) {
+		_, err := FindByPK[OrderItem](context.Background(), db, 1)
+		assert.Equal(t, errs.NewErrPrimaryKeyCount(2, 1), err)
+	})
+
+	t.Run("no primary key", func(t *testing.T) {
+		_, err := FindByPK[NoKey](context.Background(), db, 1)
+		assert.Equal(t, errs.ErrNoPrimaryKey, err)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSave(t *testing.T) {
+	mockDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	db, err := OpenDB(mockDB, DBWithDialect(DialectMySQL))
+	require.NoError(t, err)
+
+	t.Run("insert", func(t *testing.T) {
+		mock.ExpectExec("INSERT INTO `test_model` (`first_name`,`last_name`) VALUES (?,?);").
+			WithArgs("tom", "cat").
+			WillReturnResult(sqlmock.NewResult(10, 1))
+		entity := &TestModel{FirstName: "tom", LastName: "cat"}
+		require.NoError(t, Save(context.Background(), db, entity).Err())
+		assert.Equal(t, int64(10), entity.Id)
+	})
+
+	t.Run("update", func(t *testing.T) {
+		mock.ExpectExec("UPDATE `test_model` SET `first_name` = ?,`last_name` = ? WHERE `id` = ?;").
+			WithArgs("tom", "dog", int64(10)).
+			WillReturnResult(driver.RowsAffected(1))
+		entity := &TestModel{Id: 10, FirstName: "tom", LastName: "dog"}
+		affected, err := Save(context.Background(), db, entity).RowsAffected()
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), affected)
+	})
+
+	type Account struct {
+		Code string `orm:"pk"`
+		Name string
+	}
+
+	t.Run("insert assigned key", func(t *testing.T) {
+		mock.ExpectQuery("SELECT COUNT(*) FROM `account` WHERE `code` = ?;").
+			WithArgs("a1").
+			WillReturnRows(sqlmock.NewRows([]string{"COUNT(*)"}).AddRow(0))
+		mock.ExpectExec("INSERT INTO `account` (`code`,`name`) VALUES (?,?);").
+			WithArgs("a1", "tom").
+			WillReturnResult(driver.RowsAffected(1))
+		entity := &Account{Code: "a1", Name: "tom"}
+		require.NoError(t, Save(context.Background(), db, entity).Err())
+	})
+
+	t.Run("update assigned key", func(t *testing.T) {
+		mock.ExpectQuery("SELECT COUNT(*) FROM `account` WHERE `code` = ?;").
+			WithArgs("a1").
+			WillReturnRows(sqlmock.NewRows([]string{"COUNT(*)"}).AddRow(1))
+		mock.ExpectExec("UPDATE `account` SET `name` = ? WHERE `code` = ?;").
+			WithArgs("jerry", "a1").
+			WillReturnResult(driver.RowsAffected(1))
+		entity := &Account{Code: "a1", Name: "jerry"}
+		require.NoError(t, Save(context.Background(), db, entity).Err())
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateEntity(t *testing.T) {
+	creators := map[string][]DBOption{
+		"unsafe":  {DBWithDialect(DialectMySQL)},
+		"reflect": {DBWithDialect(DialectMySQL), DBUseReflect()},
+	}
+	for name, opts := range creators {
+		t.Run(name, func(t *testing.T) {
+			mockDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			require.NoError(t, err)
+			db, err := OpenDB(mockDB, opts...)
+			require.NoError(t, err)
+
+			mock.ExpectExec("UPDATE `test_model` SET `last_name` = ? WHERE `id` = ?;").
+				WithArgs("dog", int64(10)).
+				WillReturnResult(driver.RowsAffected(1))
+			entity := &TestModel{Id: 10, FirstName: "tom", LastName: "dog"}
+			require.NoError(t, UpdateEntity(context.Background(), db, entity, "LastName").Err())
+
+			err = UpdateEntity(context.Background(), db, entity, "Unknown").Err()
+			assert.Equal(t, errs.NewErrUnknownField("Unknown"), err)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestDeleteEntity(t *testing.T) {
+	mockDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	db, err := OpenDB(mockDB, DBWithDialect(DialectMySQL))
+	require.NoError(t, err)
+
+	mock.ExpectExec("DELETE FROM `test_model` WHERE `id` = ?;").
+		WithArgs(int64(10)).
+		WillReturnResult(driver.RowsAffected(1))
+	affected, err := DeleteEntity(context.Background(), db, &TestModel{Id: 10}).RowsAffected()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), affected)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -40,3 +40,40 @@ func (r RawExpr) AsPredicate() Predicate {
 		left: r,
 	}
 }
+
+// rowValue 行值表达式 (a, b)，用于 (a, b) > (?, ?) 这样的元组比较
+type rowValue struct {
+	exprs []Expression
+}
+
+func (r rowValue) expr() {}
+
+// valueList 值列表 (?,?,?)，用于 IN
+type valueList struct {
+	vals []any
+}
+
+func (v valueList) expr() {}
+
+// betweenRange BETWEEN 的范围 ? AND ?
+type betweenRange struct {
+	low  Expression
+	high Expression
+}
+
+func (b betweenRange) expr() {}
+
+// subQueryExpr 作为表达式使用的子查询，例如 IN (SELECT ...)
+type subQueryExpr struct {
+	s SqlBuilder
+}
+
+func (s subQueryExpr) expr() {}
+
+// prefixExpr 前缀操作符表达式，例如 EXISTS (SELECT ...)
+type prefixExpr struct {
+	op    op
+	right Expression
+}
+
+func (p prefixExpr) expr() {}
@@ -0,0 +1,212 @@
+package orm
+
+const (
+	opAdd op = "+"
+	opSub op = "-"
+	opMul op = "*"
+	opDiv op = "/"
+	opMod op = "%"
+)
+
+const (
+	fnConcat   = "CONCAT"
+	fnCoalesce = "COALESCE"
+	fnLower    = "LOWER"
+	fnUpper    = "UPPER"
+	fnAbs      = "ABS"
+)
+
+// MathExpr 算术表达式
+// 用法： Col("Stock").Sub(1), Col("Price").Mul(Col("Quantity")).As("total")
+type MathExpr struct {
+	left  Expression
+	op    op
+	right Expression
+	alias string
+	desc  bool
+}
+
+func (m MathExpr) expr()                  {}
+func (m MathExpr) selectedAlias() string  { return m.alias }
+func (m MathExpr) target() TableReference { return nil }
+func (m MathExpr) fieldName() string      { return "" }
+func (m MathExpr) orderAble()             {}
+func (m MathExpr) groupAble()             {}
+
+func (m MathExpr) As(alias string) MathExpr {
+	m.alias = alias
+	return m
+}
+
+func (m MathExpr) Asc() MathExpr {
+	m.desc = false
+	return m
+}
+
+func (m MathExpr) Desc() MathExpr {
+	m.desc = true
+	return m
+}
+
+func (m MathExpr) Add(arg any) MathExpr { return arith(m, opAdd, arg) }
+func (m MathExpr) Sub(arg any) MathExpr { return arith(m, opSub, arg) }
+func (m MathExpr) Mul(arg any) MathExpr { return arith(m, opMul, arg) }
+func (m MathExpr) Div(arg any) MathExpr { return arith(m, opDiv, arg) }
+func (m MathExpr) Mod(arg any) MathExpr { return arith(m, opMod, arg) }
+
+func (m MathExpr) Eq(arg any) Predicate { return compare(m, opEq, arg) }
+func (m MathExpr) Gt(arg any) Predicate { return compare(m, opGt, arg) }
+func (m MathExpr) Lt(arg any) Predicate { return compare(m, opLt, arg) }
+func (m MathExpr) Ge(arg any) Predicate { return compare(m, opGe, arg) }
+func (m MathExpr) Le(arg any) Predicate { return compare(m, opLe, arg) }
+
+// FuncExpr 函数表达式，函数名由方言决定最终的写法
+// 用法： Col("Name").Lower(), Col("Nickname").Coalesce(Col("Name"), "anonymous")
+type FuncExpr struct {
+	fn    string
+	args  []Expression
+	alias string
+	desc  bool
+}
+
+func (f FuncExpr) expr()                  {}
+func (f FuncExpr) selectedAlias() string  { return f.alias }
+func (f FuncExpr) target() TableReference { return nil }
+func (f FuncExpr) fieldName() string      { return "" }
+func (f FuncExpr) orderAble()             {}
+func (f FuncExpr) groupAble()             {}
+
+func (f FuncExpr) As(alias string) FuncExpr {
+	f.alias = alias
+	return f
+}
+
+func (f FuncExpr) Asc() FuncExpr {
+	f.desc = false
+	return f
+}
+
+func (f FuncExpr) Desc() FuncExpr {
+	f.desc = true
+	return f
+}
+
+func (f FuncExpr) Add(arg any) MathExpr { return arith(f, opAdd, arg) }
+func (f FuncExpr) Sub(arg any) MathExpr { return arith(f, opSub, arg) }
+func (f FuncExpr) Mul(arg any) MathExpr { return arith(f, opMul, arg) }
+func (f FuncExpr) Div(arg any) MathExpr { return arith(f, opDiv, arg) }
+func (f FuncExpr) Mod(arg any) MathExpr { return arith(f, opMod, arg) }
+
+func (f FuncExpr) Eq(arg any) Predicate   { return compare(f, opEq, arg) }
+func (f FuncExpr) Gt(arg any) Predicate   { return compare(f, opGt, arg) }
+func (f FuncExpr) Lt(arg any) Predicate   { return compare(f, opLt, arg) }
+func (f FuncExpr) Ge(arg any) Predicate   { return compare(f, opGe, arg) }
+func (f FuncExpr) Le(arg any) Predicate   { return compare(f, opLe, arg) }
+func (f FuncExpr) Like(arg any) Predicate { return compare(f, opLike, arg) }
+
+func (c Column) Add(arg any) MathExpr { return arith(c, opAdd, arg) }
+func (c Column) Sub(arg any) MathExpr { return arith(c, opSub, arg) }
+func (c Column) Mul(arg any) MathExpr { return arith(c, opMul, arg) }
+func (c Column) Div(arg any) MathExpr { return arith(c, opDiv, arg) }
+func (c Column) Mod(arg any) MathExpr { return arith(c, opMod, arg) }
+
+// Concat 拼接字符串，MySQL 使用 CONCAT，SQLite 和 Postgres 使用 ||
+// 用法： Col("FirstName").Concat(" ", Col("LastName"))
+func (c Column) Concat(args ...any) FuncExpr { return call(fnConcat, append([]any{c}, args...)...) }
+
+// Coalesce 返回第一个不为 NULL 的值
+func (c Column) Coalesce(args ...any) FuncExpr { return call(fnCoalesce, append([]any{c}, args...)...) }
+
+func (c Column) Lower() FuncExpr { return call(fnLower, c) }
+func (c Column) Upper() FuncExpr { return call(fnUpper, c) }
+func (c Column) Abs() FuncExpr   { return call(fnAbs, c) }
+
+// Concat 拼接字符串，参数可以是表达式或者值
+// 用法： Concat(Col("FirstName"), " ", Col("LastName"))
+func Concat(args ...any) FuncExpr { return call(fnConcat, args...) }
+
+// Coalesce 返回第一个不为 NULL 的值
+// 用法： Lower(Coalesce(Col("Nickname"), Col("Name")))
+func Coalesce(args ...any) FuncExpr { return call(fnCoalesce, args...) }
+
+func Lower(e Expression) FuncExpr { return call(fnLower, e) }
+func Upper(e Expression) FuncExpr { return call(fnUpper, e) }
+func Abs(e Expression) FuncExpr   { return call(fnAbs, e) }
+
+func arith(left Expression, o op, arg any) MathExpr {
+	return MathExpr{
+		left:  left,
+		op:    o,
+		right: valueOf(arg),
+	}
+}
+
+func compare(left Expression, o op, arg any) Predicate {
+	return Predicate{
+		left:  left,
+		op:    o,
+		right: valueOf(arg),
+	}
+}
+
+func call(fn string, args ...any) FuncExpr {
+	exprs := make([]Expression, 0, len(args))
+	for _, arg := range args {
+		exprs = append(exprs, valueOf(arg))
+	}
+	return FuncExpr{
+		fn:   fn,
+		args: exprs,
+	}
+}
+
+func (s *standardSQL) buildFunc(b *builder, f FuncExpr) error {
+	return buildCall(b, f.fn, f.args)
+}
+
+// buildFunc SQLite 3.44 之前没有 CONCAT 函数，使用 ||
+func (s *sqlite3Dialect) buildFunc(b *builder, f FuncExpr) error {
+	if f.fn == fnConcat {
+		return buildConcat(b, f.args)
+	}
+	return s.standardSQL.buildFunc(b, f)
+}
+
+// buildFunc Postgres 的 CONCAT 无法推断参数的类型，使用 ||
+func (p *postgresDialect) buildFunc(b *builder, f FuncExpr) error {
+	if f.fn == fnConcat {
+		return buildConcat(b, f.args)
+	}
+	return p.standardSQL.buildFunc(b, f)
+}
+
+// buildCall 构造 fn(arg1,arg2)
+func buildCall(b *builder, fn string, args []Expression) error {
+	b.sb.WriteString(fn)
+	b.sb.WriteByte('(')
+	for i, arg := range args {
+		if i > 0 {
+			b.sb.WriteByte(',')
+		}
+		if err := b.buildExpression(arg); err != nil {
+			return err
+		}
+	}
+	b.sb.WriteByte(')')
+	return nil
+}
+
+// buildConcat 构造 (a || b)，任意一个值为 NULL 时结果为 NULL
+func buildConcat(b *builder, args []Expression) error {
+	b.sb.WriteByte('(')
+	for i, arg := range args {
+		if i > 0 {
+			b.sb.WriteString(" || ")
+		}
+		if err := b.buildOperand(arg); err != nil {
+			return err
+		}
+	}
+	b.sb.WriteByte(')')
+	return nil
+}
@@ -0,0 +1,177 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+type ProductModel struct {
+	Id       int64
+	Name     string
+	Nickname sql.NullString
+	Price    int64
+	Stock    int64
+}
+
+func TestFunction_Build(t *testing.T) {
+	mysql, err := OpenDB(nil, DBWithDialect(DialectMySQL))
+	require.NoError(t, err)
+	sqlite, err := OpenDB(nil, DBWithDialect(DialectSQLite3))
+	require.NoError(t, err)
+	pg, err := OpenDB(nil, DBWithDialect(DialectPostgres))
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name      string
+		builder   SqlBuilder
+		wantQuery *Query
+		wantErr   error
+	}{
+		{
+			name:    "update arithmetic",
+			builder: NewUpdater[ProductModel](mysql).Set(Assign("Stock", Col("Stock").Sub(1))).Where(Col("Id").Eq(1)),
+			wantQuery: &Query{
+				SQL:  "UPDATE `product_model` SET `stock` = `stock` - ? WHERE `id` = ?;",
+				Args: []any{1, 1},
+			},
+		},
+		{
+			name:    "update column",
+			builder: NewUpdater[ProductModel](mysql).Set(Assign("Name", Upper(Coalesce(Col("Nickname"), Col("Name"))))),
+			wantQuery: &Query{
+				SQL: "UPDATE `product_model` SET `name` = UPPER(COALESCE(`nickname`,`name`));",
+			},
+		},
+		{
+			name:    "update function",
+			builder: NewUpdater[ProductModel](pg).Set(Assign("Name", Col("Nickname").Coalesce(Col("Name"), "anonymous"))),
+			wantQuery: &Query{
+				SQL:  `UPDATE "product_model" SET "name" = COALESCE("nickname","name",$1);`,
+				Args: []any{"anonymous"},
+			},
+		},
+		{
+			name: "select nested",
+			builder: NewSelector[ProductModel](mysql).Select(
+				Col("Price").Add(1).Mul(Col("Stock")).As("total"),
+				Col("Price").Mul(Col("Stock").Sub(2)),
+				Col("Name").Lower().As("name"),
+			),
+			wantQuery: &Query{
+				SQL:  "SELECT (`price` + ?) * `stock` AS `total`,`price` * (`stock` - ?),LOWER(`name`) AS `name` FROM `product_model`;",
+				Args: []any{1, 2},
+			},
+		},
+		{
+			name: "where",
+			builder: NewSelector[ProductModel](mysql).
+				Where(Col("Price").Mul(Col("Stock")).Gt(100).And(Col("Name").Lower().Like("%a%"))),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `product_model` WHERE (`price` * `stock` > ?) AND (LOWER(`name`) LIKE ?);",
+				Args: []any{100, "%a%"},
+			},
+		},
+		{
+			name: "order by alias",
+			builder: NewSelector[ProductModel](mysql).
+				Select(Col("Id"), Col("Price").Mul(Col("Stock")).As("total")).
+				OrderBy(Col("total").Desc(), Col("Id")),
+			wantQuery: &Query{
+				SQL: "SELECT `id`,`price` * `stock` AS `total` FROM `product_model` ORDER BY `total` DESC,`id` ASC;",
+			},
+		},
+		{
+			name: "order by expression",
+			builder: NewSelector[ProductModel](mysql).
+				Select(Col("Price").Mul(Col("Stock")).As("total")).
+				OrderBy(Col("Price").Mul(Col("Stock")).As("total").Desc(), Abs(Col("Stock").Sub(Col("Price")))),
+			wantQuery: &Query{
+				SQL: "SELECT `price` * `stock` AS `total` FROM `product_model` ORDER BY `total` DESC,ABS(`stock` - `price`) ASC;",
+			},
+		},
+		{
+			name: "group by",
+			builder: NewSelector[ProductModel](mysql).
+				Select(Col("Name").Upper().As("n"), Count("Id")).
+				GroupBy(Col("Name").Upper()),
+			wantQuery: &Query{
+				SQL: "SELECT UPPER(`name`) AS `n`,COUNT(`id`) FROM `product_model` GROUP BY UPPER(`name`);",
+			},
+		},
+		{
+			name:    "mysql concat",
+			builder: NewSelector[ProductModel](mysql).Select(Col("Name").Concat("-", Col("Id")).As("label")),
+			wantQuery: &Query{
+				SQL:  "SELECT CONCAT(`name`,?,`id`) AS `label` FROM `product_model`;",
+				Args: []any{"-"},
+			},
+		},
+		{
+			name:    "sqlite concat",
+			builder: NewSelector[ProductModel](sqlite).Select(Col("Name").Concat("-", Col("Price").Add(1)).As("label")),
+			wantQuery: &Query{
+				SQL:  "SELECT (`name` || ? || (`price` + ?)) AS `label` FROM `product_model`;",
+				Args: []any{"-", 1},
+			},
+		},
+		{
+			name:    "postgres concat",
+			builder: NewSelector[ProductModel](pg).Where(Col("Name").Concat("-").Eq("a-")),
+			wantQuery: &Query{
+				SQL:  `SELECT * FROM "product_model" WHERE ("name" || $1) = $2;`,
+				Args: []any{"-", "a-"},
+			},
+		},
+		{
+			name: "upsert",
+			builder: NewInserter[ProductModel](mysql).Columns("Id", "Stock").Values(&ProductModel{Id: 1, Stock: 3}).
+				OnDuplicateKey().Update(Assign("Stock", Col("Stock").Add(3))),
+			wantQuery: &Query{
+				SQL:  "INSERT INTO `product_model` (`id`,`stock`) VALUES (?,?) ON DUPLICATE KEY UPDATE `stock` = `stock` + ?;",
+				Args: []any{int64(1), int64(3), 3},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			q, err := tc.builder.Build()
+			assert.Equal(t, tc.wantErr, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.wantQuery, q)
+		})
+	}
+}
+
+func TestFunction_SQLite(t *testing.T) {
+	db := memoryWithDB("function", t, DBWithDialect(DialectSQLite3))
+	ctx := context.Background()
+	require.NoError(t, db.AutoMigrate(ctx, &ProductModel{}))
+	require.NoError(t, NewInserter[ProductModel](db).Values(
+		&ProductModel{Id: 1, Name: "Apple", Price: 3, Stock: 10},
+		&ProductModel{Id: 2, Name: "Pear", Nickname: sql.NullString{String: "P", Valid: true}, Price: 5, Stock: 1},
+	).Exec(ctx).Err())
+
+	res := NewUpdater[ProductModel](db).Set(Assign("Stock", Col("Stock").Sub(2))).Where(Col("Stock").Ge(2)).Exec(ctx)
+	affected, err := res.RowsAffected()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), affected)
+
+	list, err := NewSelector[ProductModel](db).
+		Where(Col("Price").Mul(Col("Stock")).Gt(20)).
+		OrderBy(Col("Price").Mul(Col("Stock")).Desc()).GetMulti(ctx)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, int64(8), list[0].Stock)
+
+	list, err = NewSelector[ProductModel](db).
+		Where(Lower(Concat(Coalesce(Col("Nickname"), Col("Name")), "!")).Eq("apple!")).GetMulti(ctx)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, int64(1), list[0].Id)
+}
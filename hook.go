@@ -0,0 +1,77 @@
+package orm
+
+import "context"
+
+// BeforeInsertHook 插入前调用，可以在这里规范化数据或者校验，返回 error 会中止插入
+type BeforeInsertHook interface {
+	BeforeInsert(ctx context.Context, c *Context) error
+}
+
+// AfterInsertHook 插入成功后调用，自增主键已经回填
+type AfterInsertHook interface {
+	AfterInsert(ctx context.Context, c *Context) error
+}
+
+// BeforeUpdateHook 更新前调用，只对 UpdateEntity, Save 等携带实体的更新生效
+// 直接使用 Updater 构造的批量更新不会调用钩子
+type BeforeUpdateHook interface {
+	BeforeUpdate(ctx context.Context, c *Context) error
+}
+
+// AfterUpdateHook 更新成功后调用，只对携带实体的更新生效
+type AfterUpdateHook interface {
+	AfterUpdate(ctx context.Context, c *Context) error
+}
+
+// BeforeDeleteHook 删除前调用，只对 DeleteEntity 等携带实体的删除生效
+// 直接使用 Deleter 构造的批量删除不会调用钩子
+type BeforeDeleteHook interface {
+	BeforeDelete(ctx context.Context, c *Context) error
+}
+
+// AfterDeleteHook 删除成功后调用，只对携带实体的删除生效
+type AfterDeleteHook interface {
+	AfterDelete(ctx context.Context, c *Context) error
+}
+
+// AfterFindHook 查询结果扫描完成后调用
+type AfterFindHook interface {
+	AfterFind(ctx context.Context) error
+}
+
+// hookContext 执行前钩子使用的 Context，此时 SQL 尚未构造
+func hookContext(ctx context.Context, c *core, opType string, entity any) (*Context, error) {
+	m, err := c.r.Get(entity)
+	if err != nil {
+		return nil, err
+	}
+	return &Context{
+		Type:  opType,
+		Model: m,
+		Ctx:   ctx,
+	}, nil
+}
+
+// afterFind 对查询结果调用 AfterFind
+func afterFind[T any](ctx context.Context, entities ...*T) error {
+	for _, e := range entities {
+		if h, ok := any(e).(AfterFindHook); ok {
+			if err := h.AfterFind(ctx); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// resultWithErr 语句执行成功，但是后续的回填或者钩子返回错误
+func resultWithErr(res *Result, err error) *Result {
+	er, _ := res.Res.(ExecResult)
+	return &Result{
+		Res: ExecResult{
+			res: er.res,
+			err: err,
+		},
+		Err: err,
+	}
+}
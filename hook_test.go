@@ -0,0 +1,221 @@
+package orm
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+var errInvalidName = errors.New("invalid name")
+
+type HookModel struct {
+	Id   int64
+	Name string
+
+	calls []string
+}
+
+func (h *HookModel) BeforeInsert(ctx context.Context, c *Context) error {
+	h.calls = append(h.calls, "BeforeInsert:"+c.Type)
+	if h.Name == "" {
+		return errInvalidName
+	}
+	h.Name = strings.TrimSpace(h.Name)
+	return nil
+}
+
+func (h *HookModel) AfterInsert(ctx context.Context, c *Context) error {
+	h.calls = append(h.calls, "AfterInsert:"+c.Query.SQL)
+	return nil
+}
+
+func (h *HookModel) BeforeUpdate(ctx context.Context, c *Context) error {
+	h.calls = append(h.calls, "BeforeUpdate")
+	h.Name = strings.ToUpper(h.Name)
+	return nil
+}
+
+func (h *HookModel) AfterUpdate(ctx context.Context, c *Context) error {
+	h.calls = append(h.calls, "AfterUpdate")
+	return nil
+}
+
+func (h *HookModel) BeforeDelete(ctx context.Context, c *Context) error {
+	h.calls = append(h.calls, "BeforeDelete")
+	if h.Id == 1 {
+		return errors.New("can not delete root")
+	}
+	return nil
+}
+
+func (h *HookModel) AfterDelete(ctx context.Context, c *Context) error {
+	h.calls = append(h.calls, "AfterDelete")
+	return nil
+}
+
+func (h *HookModel) AfterFind(ctx context.Context) error {
+	if h.Name == "bad" {
+		return errInvalidName
+	}
+	h.Name = "found:" + h.Name
+	return nil
+}
+
+func TestHook_Insert(t *testing.T) {
+	mockDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	db, err := OpenDB(mockDB, DBWithDialect(DialectMySQL))
+	require.NoError(t, err)
+
+	mock.ExpectExec("INSERT INTO `hook_model` (`name`) VALUES (?);").
+		WithArgs("tom").
+		WillReturnResult(sqlmock.NewResult(3, 1))
+	entity := &HookModel{Name: "  tom "}
+	res := NewInserter[HookModel](db).Columns("Name").Values(entity).Returning("Id").Exec(context.Background())
+	require.NoError(t, res.Err())
+	assert.Equal(t, int64(3), entity.Id)
+	assert.Equal(t, []string{"BeforeInsert:INSERT", "AfterInsert:INSERT INTO `hook_model` (`name`) VALUES (?);"}, entity.calls)
+
+	// BeforeInsert 返回错误，不会执行 SQL
+	res = NewInserter[HookModel](db).Columns("Name").Values(&HookModel{}).Exec(context.Background())
+	assert.Equal(t, errInvalidName, res.Err())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestHook_UpdateDelete(t *testing.T) {
+	mockDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	db, err := OpenDB(mockDB, DBWithDialect(DialectMySQL))
+	require.NoError(t, err)
+
+	mock.ExpectExec("UPDATE `hook_model` SET `name` = ? WHERE `id` = ?;").
+		WithArgs("TOM", int64(2)).
+		WillReturnResult(driver.RowsAffected(1))
+	entity := &HookModel{Id: 2, Name: "tom"}
+	require.NoError(t, UpdateEntity(context.Background(), db, entity).Err())
+	assert.Equal(t, []string{"BeforeUpdate", "AfterUpdate"}, entity.calls)
+
+	mock.ExpectExec("DELETE FROM `hook_model` WHERE `id` = ?;").
+		WithArgs(int64(2)).
+		WillReturnResult(driver.RowsAffected(1))
+	entity.calls = nil
+	require.NoError(t, DeleteEntity(context.Background(), db, entity).Err())
+	assert.Equal(t, []string{"BeforeDelete", "AfterDelete"}, entity.calls)
+
+	err = DeleteEntity(context.Background(), db, &HookModel{Id: 1}).Err()
+	assert.Equal(t, errors.New("can not delete root"), err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// HookBuilderModel 记录通过 Updater, Deleter 构造的语句触发的钩子
+type HookBuilderModel struct {
+	Id   int64
+	Name string
+}
+
+var hookBuilderCalls []string
+
+func (h *HookBuilderModel) BeforeUpdate(ctx context.Context, c *Context) error {
+	hookBuilderCalls = append(hookBuilderCalls, "BeforeUpdate")
+	return nil
+}
+
+func (h *HookBuilderModel) AfterUpdate(ctx context.Context, c *Context) error {
+	hookBuilderCalls = append(hookBuilderCalls, "AfterUpdate")
+	return nil
+}
+
+func (h *HookBuilderModel) BeforeDelete(ctx context.Context, c *Context) error {
+	hookBuilderCalls = append(hookBuilderCalls, "BeforeDelete")
+	return nil
+}
+
+func (h *HookBuilderModel) AfterDelete(ctx context.Context, c *Context) error {
+	hookBuilderCalls = append(hookBuilderCalls, "AfterDelete")
+	return nil
+}
+
+// 不携带实体的批量更新和删除不调用钩子
+func TestHook_Builder(t *testing.T) {
+	mockDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	db, err := OpenDB(mockDB, DBWithDialect(DialectMySQL))
+	require.NoError(t, err)
+	hookBuilderCalls = nil
+
+	mock.ExpectExec("UPDATE `hook_builder_model` SET `name` = ? WHERE `id` > ?;").
+		WithArgs("tom", 1).
+		WillReturnResult(driver.RowsAffected(2))
+	err = NewUpdater[HookBuilderModel](db).Set(Assign("Name", "tom")).
+		Where(Col("Id").Gt(1)).Exec(context.Background()).Err()
+	require.NoError(t, err)
+
+	mock.ExpectExec("DELETE FROM `hook_builder_model` WHERE `id` > ?;").
+		WithArgs(1).
+		WillReturnResult(driver.RowsAffected(2))
+	err = NewDeleter[HookBuilderModel](db).Where(Col("Id").Gt(1)).Exec(context.Background()).Err()
+	require.NoError(t, err)
+
+	assert.Empty(t, hookBuilderCalls)
+
+	// 携带实体时调用钩子
+	mock.ExpectExec("DELETE FROM `hook_builder_model` WHERE `id` = ?;").
+		WithArgs(int64(3)).
+		WillReturnResult(driver.RowsAffected(1))
+	err = DeleteEntity(context.Background(), db, &HookBuilderModel{Id: 3}).Err()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"BeforeDelete", "AfterDelete"}, hookBuilderCalls)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestHook_AfterFind(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	db, err := OpenDB(mockDB, DBWithDialect(DialectMySQL))
+	require.NoError(t, err)
+
+	mock.ExpectQuery("SELECT .*").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "tom"))
+	res, err := NewSelector[HookModel](db).Get(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "found:tom", res.Name)
+
+	mock.ExpectQuery("SELECT .*").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "tom").AddRow(2, "jerry"))
+	list, err := NewSelector[HookModel](db).GetMulti(context.Background())
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, "found:tom", list[0].Name)
+	assert.Equal(t, "found:jerry", list[1].Name)
+
+	mock.ExpectQuery("SELECT .*").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "bad"))
+	_, err = RawQuery[HookModel](db, "SELECT * FROM hook_model;").Get(context.Background())
+	assert.Equal(t, errInvalidName, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestHook_AbortTx(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	db, err := OpenDB(mockDB, DBWithDialect(DialectMySQL))
+	require.NoError(t, err)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO `hook_model` .*").WillReturnResult(driver.RowsAffected(1))
+	mock.ExpectRollback()
+
+	err = db.DoTx(context.Background(), func(ctx context.Context, tx *Tx) error {
+		if err := NewInserter[HookModel](tx).Columns("Name").Values(&HookModel{Name: "tom"}).Exec(ctx).Err(); err != nil {
+			return err
+		}
+		return NewInserter[HookModel](tx).Columns("Name").Values(&HookModel{}).Exec(ctx).Err()
+	}, nil)
+	assert.Equal(t, errInvalidName, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -2,8 +2,10 @@ package orm
 
 import (
 	"context"
+	"database/sql/driver"
 	"github.com/KNICEX/go-orm/internal/errs"
 	"github.com/KNICEX/go-orm/model"
+	"reflect"
 )
 
 type UpsertBuilder[T any] struct {
@@ -41,8 +43,12 @@ type Inserter[T any] struct {
 
 	values  []*T
 	columns []string
+	// 插入后需要回填到 values 中的字段
+	returning []string
 
 	onDuplicateKey *Upsert
+	// 单条语句的最大参数数量，0 时使用方言的限制
+	maxParams int
 }
 
 func NewInserter[T any](sess Session) *Inserter[T] {
@@ -74,23 +80,23 @@ func (i *Inserter[T]) Build() (*Query, error) {
 	}
 	i.model = m
 
+	// 填充创建时间和更新时间
+	now := i.now()
+	for _, v := range i.values {
+		if err = i.fillAutoTime(i.creator(m, v), now); err != nil {
+			return nil, err
+		}
+	}
+
 	i.quote(m.TableName)
 	i.sb.WriteByte(' ')
 
 	// 构造列名
 	i.sb.WriteByte('(')
 
-	fields := m.Fields
-
-	if len(i.columns) > 0 {
-		fields = make([]*model.Field, 0, len(i.columns))
-		for _, fd := range i.columns {
-			fdMeta, ok := m.FieldMap[fd]
-			if !ok {
-				return nil, errs.NewErrUnknownField(fd)
-			}
-			fields = append(fields, fdMeta)
-		}
+	fields, err := i.fields(m)
+	if err != nil {
+		return nil, err
 	}
 
 	for idx, field := range fields {
@@ -134,14 +140,97 @@ func (i *Inserter[T]) Build() (*Query, error) {
 		}
 	}
 
+	if err = i.buildReturning(); err != nil {
+		return nil, err
+	}
+
 	i.sb.WriteByte(';')
 
 	return &Query{
-		SQL:  i.sb.String(),
+		SQL:  i.dialect.rebind(i.sb.String()),
 		Args: i.args,
 	}, nil
 }
 
+// fields 插入的列，未指定列时使用 defaultFields
+func (i *Inserter[T]) fields(m *model.Model) ([]*model.Field, error) {
+	if len(i.columns) == 0 {
+		return i.defaultFields(m)
+	}
+	fields := make([]*model.Field, 0, len(i.columns))
+	for _, fd := range i.columns {
+		fdMeta, ok := m.FieldMap[fd]
+		if !ok {
+			return nil, errs.NewErrUnknownField(fd)
+		}
+		fields = append(fields, fdMeta)
+	}
+	return fields, nil
+}
+
+// defaultFields 未指定列时插入的字段
+// 所有行的值都是零值的自增列会被跳过，交给数据库生成
+func (i *Inserter[T]) defaultFields(m *model.Model) ([]*model.Field, error) {
+	fields := make([]*model.Field, 0, len(m.Fields))
+	for _, fd := range m.Fields {
+		if fd.AutoIncrement {
+			allZero := true
+			for _, v := range i.values {
+				arg, err := i.creator(m, v).Field(fd.GoName)
+				if err != nil {
+					return nil, err
+				}
+				if !reflect.ValueOf(arg).IsZero() {
+					allZero = false
+					break
+				}
+			}
+			if allZero {
+				continue
+			}
+		}
+		fields = append(fields, fd)
+	}
+	return fields, nil
+}
+
+// buildReturning 构造 RETURNING 子句
+// 不支持 RETURNING 的方言只允许回填一个自增列，由 LastInsertId 推算
+func (i *Inserter[T]) buildReturning() error {
+	if len(i.returning) == 0 {
+		return nil
+	}
+	fields := make([]*model.Field, 0, len(i.returning))
+	for _, fd := range i.returning {
+		fdMeta, ok := i.model.FieldMap[fd]
+		if !ok {
+			return errs.NewErrUnknownField(fd)
+		}
+		fields = append(fields, fdMeta)
+	}
+	if !i.dialect.supportReturning() {
+		if len(fields) > 1 {
+			return errs.ErrReturningMultiColumns
+		}
+		if !fields[0].AutoIncrement {
+			return errs.ErrReturningNotAutoInc
+		}
+		// 更新已有行时 LastInsertId 和插入的行不再对应
+		if i.onDuplicateKey != nil {
+			return errs.ErrReturningUpsert
+		}
+		return nil
+	}
+	i.sb.WriteString(" RETURNING ")
+	for idx, field := range fields {
+		if idx > 0 {
+			i.sb.WriteByte(',')
+		}
+		i.quote(field.ColName)
+	}
+	return nil
+}
+
 func (i *Inserter[T]) Values(values ...*T) *Inserter[T] {
 	i.values = append(i.values, values...)
 	return i
@@ -152,6 +241,20 @@ func (i *Inserter[T]) Columns(cols ...string) *Inserter[T] {
 	return i
 }
 
+// MaxParams 覆盖方言中单条语句的最大参数数量，超出时 Exec 会拆分为多条语句
+func (i *Inserter[T]) MaxParams(n int) *Inserter[T] {
+	i.maxParams = n
+	return i
+}
+
+// Returning 指定插入后回填到 Values 传入的结构体中的字段，例如自增主键和有默认值的列
+// Postgres 和 SQLite 使用 RETURNING，其余方言只支持一个自增列，通过 LastInsertId 按行号推算
+// 通过 LastInsertId 推算时不能和 OnDuplicateKey 一起使用
+func (i *Inserter[T]) Returning(fields ...string) *Inserter[T] {
+	i.returning = fields
+	return i
+}
+
 // execHandler 执行各种exec操作
 func execHandler(ctx *Context, sess Session) *Result {
 	res, err := sess.execContext(ctx.Ctx, ctx.Query.SQL, ctx.Query.Args...)
@@ -175,6 +278,14 @@ func execHandler(ctx *Context, sess Session) *Result {
 // exec 执行各种exec操作
 // 包装一些相同的操作：构建sql，构造handler链，构造Context
 func exec(ctx context.Context, builder SqlBuilder, sess Session, c *core, opType string) ExecResult {
+	// 将 execHandler 包装成 Handler
+	return execWithHandler(ctx, builder, c, opType, func(ctx *Context) *Result {
+		return execHandler(ctx, sess)
+	})
+}
+
+// execWithHandler 使用指定的 handler 执行, 用于需要特殊处理结果的 exec 操作
+func execWithHandler(ctx context.Context, builder SqlBuilder, c *core, opType string, root Handler) ExecResult {
 	q, err := builder.Build()
 	if err != nil {
 		return ExecResult{
@@ -182,11 +293,6 @@ func exec(ctx context.Context, builder SqlBuilder, sess Session, c *core, opType
 		}
 	}
 
-	// 将 execHandler 包装成 Handler
-	var root Handler = func(ctx *Context) *Result {
-		return execHandler(ctx, sess)
-	}
-
 	for i := len(c.middlewares) - 1; i >= 0; i-- {
 		root = c.middlewares[i](root)
 	}
@@ -201,6 +307,218 @@ func exec(ctx context.Context, builder SqlBuilder, sess Session, c *core, opType
 	return res.Res.(ExecResult)
 }
 
+// Exec 执行插入，参数数量超过 MaxParams 或者方言的限制时按行拆分为多条语句依次执行
+// 拆分后不在事务中时会开启一个事务，返回结果的 RowsAffected 为各条语句之和
 func (i *Inserter[T]) Exec(ctx context.Context) ExecResult {
-	return exec(ctx, i, i.sess, i.core, INSERT)
+	if err := i.beforeInsert(ctx); err != nil {
+		return ExecResult{
+			err: err,
+		}
+	}
+	chunks, err := i.chunks()
+	if err != nil {
+		return ExecResult{
+			err: err,
+		}
+	}
+	if len(chunks) <= 1 {
+		return execWithHandler(ctx, i, i.core, INSERT, i.insertHandler)
+	}
+
+	return execChunks(ctx, i.sess, len(chunks), func(ctx context.Context, sess Session, idx int) ExecResult {
+		c := NewInserter[T](sess).Values(chunks[idx]...)
+		c.columns = i.columns
+		c.returning = i.returning
+		c.onDuplicateKey = i.onDuplicateKey
+		return execWithHandler(ctx, c, c.core, INSERT, c.insertHandler)
+	})
+}
+
+// chunks 按参数数量限制将 values 分组，ON DUPLICATE KEY 中的赋值也会占用参数
+func (i *Inserter[T]) chunks() ([][]*T, error) {
+	limit := i.maxParams
+	if limit <= 0 {
+		limit = i.dialect.maxParams()
+	}
+	if len(i.values) == 0 || limit <= 0 {
+		return nil, nil
+	}
+	m, err := i.r.Get(i.values[0])
+	if err != nil {
+		return nil, err
+	}
+	fields, err := i.fields(m)
+	if err != nil {
+		return nil, err
+	}
+	if i.onDuplicateKey != nil {
+		for _, a := range i.onDuplicateKey.assigns {
+			if _, ok := a.(Assignment); ok {
+				limit--
+			}
+		}
+	}
+	return chunk(i.values, max(limit/max(len(fields), 1), 1)), nil
+}
+
+// chunk 将 values 按 size 分组
+func chunk[T any](values []T, size int) [][]T {
+	res := make([][]T, 0, (len(values)+size-1)/size)
+	for start := 0; start < len(values); start += size {
+		res = append(res, values[start:min(start+size, len(values))])
+	}
+	return res
+}
+
+// execChunks 依次执行拆分后的 n 条语句，sess 不是事务时在一个事务中执行
+// 返回结果的 RowsAffected 为各条语句之和
+func execChunks(ctx context.Context, sess Session, n int, fn func(ctx context.Context, sess Session, idx int) ExecResult) ExecResult {
+	run := func(ctx context.Context, sess Session) ExecResult {
+		results := make(multiResult, 0, n)
+		for idx := 0; idx < n; idx++ {
+			res := fn(ctx, sess, idx)
+			if res.err != nil {
+				return res
+			}
+			results = append(results, res.res)
+		}
+		return ExecResult{
+			res: results,
+		}
+	}
+	db, ok := sess.(*DB)
+	if !ok {
+		return run(ctx, sess)
+	}
+	var res ExecResult
+	err := db.DoTx(ctx, func(ctx context.Context, tx *Tx) error {
+		res = run(ctx, tx)
+		return res.err
+	}, nil)
+	if err != nil {
+		return ExecResult{
+			err: err,
+		}
+	}
+	return res
+}
+
+// beforeInsert 插入前调用 BeforeInsert 钩子
+func (i *Inserter[T]) beforeInsert(ctx context.Context) error {
+	if len(i.values) == 0 {
+		return nil
+	}
+	var hc *Context
+	for _, v := range i.values {
+		h, ok := any(v).(BeforeInsertHook)
+		if !ok {
+			continue
+		}
+		if hc == nil {
+			var err error
+			if hc, err = hookContext(ctx, i.core, INSERT, v); err != nil {
+				return err
+			}
+		}
+		if err := h.BeforeInsert(ctx, hc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// insertHandler 根据是否需要回填选择执行方式，成功后调用 AfterInsert 钩子
+func (i *Inserter[T]) insertHandler(ctx *Context) *Result {
+	var res *Result
+	switch {
+	case len(i.returning) == 0:
+		res = execHandler(ctx, i.sess)
+	case i.dialect.supportReturning():
+		res = i.returningHandler(ctx)
+	default:
+		res = i.backfillHandler(ctx)
+	}
+	if res.Err != nil {
+		return res
+	}
+	for _, v := range i.values {
+		if h, ok := any(v).(AfterInsertHook); ok {
+			if err := h.AfterInsert(ctx.Ctx, ctx); err != nil {
+				return resultWithErr(res, err)
+			}
+		}
+	}
+	return res
+}
+
+// returningHandler 执行 INSERT ... RETURNING，并按顺序将返回的行回填到 values
+func (i *Inserter[T]) returningHandler(ctx *Context) *Result {
+	rows, err := i.sess.queryContext(ctx.Ctx, ctx.Query.SQL, ctx.Query.Args...)
+	if err != nil {
+		return &Result{
+			Res: ExecResult{
+				err: err,
+			},
+			Err: err,
+		}
+	}
+	defer rows.Close()
+
+	var affected int64
+	for rows.Next() {
+		if int(affected) < len(i.values) {
+			err = i.creator(i.model, i.values[affected]).SetColumns(rows)
+			if err != nil {
+				return &Result{
+					Res: ExecResult{
+						err: err,
+					},
+					Err: err,
+				}
+			}
+		}
+		affected++
+	}
+	if err = rows.Err(); err != nil {
+		return &Result{
+			Res: ExecResult{
+				err: err,
+			},
+			Err: err,
+		}
+	}
+	return &Result{
+		Res: ExecResult{
+			res: driver.RowsAffected(affected),
+		},
+	}
+}
+
+// backfillHandler 通过 LastInsertId 回填自增列
+// MySQL 批量插入时 LastInsertId 为第一个自动生成的自增值，后续生成的值依次递增
+// 已经显式指定了值的行不会被覆盖，也不参与计数
+func (i *Inserter[T]) backfillHandler(ctx *Context) *Result {
+	res := execHandler(ctx, i.sess)
+	if res.Err != nil {
+		return res
+	}
+	id, err := res.Res.(ExecResult).LastInsertId()
+	if err != nil {
+		return resultWithErr(res, err)
+	}
+	for _, v := range i.values {
+		val := i.creator(i.model, v)
+		cur, err := val.Field(i.returning[0])
+		if err != nil {
+			return resultWithErr(res, err)
+		}
+		if cur != nil && !reflect.ValueOf(cur).IsZero() {
+			continue
+		}
+		if err = val.SetField(i.returning[0], id); err != nil {
+			return resultWithErr(res, err)
+		}
+		id++
+	}
+	return res
 }
@@ -89,6 +89,24 @@ func TestInserter_MySQL_Build(t *testing.T) {
 			i:       NewInserter[TestModel](db),
 			wantErr: errs.ErrInsertZeroRow,
 		},
+		{
+			name: "skip zero auto increment",
+			i: NewInserter[TestModel](db).Values(&TestModel{FirstName: "a", LastName: "b"},
+				&TestModel{FirstName: "c", LastName: "d"}),
+			wantQuery: &Query{
+				SQL:  "INSERT INTO `test_model` (`first_name`,`last_name`) VALUES (?,?),(?,?);",
+				Args: []any{"a", "b", "c", "d"},
+			},
+		},
+		{
+			name: "keep auto increment if any row set",
+			i: NewInserter[TestModel](db).Values(&TestModel{FirstName: "a", LastName: "b"},
+				&TestModel{Id: 2, FirstName: "c", LastName: "d"}),
+			wantQuery: &Query{
+				SQL:  "INSERT INTO `test_model` (`id`,`first_name`,`last_name`) VALUES (?,?,?),(?,?,?);",
+				Args: []any{int64(0), "a", "b", int64(2), "c", "d"},
+			},
+		},
 		{
 			name: "specified columns",
 			i: NewInserter[TestModel](db).Columns("FirstName", "LastName").
@@ -125,6 +143,35 @@ func TestInserter_MySQL_Build(t *testing.T) {
 				Args: []any{int64(1), "a", "b"},
 			},
 		},
+		{
+			// MySQL 不支持 RETURNING，不会生成 RETURNING 子句
+			name: "returning",
+			i: NewInserter[TestModel](db).Columns("FirstName", "LastName").
+				Values(&TestModel{FirstName: "a", LastName: "b"}).Returning("Id"),
+			wantQuery: &Query{
+				SQL:  "INSERT INTO `test_model` (`first_name`,`last_name`) VALUES (?,?);",
+				Args: []any{"a", "b"},
+			},
+		},
+		{
+			name: "returning multi columns",
+			i: NewInserter[TestModel](db).Columns("FirstName", "LastName").
+				Values(&TestModel{FirstName: "a", LastName: "b"}).Returning("Id", "FirstName"),
+			wantErr: errs.ErrReturningMultiColumns,
+		},
+		{
+			name: "returning not auto increment",
+			i: NewInserter[TestModel](db).Columns("FirstName", "LastName").
+				Values(&TestModel{FirstName: "a", LastName: "b"}).Returning("FirstName"),
+			wantErr: errs.ErrReturningNotAutoInc,
+		},
+		{
+			name: "returning with upsert",
+			i: NewInserter[TestModel](db).Columns("FirstName", "LastName").
+				Values(&TestModel{FirstName: "a", LastName: "b"}).Returning("Id").
+				OnDuplicateKey().Update(Col("LastName")),
+			wantErr: errs.ErrReturningUpsert,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -139,6 +186,105 @@ func TestInserter_MySQL_Build(t *testing.T) {
 	}
 }
 
+func TestInserter_Postgres_Build(t *testing.T) {
+	db, err := OpenDB(nil, DBWithDialect(DialectPostgres))
+	require.NoError(t, err)
+	testCases := []struct {
+		name      string
+		i         *Inserter[TestModel]
+		wantQuery *Query
+		wantErr   error
+	}{
+		{
+			name: "multi row",
+			i: NewInserter[TestModel](db).Values(&TestModel{Id: 1, FirstName: "a", LastName: "b"},
+				&TestModel{Id: 2, FirstName: "c", LastName: "d"}),
+			wantQuery: &Query{
+				SQL:  `INSERT INTO "test_model" ("id","first_name","last_name") VALUES ($1,$2,$3),($4,$5,$6);`,
+				Args: []any{int64(1), "a", "b", int64(2), "c", "d"},
+			},
+		},
+		{
+			name: "upsert with new value",
+			i: NewInserter[TestModel](db).Values(&TestModel{Id: 1, FirstName: "a", LastName: "b"}).
+				OnDuplicateKey().ConflictColumns("Id").Update(Assign("FirstName", "newA"), Assign("LastName", "newB")),
+			wantQuery: &Query{
+				SQL: `INSERT INTO "test_model" ("id","first_name","last_name") VALUES ($1,$2,$3) ` +
+					`ON CONFLICT ("id") DO UPDATE SET "first_name" = $4,"last_name" = $5;`,
+				Args: []any{int64(1), "a", "b", "newA", "newB"},
+			},
+		},
+		{
+			name: "upsert with column value",
+			i: NewInserter[TestModel](db).Values(&TestModel{Id: 1, FirstName: "a", LastName: "b"}).
+				OnDuplicateKey().ConflictColumns("Id", "FirstName").Update(Col("LastName")),
+			wantQuery: &Query{
+				SQL: `INSERT INTO "test_model" ("id","first_name","last_name") VALUES ($1,$2,$3) ` +
+					`ON CONFLICT ("id","first_name") DO UPDATE SET "last_name" = EXCLUDED."last_name";`,
+				Args: []any{int64(1), "a", "b"},
+			},
+		},
+		{
+			name: "upsert without conflict columns",
+			i: NewInserter[TestModel](db).Values(&TestModel{Id: 1, FirstName: "a", LastName: "b"}).
+				OnDuplicateKey().Update(Col("LastName")),
+			wantErr: errs.ErrUpsertNoConflictColumns,
+		},
+		{
+			name: "returning",
+			i: NewInserter[TestModel](db).Columns("FirstName", "LastName").
+				Values(&TestModel{FirstName: "a", LastName: "b"}).Returning("Id"),
+			wantQuery: &Query{
+				SQL:  `INSERT INTO "test_model" ("first_name","last_name") VALUES ($1,$2) RETURNING "id";`,
+				Args: []any{"a", "b"},
+			},
+		},
+		{
+			name: "returning unknown field",
+			i: NewInserter[TestModel](db).Values(&TestModel{FirstName: "a", LastName: "b"}).
+				Returning("Unknown"),
+			wantErr: errs.NewErrUnknownField("Unknown"),
+		},
+		{
+			name: "upsert unknown conflict column",
+			i: NewInserter[TestModel](db).Values(&TestModel{Id: 1, FirstName: "a", LastName: "b"}).
+				OnDuplicateKey().ConflictColumns("Unknown").Update(Col("LastName")),
+			wantErr: errs.NewErrUnknownField("Unknown"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			q, err := tc.i.Build()
+			assert.Equal(t, tc.wantErr, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.wantQuery, q)
+		})
+	}
+}
+
+func TestInserter_Postgres_Exec(t *testing.T) {
+	mockDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	db, err := OpenDB(mockDB, DBWithDialect(DialectPostgres))
+	require.NoError(t, err)
+
+	mock.ExpectExec(`INSERT INTO "test_model" ("id","first_name","last_name") VALUES ($1,$2,$3) `+
+		`ON CONFLICT ("id") DO UPDATE SET "first_name" = EXCLUDED."first_name";`).
+		WithArgs(int64(1), "a", "b").
+		WillReturnResult(driver.RowsAffected(1))
+
+	res := NewInserter[TestModel](db).Values(&TestModel{Id: 1, FirstName: "a", LastName: "b"}).
+		OnDuplicateKey().ConflictColumns("Id").Update(Col("FirstName")).
+		Exec(context.Background())
+	affected, err := res.RowsAffected()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), affected)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestInserter_Exec(t *testing.T) {
 	mockDB, mock, err := sqlmock.New()
 	require.NoError(t, err)
@@ -191,3 +337,198 @@ func TestInserter_Exec(t *testing.T) {
 	}
 
 }
+
+func TestInserter_Returning(t *testing.T) {
+	t.Run("postgres", func(t *testing.T) {
+		mockDB, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		db, err := OpenDB(mockDB, DBWithDialect(DialectPostgres))
+		require.NoError(t, err)
+
+		mock.ExpectQuery(`INSERT INTO "test_model" .* RETURNING "id","last_name";`).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "last_name"}).
+				AddRow(11, "default").
+				AddRow(12, "d"))
+
+		vals := []*TestModel{{FirstName: "a"}, {FirstName: "c", LastName: "d"}}
+		res := NewInserter[TestModel](db).Columns("FirstName", "LastName").
+			Values(vals...).Returning("Id", "LastName").Exec(context.Background())
+		affected, err := res.RowsAffected()
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), affected)
+		assert.Equal(t, []*TestModel{
+			{Id: 11, FirstName: "a", LastName: "default"},
+			{Id: 12, FirstName: "c", LastName: "d"},
+		}, vals)
+	})
+
+	t.Run("postgres query error", func(t *testing.T) {
+		mockDB, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		db, err := OpenDB(mockDB, DBWithDialect(DialectPostgres))
+		require.NoError(t, err)
+
+		mock.ExpectQuery(`INSERT INTO "test_model" .*`).WillReturnError(errors.New("db err"))
+		res := NewInserter[TestModel](db).Values(&TestModel{}).Returning("Id").Exec(context.Background())
+		assert.Equal(t, errors.New("db err"), res.Err())
+	})
+
+	t.Run("mysql last insert id", func(t *testing.T) {
+		mockDB, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		db, err := OpenDB(mockDB, DBWithDialect(DialectMySQL))
+		require.NoError(t, err)
+
+		mock.ExpectExec("INSERT INTO `test_model` .*").
+			WillReturnResult(sqlmock.NewResult(21, 3))
+
+		vals := []*TestModel{{FirstName: "a"}, {FirstName: "b"}, {FirstName: "c"}}
+		res := NewInserter[TestModel](db).Columns("FirstName").
+			Values(vals...).Returning("Id").Exec(context.Background())
+		require.NoError(t, res.Err())
+		assert.Equal(t, []*TestModel{
+			{Id: 21, FirstName: "a"},
+			{Id: 22, FirstName: "b"},
+			{Id: 23, FirstName: "c"},
+		}, vals)
+	})
+
+	t.Run("mysql last insert id with explicit id", func(t *testing.T) {
+		mockDB, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		db, err := OpenDB(mockDB, DBWithDialect(DialectMySQL))
+		require.NoError(t, err)
+
+		mock.ExpectExec("INSERT INTO `test_model` .*").
+			WillReturnResult(sqlmock.NewResult(21, 3))
+
+		vals := []*TestModel{{FirstName: "a"}, {Id: 5, FirstName: "b"}, {FirstName: "c"}}
+		res := NewInserter[TestModel](db).Columns("Id", "FirstName").
+			Values(vals...).Returning("Id").Exec(context.Background())
+		require.NoError(t, res.Err())
+		assert.Equal(t, []*TestModel{
+			{Id: 21, FirstName: "a"},
+			{Id: 5, FirstName: "b"},
+			{Id: 22, FirstName: "c"},
+		}, vals)
+	})
+
+	t.Run("sqlite", func(t *testing.T) {
+		db := memoryWithDB("returning", t, DBWithDialect(DialectSQLite3), DBUseReflect())
+		err := RawQuery[any](db, "CREATE TABLE `test_model` ("+
+			"`id` INTEGER PRIMARY KEY AUTOINCREMENT,"+
+			"`first_name` TEXT NOT NULL,"+
+			"`last_name` TEXT NOT NULL DEFAULT 'unknown');").
+			Exec(context.Background()).Err()
+		require.NoError(t, err)
+
+		vals := []*TestModel{{FirstName: "a"}, {FirstName: "b"}}
+		res := NewInserter[TestModel](db).Columns("FirstName").
+			Values(vals...).Returning("Id", "LastName").Exec(context.Background())
+		require.NoError(t, res.Err())
+		assert.Equal(t, []*TestModel{
+			{Id: 1, FirstName: "a", LastName: "unknown"},
+			{Id: 2, FirstName: "b", LastName: "unknown"},
+		}, vals)
+	})
+}
+
+func TestInserter_Chunk(t *testing.T) {
+	t.Run("db", func(t *testing.T) {
+		mockDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		require.NoError(t, err)
+		db, err := OpenDB(mockDB, DBWithDialect(DialectMySQL))
+		require.NoError(t, err)
+
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO `test_model` (`first_name`) VALUES (?),(?);").
+			WithArgs("a", "b").
+			WillReturnResult(sqlmock.NewResult(1, 2))
+		mock.ExpectExec("INSERT INTO `test_model` (`first_name`) VALUES (?),(?);").
+			WithArgs("c", "d").
+			WillReturnResult(sqlmock.NewResult(3, 2))
+		mock.ExpectExec("INSERT INTO `test_model` (`first_name`) VALUES (?);").
+			WithArgs("e").
+			WillReturnResult(sqlmock.NewResult(5, 1))
+		mock.ExpectCommit()
+
+		vals := []*TestModel{{FirstName: "a"}, {FirstName: "b"}, {FirstName: "c"}, {FirstName: "d"}, {FirstName: "e"}}
+		res := NewInserter[TestModel](db).Columns("FirstName").Values(vals...).
+			Returning("Id").MaxParams(2).Exec(context.Background())
+		affected, err := res.RowsAffected()
+		require.NoError(t, err)
+		assert.Equal(t, int64(5), affected)
+		for idx, v := range vals {
+			assert.Equal(t, int64(idx+1), v.Id)
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("rollback", func(t *testing.T) {
+		mockDB, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		db, err := OpenDB(mockDB, DBWithDialect(DialectMySQL))
+		require.NoError(t, err)
+
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO `test_model` .*").WillReturnResult(driver.RowsAffected(1))
+		mock.ExpectExec("INSERT INTO `test_model` .*").WillReturnError(errors.New("db err"))
+		mock.ExpectRollback()
+
+		res := NewInserter[TestModel](db).Values(&TestModel{Id: 1}, &TestModel{Id: 2}).
+			MaxParams(3).Exec(context.Background())
+		assert.Equal(t, errors.New("db err"), res.Err())
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("tx", func(t *testing.T) {
+		mockDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		require.NoError(t, err)
+		db, err := OpenDB(mockDB, DBWithDialect(DialectSQLite3))
+		require.NoError(t, err)
+
+		// 已经在事务中时不会再开启事务，更新的赋值占用一个参数
+		mock.ExpectBegin()
+		mock.ExpectExec("INSERT INTO `test_model` (`id`,`first_name`,`last_name`) VALUES (?,?,?) "+
+			"ON CONFLICT(`id`) DO UPDATE SET `first_name` = ?;").
+			WithArgs(int64(1), "a", "b", "new").
+			WillReturnResult(driver.RowsAffected(1))
+		mock.ExpectExec("INSERT INTO `test_model` (`id`,`first_name`,`last_name`) VALUES (?,?,?) "+
+			"ON CONFLICT(`id`) DO UPDATE SET `first_name` = ?;").
+			WithArgs(int64(2), "c", "d", "new").
+			WillReturnResult(driver.RowsAffected(1))
+		mock.ExpectCommit()
+
+		tx, err := db.BeginTx(context.Background(), nil)
+		require.NoError(t, err)
+		res := NewInserter[TestModel](tx).
+			Values(&TestModel{Id: 1, FirstName: "a", LastName: "b"}, &TestModel{Id: 2, FirstName: "c", LastName: "d"}).
+			MaxParams(6).OnDuplicateKey().ConflictColumns("Id").Update(Assign("FirstName", "new")).
+			Exec(context.Background())
+		affected, err := res.RowsAffected()
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), affected)
+		require.NoError(t, tx.Commit())
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("sqlite limit", func(t *testing.T) {
+		db := memoryWithDB("chunk", t, DBWithDialect(DialectSQLite3))
+		err := RawQuery[any](db, "CREATE TABLE `test_model` ("+
+			"`id` INTEGER PRIMARY KEY AUTOINCREMENT,"+
+			"`first_name` TEXT NOT NULL,"+
+			"`last_name` TEXT NOT NULL);").
+			Exec(context.Background()).Err()
+		require.NoError(t, err)
+
+		vals := make([]*TestModel, 0, 20000)
+		for idx := 0; idx < cap(vals); idx++ {
+			vals = append(vals, &TestModel{FirstName: "a", LastName: "b"})
+		}
+		res := NewInserter[TestModel](db).Values(vals...).Returning("Id").Exec(context.Background())
+		affected, err := res.RowsAffected()
+		require.NoError(t, err)
+		assert.Equal(t, int64(len(vals)), affected)
+		assert.Equal(t, int64(len(vals)), vals[len(vals)-1].Id)
+	})
+}
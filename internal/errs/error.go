@@ -10,12 +10,49 @@ var (
 	ErrNoRows        = errors.New("orm: no rows")
 	ErrInsertZeroRow = errors.New("orm: insert zero row")
 	ErrUpdateNoSet   = errors.New("orm: update no set")
+
+	ErrUpsertNoConflictColumns = errors.New("orm: upsert requires conflict columns")
+	ErrReturningMultiColumns   = errors.New("orm: dialect without RETURNING can only backfill one auto increment column")
+	ErrNoPrimaryKey            = errors.New("orm: model has no primary key")
+	ErrMigrationLocked         = errors.New("orm: failed to acquire migration lock")
+	ErrCompositePrimaryKey     = errors.New("orm: composite primary key is not supported")
+	ErrInvalidCursor           = errors.New("orm: invalid or tampered cursor")
+	ErrCaseNoWhen              = errors.New("orm: CASE requires at least one WHEN")
+	ErrUnsupportedWindow       = errors.New("orm: window functions are not supported by the dialect")
+	ErrUnsupportedCTE          = errors.New("orm: common table expressions are not supported by the dialect")
+	ErrJoinTarget              = errors.New("orm: the leftmost table of the join must be the table of the model")
+	ErrMultiTableJoin          = errors.New("orm: only INNER JOIN with ON is supported in multi-table UPDATE and DELETE by the dialect")
+	ErrMultiTableLimit         = errors.New("orm: ORDER BY and LIMIT are not supported in multi-table UPDATE and DELETE")
+	ErrUnscopedChunks          = errors.New("orm: DeleteInChunks with Unscoped requires HardDelete on a soft delete model")
+	ErrLockWithoutTx           = errors.New("orm: row locking clauses can only be used in a transaction")
+	ErrLockWaitWithoutLock     = errors.New("orm: NOWAIT and SKIP LOCKED require FOR UPDATE or FOR SHARE")
+	ErrReturningNotAutoInc     = errors.New("orm: dialect without RETURNING can only backfill an auto increment column")
+	ErrReturningUpsert         = errors.New("orm: dialect without RETURNING cannot backfill with ON DUPLICATE KEY UPDATE")
 )
 
 func NewErrUnsupportedExpression(expr any) error {
 	return fmt.Errorf("orm: unsupported expression type %v", expr)
 }
 
+func NewErrUnsupportedSetOp(op string, all bool) error {
+	if all {
+		op += " ALL"
+	}
+	return fmt.Errorf("orm: %s is not supported by the dialect", op)
+}
+
+func NewErrSetColumnCount(want, got int) error {
+	return fmt.Errorf("orm: each query in a set operation must have the same number of columns, want %d, got %d", want, got)
+}
+
+func NewErrSetOperandClause(clause string) error {
+	return fmt.Errorf("orm: a query in a set operation cannot contain %s", clause)
+}
+
+func NewErrUnsupportedLock(clause string) error {
+	return fmt.Errorf("orm: %s is not supported by the dialect", clause)
+}
+
 func NewErrUnsupportedTable(table any) error {
 	return fmt.Errorf("orm: unsupported TableReference type %v", table)
 }
@@ -32,6 +69,14 @@ func NewErrUnknownColumn(name string) error {
 	return fmt.Errorf("orm: unknown column %s", name)
 }
 
+func NewErrPrimaryKeyCount(want, got int) error {
+	return fmt.Errorf("orm: expect %d primary key values, got %d", want, got)
+}
+
+func NewErrInvalidFieldValue(name string, val any) error {
+	return fmt.Errorf("orm: invalid value %v for field %s", val, name)
+}
+
 func NewErrUnsupportedAssignable(assign any) error {
 	return fmt.Errorf("orm: unsupported assignable type %v", assign)
 }
@@ -51,3 +96,51 @@ func NewErrTableNotExist(tableName string) error {
 func NewErrTableExist(tableName string) error {
 	return fmt.Errorf("orm: table %s already exists", tableName)
 }
+
+func NewErrUnsupportedColumnType(field string, typ any) error {
+	return fmt.Errorf("orm: can not infer column type of field %s with type %v, use the type tag", field, typ)
+}
+
+func NewErrInvalidMigrationFile(name string) error {
+	return fmt.Errorf("orm: invalid migration file name %s, expect <version>_<name>.up.sql or <version>_<name>.down.sql", name)
+}
+
+func NewErrDuplicateMigration(version int64) error {
+	return fmt.Errorf("orm: duplicate migration version %d", version)
+}
+
+func NewErrMigrationNotFound(version int64) error {
+	return fmt.Errorf("orm: migration version %d not found", version)
+}
+
+func NewErrMissingMigrationFile(version int64, direction string) error {
+	return fmt.Errorf("orm: migration version %d has no %s file", version, direction)
+}
+
+func NewErrInvalidMigrationTime(src any) error {
+	return fmt.Errorf("orm: invalid migration applied_at %v", src)
+}
+
+func NewErrMigrationChecksum(version int64) error {
+	return fmt.Errorf("orm: applied migration version %d has been modified", version)
+}
+
+func NewErrInvalidRelation(field string) error {
+	return fmt.Errorf("orm: invalid relation field %s", field)
+}
+
+func NewErrInvalidBatchSize(size int) error {
+	return fmt.Errorf("orm: invalid batch size %d", size)
+}
+
+func NewErrUnsupportedOrderBy(orderBy any) error {
+	return fmt.Errorf("orm: unsupported order by type %v", orderBy)
+}
+
+func NewErrUpdatePrimaryKey(field string) error {
+	return fmt.Errorf("orm: can not update primary key %s", field)
+}
+
+func NewErrInvalidSoftDelete(field string) error {
+	return fmt.Errorf("orm: soft delete field %s must be time.Time, *time.Time, sql.NullTime or an integer", field)
+}
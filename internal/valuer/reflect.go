@@ -27,6 +27,18 @@ func (r *reflectValue) Field(name string) (any, error) {
 	return r.val.FieldByName(name).Interface(), nil
 }
 
+func (r *reflectValue) SetField(name string, val any) error {
+	_, ok := r.model.FieldMap[name]
+	if !ok {
+		// 关联字段
+		_, ok = r.model.Relations[name]
+	}
+	if !ok {
+		return errs.NewErrUnknownField(name)
+	}
+	return setValue(r.val.FieldByName(name), name, val)
+}
+
 func (r *reflectValue) SetColumns(rows *sql.Rows) error {
 	cs, err := rows.Columns()
 	if err != nil {
@@ -84,3 +96,33 @@ func (r *reflectValue) setRow(row []string, scanner *sql.Rows, entity any) error
 	}
 	return nil
 }
+
+// setValue 将 val 赋值给字段，只在数值类型之间转换
+// 避免 int64 被转换为只有一个字符的 string 这类不符合预期的转换
+func setValue(fd reflect.Value, name string, val any) error {
+	v := reflect.ValueOf(val)
+	if !v.IsValid() {
+		fd.SetZero()
+		return nil
+	}
+	switch {
+	case v.Type().AssignableTo(fd.Type()):
+		fd.Set(v)
+	case isNumber(v.Kind()) && isNumber(fd.Kind()):
+		fd.Set(v.Convert(fd.Type()))
+	default:
+		return errs.NewErrInvalidFieldValue(name, val)
+	}
+	return nil
+}
+
+func isNumber(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	default:
+		return false
+	}
+}
@@ -2,6 +2,7 @@ package valuer
 
 import (
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/KNICEX/go-orm/internal/errs"
 	"github.com/KNICEX/go-orm/model"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -88,3 +89,58 @@ func TestReflectValue_SetColumns(t *testing.T) {
 	}
 
 }
+
+func TestReflectValue_SetField(t *testing.T) {
+	testCases := []struct {
+		name       string
+		field      string
+		val        any
+		wantErr    error
+		wantEntity *TestModel
+	}{
+		{
+			name:       "same type",
+			field:      "FirstName",
+			val:        "John",
+			wantEntity: &TestModel{FirstName: "John"},
+		},
+		{
+			name:       "convertible type",
+			field:      "Id",
+			val:        int32(12),
+			wantEntity: &TestModel{Id: 12},
+		},
+		{
+			name:    "unknown field",
+			field:   "Age",
+			val:     12,
+			wantErr: errs.NewErrUnknownField("Age"),
+		},
+		{
+			name:    "invalid value",
+			field:   "Id",
+			val:     "12",
+			wantErr: errs.NewErrInvalidFieldValue("Id", "12"),
+		},
+		{
+			name:    "number to string",
+			field:   "FirstName",
+			val:     int64(65),
+			wantErr: errs.NewErrInvalidFieldValue("FirstName", int64(65)),
+		},
+	}
+
+	m, err := model.NewRegistry().Get(&TestModel{})
+	require.NoError(t, err)
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			entity := &TestModel{}
+			err := NewReflectValue(m, entity).SetField(tc.field, tc.val)
+			assert.Equal(t, tc.wantErr, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.wantEntity, entity)
+		})
+	}
+}
@@ -37,6 +37,19 @@ func (u *unsafeValue) Field(name string) (any, error) {
 	return reflect.NewAt(fd.Typ, fdPtr).Elem().Interface(), nil
 }
 
+func (u *unsafeValue) SetField(name string, val any) error {
+	if fd, ok := u.model.FieldMap[name]; ok {
+		fdPtr := unsafe.Pointer(uintptr(u.addr) + fd.Offset)
+		return setValue(reflect.NewAt(fd.Typ, fdPtr).Elem(), name, val)
+	}
+	// 关联字段
+	if rel, ok := u.model.Relations[name]; ok {
+		fdPtr := unsafe.Pointer(uintptr(u.addr) + rel.Offset)
+		return setValue(reflect.NewAt(rel.Typ, fdPtr).Elem(), name, val)
+	}
+	return errs.NewErrUnknownField(name)
+}
+
 func (u *unsafeValue) SetColumns(rows *sql.Rows) error {
 	cs, err := rows.Columns()
 	if err != nil {
@@ -8,6 +8,9 @@ import (
 type Value interface {
 	SetColumns(rows *sql.Rows) error
 	Field(name string) (any, error)
+	// SetField 设置字段或者关联字段的值
+	// val 需要能赋值给字段，只有数字类型之间会做类型转换
+	SetField(name string, val any) error
 }
 
 type Creator func(model *model.Model, entity any) Value
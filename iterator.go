@@ -0,0 +1,129 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Iterator 逐行扫描查询结果，不会一次性把所有行读入内存
+// 用法：
+//
+//	it := NewSelector[User](db).Iter(ctx)
+//	defer it.Close()
+//	for it.Next() {
+//		u, err := it.Scan()
+//	}
+//	err := it.Err()
+//
+// 迭代期间中间件链一直处于执行中，日志和慢查询统计覆盖整个迭代过程，直到 Close
+// Next 返回 false 时会自动 Close，提前结束迭代时必须调用 Close
+type Iterator[T any] struct {
+	ctx  context.Context
+	c    *core
+	rows *sql.Rows
+	err  error
+
+	// 迭代结束后关闭，通知中间件链返回
+	closing chan struct{}
+	// 中间件链的执行结果
+	result chan *Result
+	closed bool
+}
+
+// Iter 执行查询并返回迭代器，不支持 Preload
+func (s *Selector[T]) Iter(ctx context.Context) *Iterator[T] {
+	it := &Iterator[T]{
+		ctx:     ctx,
+		c:       s.core,
+		closing: make(chan struct{}),
+		result:  make(chan *Result, 1),
+	}
+	q, err := s.Build()
+	if err != nil {
+		it.err = err
+		it.closed = true
+		return it
+	}
+
+	rowsCh := make(chan *sql.Rows)
+	var root Handler = func(c *Context) *Result {
+		rows, err := s.sess.queryContext(c.Ctx, c.Query.SQL, c.Query.Args...)
+		if err != nil {
+			return &Result{Err: err}
+		}
+		rowsCh <- rows
+		<-it.closing
+		// it.err 在 closing 关闭前写入
+		return &Result{Err: it.err}
+	}
+	for i := len(s.middlewares) - 1; i >= 0; i-- {
+		root = s.middlewares[i](root)
+	}
+
+	go func() {
+		it.result <- root(&Context{
+			Type:  SELECT,
+			Query: q,
+			Model: s.model,
+			Ctx:   ctx,
+		})
+	}()
+
+	select {
+	case it.rows = <-rowsCh:
+	case res := <-it.result:
+		// 中间件没有执行查询就返回了
+		it.err = res.Err
+		it.closed = true
+	}
+	return it
+}
+
+// Next 移动到下一行，没有更多的行或者出错时返回 false
+func (it *Iterator[T]) Next() bool {
+	if it.closed || it.err != nil {
+		return false
+	}
+	if it.rows.Next() {
+		return true
+	}
+	it.err = it.rows.Err()
+	_ = it.Close()
+	return false
+}
+
+// Scan 扫描当前行
+func (it *Iterator[T]) Scan() (*T, error) {
+	if it.closed {
+		return nil, ErrNoRows
+	}
+	res := new(T)
+	if err := it.c.creator(it.c.model, res).SetColumns(it.rows); err != nil {
+		return nil, err
+	}
+	if err := afterFind(it.ctx, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// Err 迭代过程中的错误，包括中间件返回的错误
+func (it *Iterator[T]) Err() error {
+	return it.err
+}
+
+// Close 关闭结果集并等待中间件链返回，可以重复调用
+func (it *Iterator[T]) Close() error {
+	if it.closed {
+		return it.err
+	}
+	it.closed = true
+	if err := it.rows.Close(); err != nil && it.err == nil {
+		it.err = err
+	}
+	close(it.closing)
+	if res := <-it.result; res.Err != nil && it.err == nil {
+		it.err = res.Err
+	}
+	return it.err
+}
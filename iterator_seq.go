@@ -0,0 +1,31 @@
+//go:build go1.23
+
+package orm
+
+import (
+	"context"
+	"iter"
+)
+
+// All 以 iter.Seq2 的形式逐行返回查询结果，出错时 yield 错误后结束
+// 用法：
+//
+//	for u, err := range NewSelector[User](db).All(ctx) {
+//	}
+func (s *Selector[T]) All(ctx context.Context) iter.Seq2[*T, error] {
+	return func(yield func(*T, error) bool) {
+		it := s.Iter(ctx)
+		defer func() {
+			_ = it.Close()
+		}()
+		for it.Next() {
+			res, err := it.Scan()
+			if !yield(res, err) || err != nil {
+				return
+			}
+		}
+		if err := it.Close(); err != nil {
+			yield(nil, err)
+		}
+	}
+}
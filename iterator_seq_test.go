@@ -0,0 +1,37 @@
+//go:build go1.23
+
+package orm
+
+import (
+	"context"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestSelector_All(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	db, err := OpenDB(mockDB, DBWithDialect(DialectMySQL))
+	require.NoError(t, err)
+
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id", "first_name"}).
+		AddRow(1, "tom").AddRow(2, "jerry").AddRow(3, "spike"))
+	var names []string
+	for tm, err := range NewSelector[TestModel](db).All(context.Background()) {
+		require.NoError(t, err)
+		names = append(names, tm.FirstName)
+		if len(names) == 2 {
+			break
+		}
+	}
+	assert.Equal(t, []string{"tom", "jerry"}, names)
+
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id", "unknown"}).AddRow(1, "tom"))
+	for tm, err := range NewSelector[TestModel](db).All(context.Background()) {
+		assert.Nil(t, tm)
+		assert.Error(t, err)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
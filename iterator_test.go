@@ -0,0 +1,90 @@
+package orm
+
+import (
+	"context"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/KNICEX/go-orm/internal/errs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestSelector_Iter(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	var events []string
+	mdl := func(next Handler) Handler {
+		return func(c *Context) *Result {
+			events = append(events, "start")
+			res := next(c)
+			events = append(events, "end")
+			return res
+		}
+	}
+	db, err := OpenDB(mockDB, DBWithDialect(DialectMySQL), DBWithMiddlewares(mdl))
+	require.NoError(t, err)
+
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id", "first_name"}).
+		AddRow(1, "tom").AddRow(2, "jerry"))
+	it := NewSelector[TestModel](db).Iter(context.Background())
+	var names []string
+	for it.Next() {
+		tm, err := it.Scan()
+		require.NoError(t, err)
+		names = append(names, tm.FirstName)
+		events = append(events, "row")
+	}
+	require.NoError(t, it.Err())
+	require.NoError(t, it.Close())
+	assert.Equal(t, []string{"tom", "jerry"}, names)
+	// 中间件覆盖整个迭代过程
+	assert.Equal(t, []string{"start", "row", "row", "end"}, events)
+
+	// 提前结束迭代
+	events = nil
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2))
+	it = NewSelector[TestModel](db).Iter(context.Background())
+	require.True(t, it.Next())
+	require.NoError(t, it.Close())
+	assert.False(t, it.Next())
+	assert.Equal(t, []string{"start", "end"}, events)
+
+	// 迭代过程中出错
+	rowErr := errors.New("row error")
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).RowError(0, rowErr))
+	it = NewSelector[TestModel](db).Iter(context.Background())
+	assert.False(t, it.Next())
+	assert.Equal(t, rowErr, it.Err())
+	assert.Equal(t, rowErr, it.Close())
+
+	// 查询出错
+	mock.ExpectQuery("SELECT .*").WillReturnError(errs.ErrNoRows)
+	it = NewSelector[TestModel](db).Iter(context.Background())
+	assert.False(t, it.Next())
+	assert.Equal(t, errs.ErrNoRows, it.Err())
+
+	// 构造 SQL 出错
+	it = NewSelector[TestModel](db).Where(Col("Unknown").Eq(1)).Iter(context.Background())
+	assert.False(t, it.Next())
+	assert.Equal(t, errs.NewErrUnknownField("Unknown"), it.Err())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSelector_Iter_MiddlewareAbort(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	abortErr := errors.New("abort")
+	db, err := OpenDB(mockDB, DBWithDialect(DialectMySQL), DBWithMiddlewares(func(next Handler) Handler {
+		return func(c *Context) *Result {
+			return &Result{Err: abortErr}
+		}
+	}))
+	require.NoError(t, err)
+
+	it := NewSelector[TestModel](db).Iter(context.Background())
+	assert.False(t, it.Next())
+	assert.Equal(t, abortErr, it.Close())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,97 @@
+package orm
+
+import "github.com/KNICEX/go-orm/internal/errs"
+
+type lockStrength = string
+
+const (
+	lockUpdate lockStrength = "UPDATE"
+	lockShare  lockStrength = "SHARE"
+)
+
+type lockWait = string
+
+const (
+	lockNoWait     lockWait = "NOWAIT"
+	lockSkipLocked lockWait = "SKIP LOCKED"
+)
+
+// rowLock 行锁 FOR UPDATE [NOWAIT | SKIP LOCKED]
+type rowLock struct {
+	strength lockStrength
+	wait     lockWait
+}
+
+// ForUpdate 锁定查询到的行，只能在事务中使用
+// 用法： NewSelector[Job](tx).Where(Col("Status").Eq("pending")).Limit(10).ForUpdate().SkipLocked()
+func (s *Selector[T]) ForUpdate() *Selector[T] {
+	s.lock.strength = lockUpdate
+	return s
+}
+
+// ForShare 共享锁，其他事务可以读取但不能修改查询到的行，只能在事务中使用
+func (s *Selector[T]) ForShare() *Selector[T] {
+	s.lock.strength = lockShare
+	return s
+}
+
+// NoWait 行已经被锁定时立即返回错误，不等待
+func (s *Selector[T]) NoWait() *Selector[T] {
+	s.lock.wait = lockNoWait
+	return s
+}
+
+// SkipLocked 跳过已经被锁定的行，用于多个消费者从表中领取任务
+func (s *Selector[T]) SkipLocked() *Selector[T] {
+	s.lock.wait = lockSkipLocked
+	return s
+}
+
+// buildLock 校验行锁只在事务中使用，并由方言构造锁定子句
+func (b *builder) buildLock(sess Session, l rowLock) error {
+	if l.strength == "" {
+		if l.wait != "" {
+			return errs.ErrLockWaitWithoutLock
+		}
+		return nil
+	}
+	if _, ok := sess.(*Tx); !ok {
+		return errs.ErrLockWithoutTx
+	}
+	return b.dialect.buildLock(b, l)
+}
+
+func (s *standardSQL) buildLock(b *builder, l rowLock) error {
+	b.sb.WriteString(" FOR ")
+	b.sb.WriteString(l.strength)
+	if l.wait != "" {
+		b.sb.WriteByte(' ')
+		b.sb.WriteString(l.wait)
+	}
+	return nil
+}
+
+// buildLock MySQL 8.0 之前使用 LOCK IN SHARE MODE，不支持 NOWAIT 和 SKIP LOCKED
+func (s *mysqlDialect) buildLock(b *builder, l rowLock) error {
+	if !s.v57 {
+		return s.standardSQL.buildLock(b, l)
+	}
+	if l.wait != "" {
+		return errs.NewErrUnsupportedLock(l.wait)
+	}
+	if l.strength == lockShare {
+		b.sb.WriteString(" LOCK IN SHARE MODE")
+		return nil
+	}
+	b.sb.WriteString(" FOR UPDATE")
+	return nil
+}
+
+// buildLock SQLite 没有行锁，写事务之间本身是串行的，忽略 FOR UPDATE 和 FOR SHARE
+// NOWAIT 和 SKIP LOCKED 无法保证，返回错误
+func (s *sqlite3Dialect) buildLock(b *builder, l rowLock) error {
+	if l.wait != "" {
+		return errs.NewErrUnsupportedLock(l.wait)
+	}
+	return nil
+}
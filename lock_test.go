@@ -0,0 +1,149 @@
+package orm
+
+import (
+	"context"
+	"database/sql/driver"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/KNICEX/go-orm/internal/errs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestSelector_Lock(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	begin := func(dialect Dialect) *Tx {
+		db, err := OpenDB(mockDB, DBWithDialect(dialect))
+		require.NoError(t, err)
+		mock.ExpectBegin()
+		tx, err := db.BeginTx(context.Background(), nil)
+		require.NoError(t, err)
+		return tx
+	}
+	mysql := begin(DialectMySQL)
+	mysql57 := begin(DialectMySQL57)
+	pg := begin(DialectPostgres)
+	sqlite := begin(DialectSQLite3)
+	db, err := OpenDB(mockDB, DBWithDialect(DialectMySQL))
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name      string
+		builder   SqlBuilder
+		wantQuery *Query
+		wantErr   error
+	}{
+		{
+			name: "for update skip locked",
+			builder: NewSelector[PredicateOrder](mysql).Where(Col("UserId").Eq(1)).
+				OrderBy(Col("Id")).Limit(10).ForUpdate().SkipLocked(),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `predicate_order` WHERE `user_id` = ? ORDER BY `id` ASC LIMIT 10 FOR UPDATE SKIP LOCKED;",
+				Args: []any{1},
+			},
+		},
+		{
+			name:    "for share nowait",
+			builder: NewSelector[PredicateOrder](mysql).Where(Col("Id").Eq(1)).ForShare().NoWait(),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `predicate_order` WHERE `id` = ? FOR SHARE NOWAIT;",
+				Args: []any{1},
+			},
+		},
+		{
+			name:    "mysql 5.7 share mode",
+			builder: NewSelector[PredicateOrder](mysql57).Where(Col("Id").Eq(1)).ForShare(),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `predicate_order` WHERE `id` = ? LOCK IN SHARE MODE;",
+				Args: []any{1},
+			},
+		},
+		{
+			name:    "mysql 5.7 for update",
+			builder: NewSelector[PredicateOrder](mysql57).Where(Col("Id").Eq(1)).ForUpdate(),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `predicate_order` WHERE `id` = ? FOR UPDATE;",
+				Args: []any{1},
+			},
+		},
+		{
+			name:    "mysql 5.7 skip locked",
+			builder: NewSelector[PredicateOrder](mysql57).ForUpdate().SkipLocked(),
+			wantErr: errs.NewErrUnsupportedLock("SKIP LOCKED"),
+		},
+		{
+			name: "postgres",
+			builder: NewSelector[PredicateOrder](pg).Where(Col("UserId").Eq(1)).
+				Limit(1).ForUpdate().SkipLocked(),
+			wantQuery: &Query{
+				SQL:  `SELECT * FROM "predicate_order" WHERE "user_id" = $1 LIMIT 1 FOR UPDATE SKIP LOCKED;`,
+				Args: []any{1},
+			},
+		},
+		{
+			name:    "sqlite ignore",
+			builder: NewSelector[PredicateOrder](sqlite).Where(Col("Id").Eq(1)).ForUpdate(),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `predicate_order` WHERE `id` = ?;",
+				Args: []any{1},
+			},
+		},
+		{
+			name:    "sqlite nowait",
+			builder: NewSelector[PredicateOrder](sqlite).ForUpdate().NoWait(),
+			wantErr: errs.NewErrUnsupportedLock("NOWAIT"),
+		},
+		{
+			name:    "not in tx",
+			builder: NewSelector[PredicateOrder](db).ForUpdate(),
+			wantErr: errs.ErrLockWithoutTx,
+		},
+		{
+			name:    "wait without lock",
+			builder: NewSelector[PredicateOrder](mysql).SkipLocked(),
+			wantErr: errs.ErrLockWaitWithoutLock,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			q, err := tc.builder.Build()
+			assert.Equal(t, tc.wantErr, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.wantQuery, q)
+		})
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSelector_LockDoTx(t *testing.T) {
+	mockDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	db, err := OpenDB(mockDB, DBWithDialect(DialectMySQL))
+	require.NoError(t, err)
+
+	// 领取一个任务并标记
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT * FROM `predicate_order` WHERE `user_id` = ? ORDER BY `id` ASC LIMIT 1 FOR UPDATE SKIP LOCKED;").
+		WithArgs(0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "amount"}).AddRow(3, 0, 10))
+	mock.ExpectExec("UPDATE `predicate_order` SET `user_id` = ? WHERE `id` = ?;").
+		WithArgs(7, int64(3)).
+		WillReturnResult(driver.RowsAffected(1))
+	mock.ExpectCommit()
+
+	err = db.DoTx(context.Background(), func(ctx context.Context, tx *Tx) error {
+		job, err := NewSelector[PredicateOrder](tx).Where(Col("UserId").Eq(0)).
+			OrderBy(Col("Id")).ForUpdate().SkipLocked().Get(ctx)
+		if err != nil {
+			return err
+		}
+		return NewUpdater[PredicateOrder](tx).Set(Assign("UserId", 7)).
+			Where(Col("Id").Eq(job.Id)).Exec(ctx).Err()
+	}, nil)
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,413 @@
+package orm
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"github.com/KNICEX/go-orm/internal/errs"
+	"github.com/KNICEX/go-orm/model"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultMigrationTable = "schema_migrations"
+	defaultMigrationLock  = "go-orm-migration"
+)
+
+var migrationFileName = regexp.MustCompile(`^(\d+)(?:_(.*))?\.(up|down)\.sql$`)
+
+// MigrationStatus 一个版本的迁移状态
+type MigrationStatus struct {
+	Version int64
+	Name    string
+	Applied bool
+	// 执行时间，未执行时为零值
+	AppliedAt time.Time
+	// 已执行的迁移文件在执行后被修改过
+	Modified bool
+}
+
+type migration struct {
+	version  int64
+	name     string
+	up       string
+	down     string
+	hasUp    bool
+	hasDown  bool
+	checksum string
+}
+
+type appliedMigration struct {
+	version   int64
+	name      string
+	checksum  string
+	appliedAt time.Time
+}
+
+type MigrationRunnerOption func(r *MigrationRunner)
+
+// MigrationRunner 执行 fs.FS 中按版本号命名的 SQL 迁移文件
+// 文件名格式为 <version>_<name>.up.sql 和 <version>_<name>.down.sql，执行记录保存在迁移历史表中
+// 一个文件中包含多条语句时，MySQL 需要在 DSN 中开启 multiStatements
+type MigrationRunner struct {
+	db   *DB
+	fsys fs.FS
+
+	table    string
+	lockName string
+}
+
+func NewMigrationRunner(db *DB, fsys fs.FS, opts ...MigrationRunnerOption) *MigrationRunner {
+	res := &MigrationRunner{
+		db:       db,
+		fsys:     fsys,
+		table:    defaultMigrationTable,
+		lockName: defaultMigrationLock,
+	}
+	for _, opt := range opts {
+		opt(res)
+	}
+	return res
+}
+
+// MigrationRunnerWithTable 指定迁移历史表，默认为 schema_migrations
+func MigrationRunnerWithTable(table string) MigrationRunnerOption {
+	return func(r *MigrationRunner) {
+		r.table = table
+	}
+}
+
+// MigrationRunnerWithLockName 指定 advisory lock 的锁名，同一个数据库中的多个应用需要使用不同的锁名
+func MigrationRunnerWithLockName(name string) MigrationRunnerOption {
+	return func(r *MigrationRunner) {
+		r.lockName = name
+	}
+}
+
+// Up 按版本号顺序执行所有未执行的迁移
+func (r *MigrationRunner) Up(ctx context.Context) error {
+	return r.withLock(ctx, func(migrations []*migration, applied map[int64]appliedMigration) error {
+		for _, mg := range migrations {
+			if _, ok := applied[mg.version]; ok {
+				continue
+			}
+			if err := r.apply(ctx, mg); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Down 按版本号倒序回滚最近执行的 n 个迁移
+func (r *MigrationRunner) Down(ctx context.Context, n int) error {
+	return r.withLock(ctx, func(migrations []*migration, applied map[int64]appliedMigration) error {
+		versions := appliedVersions(applied)
+		for i := len(versions) - 1; i >= 0 && n > 0; i-- {
+			if err := r.rollback(ctx, migrations, versions[i]); err != nil {
+				return err
+			}
+			n--
+		}
+		return nil
+	})
+}
+
+// Goto 迁移到指定版本，高于该版本的已执行迁移会被回滚，不高于该版本的未执行迁移会被执行
+// version 为 0 时回滚所有迁移
+func (r *MigrationRunner) Goto(ctx context.Context, version int64) error {
+	return r.withLock(ctx, func(migrations []*migration, applied map[int64]appliedMigration) error {
+		if version != 0 && findMigration(migrations, version) == nil {
+			return errs.NewErrMigrationNotFound(version)
+		}
+		versions := appliedVersions(applied)
+		for i := len(versions) - 1; i >= 0 && versions[i] > version; i-- {
+			if err := r.rollback(ctx, migrations, versions[i]); err != nil {
+				return err
+			}
+		}
+		for _, mg := range migrations {
+			if mg.version > version {
+				break
+			}
+			if _, ok := applied[mg.version]; ok {
+				continue
+			}
+			if err := r.apply(ctx, mg); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Status 所有迁移文件和已执行迁移的状态，按版本号排序
+func (r *MigrationRunner) Status(ctx context.Context) ([]MigrationStatus, error) {
+	migrations, err := r.load()
+	if err != nil {
+		return nil, err
+	}
+	if err = r.ensureTable(ctx); err != nil {
+		return nil, err
+	}
+	applied, err := r.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]MigrationStatus, 0, len(migrations))
+	for _, mg := range migrations {
+		st := MigrationStatus{
+			Version: mg.version,
+			Name:    mg.name,
+		}
+		if a, ok := applied[mg.version]; ok {
+			st.Applied = true
+			st.AppliedAt = a.appliedAt
+			st.Modified = a.checksum != mg.checksum
+		}
+		res = append(res, st)
+	}
+	// 迁移文件已经被删除的版本
+	for _, a := range applied {
+		if findMigration(migrations, a.version) == nil {
+			res = append(res, MigrationStatus{
+				Version:   a.version,
+				Name:      a.name,
+				Applied:   true,
+				AppliedAt: a.appliedAt,
+			})
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Version < res[j].Version
+	})
+	return res, nil
+}
+
+// withLock 持有 advisory lock 执行 fn，执行前会校验已执行的迁移文件没有被修改
+func (r *MigrationRunner) withLock(ctx context.Context,
+	fn func(migrations []*migration, applied map[int64]appliedMigration) error) (err error) {
+	migrations, err := r.load()
+	if err != nil {
+		return err
+	}
+
+	lock, unlock := r.db.dialect.advisoryLock(r.lockName)
+	if lock != nil {
+		// 会话级别的锁需要在同一个连接上获取和释放
+		var conn *sql.Conn
+		conn, err = r.db.db.Conn(ctx)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = conn.Close()
+		}()
+		var locked bool
+		if err = conn.QueryRowContext(ctx, r.db.dialect.rebind(lock.SQL), lock.Args...).Scan(&locked); err != nil {
+			return err
+		}
+		if !locked {
+			return errs.ErrMigrationLocked
+		}
+		defer func() {
+			var res any
+			if e := conn.QueryRowContext(context.WithoutCancel(ctx), r.db.dialect.rebind(unlock.SQL), unlock.Args...).Scan(&res); e != nil && err == nil {
+				err = e
+			}
+		}()
+	}
+
+	if err = r.ensureTable(ctx); err != nil {
+		return err
+	}
+	applied, err := r.applied(ctx)
+	if err != nil {
+		return err
+	}
+	for _, mg := range migrations {
+		if a, ok := applied[mg.version]; ok && a.checksum != mg.checksum {
+			return errs.NewErrMigrationChecksum(mg.version)
+		}
+	}
+	return fn(migrations, applied)
+}
+
+// apply 执行迁移并写入历史表，支持事务 DDL 的方言在同一个事务中完成
+func (r *MigrationRunner) apply(ctx context.Context, mg *migration) error {
+	return r.run(ctx, mg.up, func(ctx context.Context, sess Session) error {
+		return r.execSQL(ctx, sess, "INSERT INTO "+r.quotedTable()+" (version, name, checksum, applied_at) VALUES (?,?,?,?);",
+			mg.version, mg.name, mg.checksum, r.db.now())
+	})
+}
+
+// rollback 回滚迁移并删除历史记录
+func (r *MigrationRunner) rollback(ctx context.Context, migrations []*migration, version int64) error {
+	mg := findMigration(migrations, version)
+	if mg == nil || !mg.hasDown {
+		return errs.NewErrMissingMigrationFile(version, "down")
+	}
+	return r.run(ctx, mg.down, func(ctx context.Context, sess Session) error {
+		return r.execSQL(ctx, sess, "DELETE FROM "+r.quotedTable()+" WHERE version = ?;", mg.version)
+	})
+}
+
+func (r *MigrationRunner) run(ctx context.Context, ddl string, record func(ctx context.Context, sess Session) error) error {
+	fn := func(ctx context.Context, sess Session) error {
+		if strings.TrimSpace(ddl) != "" {
+			if err := RawQuery[any](sess, ddl).Exec(ctx).Err(); err != nil {
+				return err
+			}
+		}
+		return record(ctx, sess)
+	}
+	if !r.db.dialect.transactionalDDL() {
+		return fn(ctx, r.db)
+	}
+	return r.db.DoTx(ctx, func(ctx context.Context, tx *Tx) error {
+		return fn(ctx, tx)
+	}, nil)
+}
+
+func (r *MigrationRunner) execSQL(ctx context.Context, sess Session, query string, args ...any) error {
+	return RawQuery[any](sess, r.db.dialect.rebind(query), args...).Exec(ctx).Err()
+}
+
+func (r *MigrationRunner) quotedTable() string {
+	q := string(r.db.dialect.quoter())
+	return q + r.table + q
+}
+
+func (r *MigrationRunner) ensureTable(ctx context.Context) error {
+	timeCol := r.db.dialect.DataTypeOf(&model.Field{Typ: timeType})
+	return r.execSQL(ctx, r.db, "CREATE TABLE IF NOT EXISTS "+r.quotedTable()+
+		" (version BIGINT NOT NULL PRIMARY KEY, name VARCHAR(255) NOT NULL, checksum VARCHAR(64) NOT NULL, applied_at "+timeCol+" NOT NULL);")
+}
+
+func (r *MigrationRunner) applied(ctx context.Context) (map[int64]appliedMigration, error) {
+	rows, err := r.db.queryContext(ctx, "SELECT version, name, checksum, applied_at FROM "+r.quotedTable()+";")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	res := make(map[int64]appliedMigration)
+	for rows.Next() {
+		var a appliedMigration
+		if err = rows.Scan(&a.version, &a.name, &a.checksum, migrationTime{t: &a.appliedAt}); err != nil {
+			return nil, err
+		}
+		res[a.version] = a
+	}
+	return res, rows.Err()
+}
+
+// migrationTime 读取 applied_at，MySQL 的 DSN 没有设置 parseTime=true 时，
+// DATETIME 返回的是 []byte，按 UTC 解析
+type migrationTime struct {
+	t *time.Time
+}
+
+func (m migrationTime) Scan(src any) error {
+	var str string
+	switch v := src.(type) {
+	case time.Time:
+		*m.t = v
+		return nil
+	case []byte:
+		str = string(v)
+	case string:
+		str = v
+	default:
+		return errs.NewErrInvalidMigrationTime(src)
+	}
+	for _, layout := range []string{"2006-01-02 15:04:05.999999999", time.RFC3339Nano} {
+		if t, err := time.ParseInLocation(layout, str, time.UTC); err == nil {
+			*m.t = t
+			return nil
+		}
+	}
+	return errs.NewErrInvalidMigrationTime(src)
+}
+
+// load 读取迁移文件，按版本号排序
+func (r *MigrationRunner) load() ([]*migration, error) {
+	entries, err := fs.ReadDir(r.fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+	versions := make(map[int64]*migration, len(entries))
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".sql") {
+			continue
+		}
+		matches := migrationFileName.FindStringSubmatch(e.Name())
+		if matches == nil {
+			return nil, errs.NewErrInvalidMigrationFile(e.Name())
+		}
+		version, err := strconv.ParseInt(matches[1], 10, 64)
+		if err != nil || version <= 0 {
+			return nil, errs.NewErrInvalidMigrationFile(e.Name())
+		}
+		content, err := fs.ReadFile(r.fsys, e.Name())
+		if err != nil {
+			return nil, err
+		}
+		mg, ok := versions[version]
+		if !ok {
+			mg = &migration{version: version, name: matches[2]}
+			versions[version] = mg
+		} else if mg.name != matches[2] {
+			return nil, errs.NewErrDuplicateMigration(version)
+		}
+		up := matches[3] == "up"
+		if up && mg.hasUp || !up && mg.hasDown {
+			return nil, errs.NewErrDuplicateMigration(version)
+		}
+		if up {
+			mg.up, mg.hasUp = string(content), true
+			sum := sha256.Sum256(content)
+			mg.checksum = hex.EncodeToString(sum[:])
+		} else {
+			mg.down, mg.hasDown = string(content), true
+		}
+	}
+
+	res := make([]*migration, 0, len(versions))
+	for _, mg := range versions {
+		if !mg.hasUp {
+			return nil, errs.NewErrMissingMigrationFile(mg.version, "up")
+		}
+		res = append(res, mg)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].version < res[j].version
+	})
+	return res, nil
+}
+
+func findMigration(migrations []*migration, version int64) *migration {
+	i := sort.Search(len(migrations), func(i int) bool {
+		return migrations[i].version >= version
+	})
+	if i < len(migrations) && migrations[i].version == version {
+		return migrations[i]
+	}
+	return nil
+}
+
+func appliedVersions(applied map[int64]appliedMigration) []int64 {
+	res := make([]int64, 0, len(applied))
+	for v := range applied {
+		res = append(res, v)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i] < res[j]
+	})
+	return res
+}
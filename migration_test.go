@@ -0,0 +1,206 @@
+package orm
+
+import (
+	"context"
+	"database/sql/driver"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/KNICEX/go-orm/internal/errs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestMigrationRunner_SQLite(t *testing.T) {
+	db, err := Open("sqlite3", "file:migration_runner.db?cache=shared&mode=memory",
+		DBWithDialect(DialectSQLite3), DBWithClock(func() time.Time {
+			return time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+		}))
+	require.NoError(t, err)
+	ctx := context.Background()
+	fsys := fstest.MapFS{
+		"0001_create_user.up.sql":   {Data: []byte("CREATE TABLE mg_user (id INTEGER PRIMARY KEY, name TEXT);")},
+		"0001_create_user.down.sql": {Data: []byte("DROP TABLE mg_user;")},
+		"0002_add_age.up.sql":       {Data: []byte("ALTER TABLE mg_user ADD COLUMN age INTEGER;")},
+		"0002_add_age.down.sql":     {Data: []byte("ALTER TABLE mg_user DROP COLUMN age;")},
+		"0003_seed.up.sql":          {Data: []byte("INSERT INTO mg_user (id, name, age) VALUES (1, 'tom', 18);\nINSERT INTO mg_user (id, name, age) VALUES (2, 'jerry', 20);")},
+		"0003_seed.down.sql":        {Data: []byte("DELETE FROM mg_user;")},
+		"README.md":                 {Data: []byte("ignored")},
+	}
+	r := NewMigrationRunner(db, fsys)
+
+	status, err := r.Status(ctx)
+	require.NoError(t, err)
+	require.Len(t, status, 3)
+	assert.False(t, status[0].Applied)
+
+	require.NoError(t, r.Up(ctx))
+	status, err = r.Status(ctx)
+	require.NoError(t, err)
+	for _, st := range status {
+		assert.True(t, st.Applied)
+		assert.False(t, st.Modified)
+		assert.True(t, st.AppliedAt.Equal(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)))
+	}
+	assert.Equal(t, "add_age", status[1].Name)
+	assert.Equal(t, 2, countRows(t, db, "SELECT COUNT(*) FROM mg_user;"))
+
+	// 重复执行没有效果
+	require.NoError(t, r.Up(ctx))
+
+	require.NoError(t, r.Down(ctx, 1))
+	assert.Equal(t, 0, countRows(t, db, "SELECT COUNT(*) FROM mg_user;"))
+	status, err = r.Status(ctx)
+	require.NoError(t, err)
+	assert.False(t, status[2].Applied)
+
+	require.NoError(t, r.Goto(ctx, 1))
+	status, err = r.Status(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []bool{true, false, false}, []bool{status[0].Applied, status[1].Applied, status[2].Applied})
+
+	assert.Equal(t, errs.NewErrMigrationNotFound(5), r.Goto(ctx, 5))
+	require.NoError(t, r.Goto(ctx, 3))
+	assert.Equal(t, 2, countRows(t, db, "SELECT COUNT(*) FROM mg_user;"))
+
+	// 已执行的迁移文件被修改
+	fsys["0002_add_age.up.sql"] = &fstest.MapFile{Data: []byte("ALTER TABLE mg_user ADD COLUMN age BIGINT;")}
+	assert.Equal(t, errs.NewErrMigrationChecksum(2), r.Up(ctx))
+	status, err = r.Status(ctx)
+	require.NoError(t, err)
+	assert.True(t, status[1].Modified)
+
+	// 迁移失败时事务回滚，历史表不会记录
+	fsys["0002_add_age.up.sql"] = &fstest.MapFile{Data: []byte("ALTER TABLE mg_user ADD COLUMN age INTEGER;")}
+	fsys["0004_broken.up.sql"] = &fstest.MapFile{Data: []byte("CREATE TABLE mg_broken (id INTEGER);\nINSERT INTO unknown_table VALUES (1);")}
+	assert.Error(t, r.Up(ctx))
+	status, err = r.Status(ctx)
+	require.NoError(t, err)
+	assert.False(t, status[3].Applied)
+	assert.Equal(t, 0, countRows(t, db, "SELECT COUNT(*) FROM sqlite_master WHERE name = 'mg_broken';"))
+
+	require.NoError(t, r.Goto(ctx, 0))
+	status, err = r.Status(ctx)
+	require.NoError(t, err)
+	for _, st := range status {
+		assert.False(t, st.Applied)
+	}
+}
+
+func countRows(t *testing.T, db *DB, query string) int {
+	var cnt int
+	require.NoError(t, db.queryRowContext(context.Background(), query).Scan(&cnt))
+	return cnt
+}
+
+func TestMigrationRunner_load(t *testing.T) {
+	testCases := []struct {
+		name    string
+		fsys    fstest.MapFS
+		wantErr error
+	}{
+		{
+			name: "invalid name",
+			fsys: fstest.MapFS{
+				"create_user.up.sql": {},
+			},
+			wantErr: errs.NewErrInvalidMigrationFile("create_user.up.sql"),
+		},
+		{
+			name: "duplicate version",
+			fsys: fstest.MapFS{
+				"1_create_user.up.sql":  {},
+				"01_create_user.up.sql": {},
+			},
+			wantErr: errs.NewErrDuplicateMigration(1),
+		},
+		{
+			name: "duplicate version with different name",
+			fsys: fstest.MapFS{
+				"1_create_user.up.sql":  {},
+				"1_create_order.up.sql": {},
+			},
+			wantErr: errs.NewErrDuplicateMigration(1),
+		},
+		{
+			name: "missing up",
+			fsys: fstest.MapFS{
+				"1_create_user.down.sql": {},
+			},
+			wantErr: errs.NewErrMissingMigrationFile(1, "up"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewMigrationRunner(nil, tc.fsys).load()
+			assert.Equal(t, tc.wantErr, err)
+		})
+	}
+}
+
+func TestMigrationRunner_MySQL(t *testing.T) {
+	mockDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	db, err := OpenDB(mockDB, DBWithDialect(DialectMySQL))
+	require.NoError(t, err)
+	fsys := fstest.MapFS{
+		"1_create_user.up.sql": {Data: []byte("CREATE TABLE user (id BIGINT);")},
+	}
+
+	// MySQL 的 DDL 不在事务中执行
+	mock.ExpectQuery("SELECT GET_LOCK(?, -1);").WithArgs("deploy").
+		WillReturnRows(sqlmock.NewRows([]string{"lock"}).AddRow(1))
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS `migrations` (version BIGINT NOT NULL PRIMARY KEY, name VARCHAR(255) NOT NULL, checksum VARCHAR(64) NOT NULL, applied_at DATETIME NOT NULL);").
+		WillReturnResult(driver.ResultNoRows)
+	mock.ExpectQuery("SELECT version, name, checksum, applied_at FROM `migrations`;").
+		WillReturnRows(sqlmock.NewRows([]string{"version", "name", "checksum", "applied_at"}))
+	mock.ExpectExec("CREATE TABLE user (id BIGINT);").WillReturnResult(driver.ResultNoRows)
+	mock.ExpectExec("INSERT INTO `migrations` (version, name, checksum, applied_at) VALUES (?,?,?,?);").
+		WithArgs(int64(1), "create_user", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(driver.RowsAffected(1))
+	mock.ExpectQuery("SELECT RELEASE_LOCK(?);").WithArgs("deploy").
+		WillReturnRows(sqlmock.NewRows([]string{"unlock"}).AddRow(1))
+
+	r := NewMigrationRunner(db, fsys, MigrationRunnerWithTable("migrations"), MigrationRunnerWithLockName("deploy"))
+	require.NoError(t, r.Up(context.Background()))
+
+	// 获取锁失败
+	mock.ExpectQuery("SELECT GET_LOCK(?, -1);").WithArgs("deploy").
+		WillReturnRows(sqlmock.NewRows([]string{"lock"}).AddRow(0))
+	assert.Equal(t, errs.ErrMigrationLocked, r.Up(context.Background()))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMigrationRunner_StatusWithoutParseTime(t *testing.T) {
+	mockDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	db, err := OpenDB(mockDB, DBWithDialect(DialectMySQL))
+	require.NoError(t, err)
+	fsys := fstest.MapFS{
+		"1_create_user.up.sql": {Data: []byte("CREATE TABLE user (id BIGINT);")},
+	}
+
+	// 没有设置 parseTime=true 时，驱动返回 []byte
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS `schema_migrations` (version BIGINT NOT NULL PRIMARY KEY, name VARCHAR(255) NOT NULL, checksum VARCHAR(64) NOT NULL, applied_at DATETIME NOT NULL);").
+		WillReturnResult(driver.ResultNoRows)
+	mock.ExpectQuery("SELECT version, name, checksum, applied_at FROM `schema_migrations`;").
+		WillReturnRows(sqlmock.NewRows([]string{"version", "name", "checksum", "applied_at"}).
+			AddRow(1, "create_user", "x", []byte("2024-01-02 03:04:05.123")))
+
+	r := NewMigrationRunner(db, fsys)
+	status, err := r.Status(context.Background())
+	require.NoError(t, err)
+	require.Len(t, status, 1)
+	assert.True(t, status[0].Applied)
+	assert.Equal(t, time.Date(2024, 1, 2, 3, 4, 5, 123000000, time.UTC), status[0].AppliedAt)
+
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS `schema_migrations` (version BIGINT NOT NULL PRIMARY KEY, name VARCHAR(255) NOT NULL, checksum VARCHAR(64) NOT NULL, applied_at DATETIME NOT NULL);").
+		WillReturnResult(driver.ResultNoRows)
+	mock.ExpectQuery("SELECT version, name, checksum, applied_at FROM `schema_migrations`;").
+		WillReturnRows(sqlmock.NewRows([]string{"version", "name", "checksum", "applied_at"}).
+			AddRow(1, "create_user", "x", []byte("yesterday")))
+	_, err = r.Status(context.Background())
+	assert.ErrorContains(t, err, errs.NewErrInvalidMigrationTime([]byte("yesterday")).Error())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,322 @@
+package orm
+
+import (
+	"context"
+	"github.com/KNICEX/go-orm/internal/errs"
+	"github.com/KNICEX/go-orm/model"
+	"reflect"
+)
+
+// Migrator 根据注册的模型维护表结构
+type Migrator struct {
+	sess Session
+	*core
+
+	// dryRun 为 true 时只生成 DDL 不执行
+	dryRun bool
+}
+
+func NewMigrator(sess Session) *Migrator {
+	return &Migrator{
+		sess: sess,
+		core: sess.getCore(),
+	}
+}
+
+// DryRun 只生成 DDL 不执行，仍然会查询当前的表结构
+func (m *Migrator) DryRun() *Migrator {
+	m.dryRun = true
+	return m
+}
+
+// AutoMigrate 创建缺失的表，添加缺失的列和索引，不会删除或者修改已有的列
+// 返回按执行顺序排列的 DDL
+func (m *Migrator) AutoMigrate(ctx context.Context, entities ...any) ([]string, error) {
+	var res []string
+	for _, entity := range entities {
+		md, err := m.r.Get(entity)
+		if err != nil {
+			return res, err
+		}
+		exist, err := m.hasTable(ctx, md)
+		if err != nil {
+			return res, err
+		}
+		var ddls []string
+		if exist {
+			ddls, err = m.alterTableSQL(ctx, md)
+		} else {
+			ddls, err = m.createTableSQL(md)
+		}
+		if err != nil {
+			return res, err
+		}
+		for _, ddl := range ddls {
+			if err = m.exec(ctx, ddl); err != nil {
+				return res, err
+			}
+			res = append(res, ddl)
+		}
+	}
+	return res, nil
+}
+
+// HasTable 表是否存在
+func (m *Migrator) HasTable(ctx context.Context, entity any) (bool, error) {
+	md, err := m.r.Get(entity)
+	if err != nil {
+		return false, err
+	}
+	return m.hasTable(ctx, md)
+}
+
+// CreateTable 创建表和索引，表已经存在时返回错误
+func (m *Migrator) CreateTable(ctx context.Context, entity any) error {
+	md, err := m.r.Get(entity)
+	if err != nil {
+		return err
+	}
+	exist, err := m.hasTable(ctx, md)
+	if err != nil {
+		return err
+	}
+	if exist {
+		return errs.NewErrTableExist(md.TableName)
+	}
+	ddls, err := m.createTableSQL(md)
+	if err != nil {
+		return err
+	}
+	for _, ddl := range ddls {
+		if err = m.exec(ctx, ddl); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DropTable 删除表，表不存在时返回错误
+func (m *Migrator) DropTable(ctx context.Context, entity any) error {
+	md, err := m.r.Get(entity)
+	if err != nil {
+		return err
+	}
+	exist, err := m.hasTable(ctx, md)
+	if err != nil {
+		return err
+	}
+	if !exist {
+		return errs.NewErrTableNotExist(md.TableName)
+	}
+	b := m.newBuilder()
+	b.sb.WriteString("DROP TABLE ")
+	b.quote(md.TableName)
+	b.sb.WriteByte(';')
+	return m.exec(ctx, b.sb.String())
+}
+
+func (m *Migrator) newBuilder() *builder {
+	return &builder{
+		core:   m.core,
+		quoter: m.dialect.quoter(),
+	}
+}
+
+// exec 通过中间件执行 DDL
+func (m *Migrator) exec(ctx context.Context, ddl string) error {
+	if m.dryRun {
+		return nil
+	}
+	return RawQuery[any](m.sess, ddl).Exec(ctx).Err()
+}
+
+func (m *Migrator) hasTable(ctx context.Context, md *model.Model) (bool, error) {
+	names, err := m.queryNames(ctx, m.dialect.tableExistSQL(md.TableName))
+	if err != nil {
+		return false, err
+	}
+	return len(names) > 0, nil
+}
+
+// queryNames 执行返回单列字符串的查询
+func (m *Migrator) queryNames(ctx context.Context, q *Query) (map[string]struct{}, error) {
+	rows, err := m.sess.queryContext(ctx, m.dialect.rebind(q.SQL), q.Args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	res := make(map[string]struct{})
+	for rows.Next() {
+		var name string
+		if err = rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		res[name] = struct{}{}
+	}
+	return res, rows.Err()
+}
+
+// createTableSQL 建表语句和建索引语句
+func (m *Migrator) createTableSQL(md *model.Model) ([]string, error) {
+	b := m.newBuilder()
+	b.sb.WriteString("CREATE TABLE ")
+	b.quote(md.TableName)
+	b.sb.WriteString(" (")
+	inlinePK := false
+	for i, fd := range md.Fields {
+		if i > 0 {
+			b.sb.WriteString(", ")
+		}
+		inline, err := m.buildColumnDef(b, md, fd, false)
+		if err != nil {
+			return nil, err
+		}
+		inlinePK = inlinePK || inline
+	}
+	if len(md.PrimaryKeys) > 0 && !inlinePK {
+		b.sb.WriteString(", PRIMARY KEY (")
+		for i, fd := range md.PrimaryKeys {
+			if i > 0 {
+				b.sb.WriteByte(',')
+			}
+			b.quote(fd.ColName)
+		}
+		b.sb.WriteByte(')')
+	}
+	b.sb.WriteString(");")
+
+	res := []string{b.sb.String()}
+	for _, idx := range md.Indexes {
+		res = append(res, m.createIndexSQL(md, idx))
+	}
+	return res, nil
+}
+
+// alterTableSQL 添加缺失的列和索引
+func (m *Migrator) alterTableSQL(ctx context.Context, md *model.Model) ([]string, error) {
+	info, err := m.inspect(ctx, md.TableName)
+	if err != nil {
+		return nil, err
+	}
+	var res []string
+	for _, fd := range md.Fields {
+		if _, ok := info.column(fd.ColName); ok {
+			continue
+		}
+		b := m.newBuilder()
+		b.sb.WriteString("ALTER TABLE ")
+		b.quote(md.TableName)
+		b.sb.WriteString(" ADD COLUMN ")
+		if _, err = m.buildColumnDef(b, md, fd, true); err != nil {
+			return nil, err
+		}
+		b.sb.WriteByte(';')
+		res = append(res, b.sb.String())
+	}
+	for _, idx := range md.Indexes {
+		if _, ok := info.index(idx.Name); ok {
+			continue
+		}
+		res = append(res, m.createIndexSQL(md, idx))
+	}
+	return res, nil
+}
+
+// buildColumnDef 构造列定义，返回主键是否已经在列定义中声明
+// 给已有的表添加 NOT NULL 列时，没有指定默认值则使用零值作为默认值
+func (m *Migrator) buildColumnDef(b *builder, md *model.Model, fd *model.Field, alter bool) (bool, error) {
+	b.quote(fd.ColName)
+	b.sb.WriteByte(' ')
+	var extra string
+	var inlinePK bool
+	if isAutoIncrementPK(md, fd) {
+		var typ string
+		typ, extra, inlinePK = m.dialect.autoIncrement(fd)
+		b.sb.WriteString(typ)
+	} else {
+		typ := m.dialect.DataTypeOf(fd)
+		if typ == "" {
+			return false, errs.NewErrUnsupportedColumnType(fd.GoName, fd.Typ)
+		}
+		b.sb.WriteString(typ)
+	}
+	nullable := columnNullable(fd)
+	def := fd.Default
+	if def == "" && alter && !nullable {
+		def = zeroDefault(fd)
+	}
+	if !nullable {
+		b.sb.WriteString(" NOT NULL")
+	}
+	if def != "" {
+		b.sb.WriteString(" DEFAULT ")
+		b.sb.WriteString(def)
+	}
+	if extra != "" {
+		b.sb.WriteByte(' ')
+		b.sb.WriteString(extra)
+	}
+	return inlinePK, nil
+}
+
+func (m *Migrator) createIndexSQL(md *model.Model, idx *model.Index) string {
+	b := m.newBuilder()
+	b.sb.WriteString("CREATE ")
+	if idx.Unique {
+		b.sb.WriteString("UNIQUE ")
+	}
+	b.sb.WriteString("INDEX ")
+	b.quote(idx.Name)
+	b.sb.WriteString(" ON ")
+	b.quote(md.TableName)
+	b.sb.WriteString(" (")
+	for i, fd := range idx.Fields {
+		if i > 0 {
+			b.sb.WriteByte(',')
+		}
+		b.quote(fd.ColName)
+	}
+	b.sb.WriteString(");")
+	return b.sb.String()
+}
+
+// isAutoIncrementPK 只有单一自增主键使用方言的自增定义
+func isAutoIncrementPK(md *model.Model, fd *model.Field) bool {
+	return fd.AutoIncrement && len(md.PrimaryKeys) == 1 && md.PrimaryKeys[0] == fd
+}
+
+// zeroDefault 零值对应的默认值，时间等没有通用零值的类型返回空字符串
+// columnNullable 字段对应的列是否可以为 NULL
+// 时间等没有通用零值的类型在没有指定默认值时作为可以为 NULL 的列，
+// 否则添加到已有数据的表时已有的行无法满足 NOT NULL，建表、添加列和 Diff 都使用这个定义
+func columnNullable(fd *model.Field) bool {
+	if fd.Nullable {
+		return true
+	}
+	if fd.PrimaryKey {
+		return false
+	}
+	return fd.Default == "" && zeroDefault(fd) == ""
+}
+
+func zeroDefault(fd *model.Field) string {
+	switch columnType(fd.Typ).Kind() {
+	case reflect.Bool:
+		return "FALSE"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "0"
+	case reflect.String:
+		return "''"
+	default:
+		return ""
+	}
+}
+
+// AutoMigrate 创建缺失的表，添加缺失的列和索引
+// 需要查看将要执行的 DDL 时使用 NewMigrator(db).DryRun().AutoMigrate
+func (db *DB) AutoMigrate(ctx context.Context, entities ...any) error {
+	_, err := NewMigrator(db).AutoMigrate(ctx, entities...)
+	return err
+}
@@ -0,0 +1,161 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/KNICEX/go-orm/internal/errs"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+type MigrateUserV1 struct {
+	Id   int64
+	Name string `orm:"size=32,index"`
+}
+
+func (m MigrateUserV1) TableName() string {
+	return "migrate_user"
+}
+
+type MigrateUserV2 struct {
+	Id        int64
+	Name      string `orm:"size=32,index"`
+	Email     string `orm:"unique_index"`
+	Age       *int32
+	Score     sql.NullFloat64
+	Avatar    []byte
+	Active    bool `orm:"default=TRUE"`
+	CreatedAt time.Time
+}
+
+func (m MigrateUserV2) TableName() string {
+	return "migrate_user"
+}
+
+type MigrateOrder struct {
+	UserId int64  `orm:"pk,index=idx_order_user_item"`
+	ItemId int32  `orm:"pk,index=idx_order_user_item"`
+	Remark string `orm:"type=TEXT"`
+}
+
+func TestMigrator_SQLite(t *testing.T) {
+	db, err := Open("sqlite3", "file:migrator.db?cache=shared&mode=memory", DBWithDialect(DialectSQLite3))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	ddls, err := NewMigrator(db).AutoMigrate(ctx, &MigrateUserV1{}, &MigrateOrder{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"CREATE TABLE `migrate_user` (`id` INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT, `name` TEXT NOT NULL);",
+		"CREATE INDEX `idx_migrate_user_name` ON `migrate_user` (`name`);",
+		"CREATE TABLE `migrate_order` (`user_id` INTEGER NOT NULL, `item_id` INTEGER NOT NULL, `remark` TEXT NOT NULL, PRIMARY KEY (`user_id`,`item_id`));",
+		"CREATE INDEX `idx_order_user_item` ON `migrate_order` (`user_id`,`item_id`);",
+	}, ddls)
+	require.NoError(t, NewInserter[MigrateUserV1](db).Values(&MigrateUserV1{Name: "tom"}).Exec(ctx).Err())
+
+	// dry run 不会执行 DDL
+	want := []string{
+		"ALTER TABLE `migrate_user` ADD COLUMN `email` TEXT NOT NULL DEFAULT '';",
+		"ALTER TABLE `migrate_user` ADD COLUMN `age` INTEGER;",
+		"ALTER TABLE `migrate_user` ADD COLUMN `score` REAL;",
+		"ALTER TABLE `migrate_user` ADD COLUMN `avatar` BLOB;",
+		"ALTER TABLE `migrate_user` ADD COLUMN `active` INTEGER NOT NULL DEFAULT TRUE;",
+		"ALTER TABLE `migrate_user` ADD COLUMN `created_at` DATETIME;",
+		"CREATE UNIQUE INDEX `uk_migrate_user_email` ON `migrate_user` (`email`);",
+	}
+	ddls, err = NewMigrator(db).DryRun().AutoMigrate(ctx, &MigrateUserV2{})
+	require.NoError(t, err)
+	assert.Equal(t, want, ddls)
+
+	// 表中已有数据，没有零值默认值的列作为可以为 NULL 的列添加
+	ddls, err = NewMigrator(db).AutoMigrate(ctx, &MigrateUserV2{})
+	require.NoError(t, err)
+	assert.Equal(t, want, ddls)
+	ddls, err = NewMigrator(db).DryRun().AutoMigrate(ctx, &MigrateUserV2{})
+	require.NoError(t, err)
+	assert.Empty(t, ddls)
+	// 添加的列和模型的定义一致
+	diffs, err := NewMigrator(db).Diff(ctx, &MigrateUserV2{})
+	require.NoError(t, err)
+	assert.Empty(t, diffs)
+	user, err := NewSelector[MigrateUserV1](db).Select(Col("Name")).Get(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "tom", user.Name)
+
+	err = NewMigrator(db).CreateTable(ctx, &MigrateUserV2{})
+	assert.Equal(t, errs.NewErrTableExist("migrate_user"), err)
+	require.NoError(t, NewMigrator(db).DropTable(ctx, &MigrateOrder{}))
+	exist, err := NewMigrator(db).HasTable(ctx, &MigrateOrder{})
+	require.NoError(t, err)
+	assert.False(t, exist)
+	err = NewMigrator(db).DropTable(ctx, &MigrateOrder{})
+	assert.Equal(t, errs.NewErrTableNotExist("migrate_order"), err)
+	require.NoError(t, db.AutoMigrate(ctx, &MigrateOrder{}))
+	ddls, err = NewMigrator(db).DryRun().AutoMigrate(ctx, &MigrateOrder{})
+	require.NoError(t, err)
+	assert.Empty(t, ddls)
+}
+
+func TestMigrator_DryRun(t *testing.T) {
+	testCases := []struct {
+		name     string
+		dialect  Dialect
+		existSQL string
+		entity   any
+		wantDDLs []string
+		wantErr  error
+	}{
+		{
+			name:     "mysql",
+			dialect:  DialectMySQL,
+			existSQL: "SELECT TABLE_NAME FROM information_schema.TABLES WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ?;",
+			entity:   &MigrateUserV2{},
+			wantDDLs: []string{
+				"CREATE TABLE `migrate_user` (`id` BIGINT NOT NULL AUTO_INCREMENT, `name` VARCHAR(32) NOT NULL, `email` VARCHAR(255) NOT NULL, `age` INT, `score` DOUBLE, `avatar` BLOB, `active` BOOLEAN NOT NULL DEFAULT TRUE, `created_at` DATETIME, PRIMARY KEY (`id`));",
+				"CREATE INDEX `idx_migrate_user_name` ON `migrate_user` (`name`);",
+				"CREATE UNIQUE INDEX `uk_migrate_user_email` ON `migrate_user` (`email`);",
+			},
+		},
+		{
+			name:     "postgres",
+			dialect:  DialectPostgres,
+			existSQL: "SELECT table_name FROM information_schema.tables WHERE table_schema = current_schema() AND table_name = $1;",
+			entity:   &MigrateUserV2{},
+			wantDDLs: []string{
+				`CREATE TABLE "migrate_user" ("id" BIGSERIAL NOT NULL, "name" VARCHAR(32) NOT NULL, "email" VARCHAR(255) NOT NULL, "age" INTEGER, "score" DOUBLE PRECISION, "avatar" BYTEA, "active" BOOLEAN NOT NULL DEFAULT TRUE, "created_at" TIMESTAMPTZ, PRIMARY KEY ("id"));`,
+				`CREATE INDEX "idx_migrate_user_name" ON "migrate_user" ("name");`,
+				`CREATE UNIQUE INDEX "uk_migrate_user_email" ON "migrate_user" ("email");`,
+			},
+		},
+		{
+			name:     "unsupported type",
+			dialect:  DialectMySQL,
+			existSQL: "SELECT TABLE_NAME FROM information_schema.TABLES WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ?;",
+			entity:   &struct{ Tags []string }{},
+			wantErr:  errs.NewErrUnsupportedColumnType("Tags", "[]string"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			require.NoError(t, err)
+			db, err := OpenDB(mockDB, DBWithDialect(tc.dialect))
+			require.NoError(t, err)
+			mock.ExpectQuery(tc.existSQL).WillReturnRows(sqlmock.NewRows([]string{"name"}))
+
+			ddls, err := NewMigrator(db).DryRun().AutoMigrate(context.Background(), tc.entity)
+			if tc.wantErr != nil {
+				assert.EqualError(t, err, tc.wantErr.Error())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantDDLs, ddls)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
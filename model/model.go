@@ -6,7 +6,22 @@ import (
 )
 
 const (
-	tagColumn = "column"
+	tagColumn        = "column"
+	tagPrimaryKey    = "pk"
+	tagAutoIncrement = "auto_increment"
+	tagSoftDelete    = "soft_delete"
+	tagCreateTime    = "autoCreateTime"
+	tagUpdateTime    = "autoUpdateTime"
+	tagType          = "type"
+	tagSize          = "size"
+	tagDefault       = "default"
+	tagIndex         = "index"
+	tagUniqueIndex   = "unique_index"
+
+	tagForeignKey     = "foreign_key"
+	tagReferences     = "references"
+	tagJoinForeignKey = "join_foreign_key"
+	tagJoinReferences = "join_references"
 )
 
 type Model struct {
@@ -17,6 +32,18 @@ type Model struct {
 	// 列名 -> 字段信息
 	ColMap map[string]*Field
 	Fields []*Field
+	// 主键，复合主键时按字段定义顺序排列
+	PrimaryKeys []*Field
+	// 软删除字段，为 nil 时表示不使用软删除
+	SoftDelete *Field
+	// 插入时自动填充的创建时间字段
+	AutoCreateTime *Field
+	// 插入和更新时自动填充的更新时间字段
+	AutoUpdateTime *Field
+	// 索引，按第一次声明的字段顺序排列
+	Indexes []*Index
+	// 字段名 -> 关联关系，关联字段不对应列，不在 Fields 中
+	Relations map[string]*Relation
 
 	// 分表键
 	Sks map[string]struct{}
@@ -58,6 +85,67 @@ type Field struct {
 	GoName string
 	Typ    reflect.Type
 	Offset uintptr
+
+	// 是否为主键
+	PrimaryKey bool
+	// 是否为自增列
+	AutoIncrement bool
+	// 是否允许 NULL，指针和 sql.NullXXX 等实现了 driver.Valuer 的结构体允许 NULL
+	Nullable bool
+	// 通过 type 标签指定的列类型，为空时由方言根据 Go 类型推断
+	SQLType string
+	// 通过 size 标签指定的长度，用于字符串类型
+	Size int
+	// 通过 default 标签指定的默认值，原样拼接到 DDL 中
+	// 标签按 ',' 和 '=' 切分，默认值和 type 中都不能包含这两个字符
+	Default string
+}
+
+// Index 索引，复合索引的多个字段声明相同的索引名
+type Index struct {
+	Name   string
+	Unique bool
+	Fields []*Field
+}
+
+type RelationKind string
+
+const (
+	HasOne     RelationKind = "has_one"
+	HasMany    RelationKind = "has_many"
+	BelongsTo  RelationKind = "belongs_to"
+	ManyToMany RelationKind = "many_to_many"
+)
+
+// Relation 关联关系
+// 用法：
+//
+//	Orders []*Order `orm:"has_many,foreign_key=UserId"`
+//	User   *User    `orm:"belongs_to,foreign_key=UserId"`
+//	Roles  []*Role  `orm:"many_to_many=user_role,join_foreign_key=user_id,join_references=role_id"`
+type Relation struct {
+	Kind RelationKind
+	// 代码中的字段名
+	GoName string
+	// 字段类型，*T, T, []*T 或者 []T
+	Typ    reflect.Type
+	Offset uintptr
+	// 关联模型的结构体类型
+	RefType reflect.Type
+
+	// has_one, has_many 为关联模型中的字段名，默认为 当前类型名+主键字段名，比如 UserId
+	// belongs_to 为当前模型中的字段名，默认为 关联字段名+Id
+	ForeignKey string
+	// has_one, has_many, many_to_many 为当前模型中被引用的字段名，默认为主键
+	// belongs_to 为关联模型中被引用的字段名，为空时使用关联模型的主键
+	References string
+
+	// many_to_many 的连接表
+	JoinTable string
+	// 连接表中引用当前模型的列，默认为 当前类型名_被引用列名，比如 user_id
+	JoinForeignKey string
+	// 连接表中引用关联模型的列，默认为 关联类型名_id，比如 role_id
+	JoinReferences string
 }
 
 type TableName interface {
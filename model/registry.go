@@ -1,10 +1,14 @@
 package model
 
 import (
+	"database/sql"
+	"database/sql/driver"
 	"github.com/KNICEX/go-orm/internal/errs"
 	"reflect"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 	"unicode"
 )
 
@@ -44,16 +48,28 @@ func (r *registry) Get(val any) (*Model, error) {
 		return m, nil
 	}
 
-	var err error
-	m, err = r.Register(val)
+	m, err := r.parse(val)
 	if err != nil {
 		return nil, err
 	}
+	r.models[typ] = m
 	return m, nil
 }
 
-// Register 只接受 struct 一级指针
+// Register 只接受 struct 一级指针，覆盖已注册的模型
 func (r *registry) Register(entity any, opts ...Option) (*Model, error) {
+	m, err := r.parse(entity, opts...)
+	if err != nil {
+		return nil, err
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.models[reflect.TypeOf(entity)] = m
+	return m, nil
+}
+
+// parse 解析模型，不会保存
+func (r *registry) parse(entity any, opts ...Option) (*Model, error) {
 	typ := reflect.TypeOf(entity)
 	if typ.Kind() != reflect.Pointer {
 		return nil, errs.ErrModelType
@@ -67,6 +83,12 @@ func (r *registry) Register(entity any, opts ...Option) (*Model, error) {
 	fieldMap := make(map[string]*Field)
 	colMap := make(map[string]*Field)
 	fields := make([]*Field, 0, numField)
+	var pks []*Field
+	var softDelete, createTime, updateTime *Field
+	var idxFields []indexField
+	var relations map[string]*Relation
+	// 显式声明了 auto_increment 的字段，不再按约定推断
+	autoIncTagged := make(map[*Field]struct{})
 	for i := 0; i < numField; i++ {
 		fd := typ.Field(i)
 		if fd.IsExported() {
@@ -75,23 +97,85 @@ func (r *registry) Register(entity any, opts ...Option) (*Model, error) {
 				return nil, err
 			}
 
+			if rel, ok, err := r.relation(fd, tags); ok || err != nil {
+				if err != nil {
+					return nil, err
+				}
+				if relations == nil {
+					relations = make(map[string]*Relation)
+				}
+				relations[fd.Name] = rel
+				continue
+			}
+
 			colName := tags[tagColumn]
 			if colName == "" {
 				colName = underscoreName(fd.Name)
 			}
 
+			_, pk := tags[tagPrimaryKey]
+			autoInc, hasAutoInc := tags[tagAutoIncrement]
+			var size int
+			if sz, ok := tags[tagSize]; ok {
+				if size, err = strconv.Atoi(sz); err != nil {
+					return nil, errs.NewErrInvalidTag(tagSize + "=" + sz)
+				}
+			}
 			fieldInfo := &Field{
-				ColName: colName,
-				Typ:     fd.Type,
-				GoName:  fd.Name,
-				Offset:  fd.Offset,
+				ColName:       colName,
+				Typ:           fd.Type,
+				GoName:        fd.Name,
+				Offset:        fd.Offset,
+				PrimaryKey:    pk,
+				AutoIncrement: hasAutoInc && autoInc != "false",
+				Nullable:      nullable(fd.Type),
+				SQLType:       tags[tagType],
+				Size:          size,
+				Default:       tags[tagDefault],
 			}
 			fieldMap[fd.Name] = fieldInfo
 			colMap[colName] = fieldInfo
 			fields = append(fields, fieldInfo)
+			if pk {
+				pks = append(pks, fieldInfo)
+			}
+			if hasAutoInc {
+				autoIncTagged[fieldInfo] = struct{}{}
+			}
+			if name, ok := tags[tagIndex]; ok {
+				idxFields = append(idxFields, indexField{name: name, field: fieldInfo})
+			}
+			if name, ok := tags[tagUniqueIndex]; ok {
+				idxFields = append(idxFields, indexField{name: name, unique: true, field: fieldInfo})
+			}
+			if _, ok := tags[tagSoftDelete]; ok {
+				if !isTimeType(fd.Type) {
+					return nil, errs.NewErrInvalidSoftDelete(fd.Name)
+				}
+				softDelete = fieldInfo
+			}
+			if _, ok := tags[tagCreateTime]; ok {
+				createTime = fieldInfo
+			}
+			if _, ok := tags[tagUpdateTime]; ok {
+				updateTime = fieldInfo
+			}
 		}
 	}
 
+	// 没有通过标签声明时，按约定使用 DeletedAt, CreatedAt, UpdatedAt 字段，类型不支持时忽略
+	if softDelete == nil {
+		softDelete = conventionTimeField(fieldMap, "DeletedAt")
+	}
+	if createTime == nil {
+		createTime = conventionTimeField(fieldMap, "CreatedAt")
+	}
+	if updateTime == nil {
+		updateTime = conventionTimeField(fieldMap, "UpdatedAt")
+	}
+
+	pks = r.primaryKeys(pks, fields, autoIncTagged)
+
 	var tableName string
 	if tbn, ok := entity.(TableName); ok {
 		tableName = tbn.TableName()
@@ -99,22 +183,225 @@ func (r *registry) Register(entity any, opts ...Option) (*Model, error) {
 		tableName = underscoreName(typ.Name())
 	}
 
+	indexes := r.indexes(tableName, idxFields)
+	if err := r.relationDefaults(typ, fieldMap, pks, relations); err != nil {
+		return nil, err
+	}
+
 	res := &Model{
-		typ:       typ,
-		TableName: tableName,
-		FieldMap:  fieldMap,
-		ColMap:    colMap,
-		Fields:    fields,
+		typ:            typ,
+		TableName:      tableName,
+		FieldMap:       fieldMap,
+		ColMap:         colMap,
+		Fields:         fields,
+		PrimaryKeys:    pks,
+		SoftDelete:     softDelete,
+		AutoCreateTime: createTime,
+		AutoUpdateTime: updateTime,
+		Indexes:        indexes,
+		Relations:      relations,
 	}
 	for _, opt := range opts {
 		if err := opt(res); err != nil {
 			return nil, err
 		}
 	}
-	r.models[typ] = res
 	return res, nil
 }
 
+// primaryKeys 确定主键
+// 没有字段声明 pk 时，按约定使用 Id/ID 字段作为主键
+// 单一整数主键默认自增，除非显式声明 auto_increment=false
+func (r *registry) primaryKeys(pks []*Field, fields []*Field, autoIncTagged map[*Field]struct{}) []*Field {
+	if len(pks) == 0 {
+		for _, fd := range fields {
+			if fd.GoName == "Id" || fd.GoName == "ID" || fd.ColName == "id" {
+				fd.PrimaryKey = true
+				pks = append(pks, fd)
+				break
+			}
+		}
+	}
+	if len(pks) == 1 {
+		if _, ok := autoIncTagged[pks[0]]; !ok && isInteger(pks[0].Typ) {
+			pks[0].AutoIncrement = true
+		}
+	}
+	return pks
+}
+
+// relation 解析关联字段，没有关联标签时返回 false
+func (r *registry) relation(fd reflect.StructField, tags map[string]string) (*Relation, bool, error) {
+	var kind RelationKind
+	for _, k := range []RelationKind{HasOne, HasMany, BelongsTo, ManyToMany} {
+		if _, ok := tags[string(k)]; ok {
+			kind = k
+			break
+		}
+	}
+	if kind == "" {
+		return nil, false, nil
+	}
+
+	refType := fd.Type
+	many := kind == HasMany || kind == ManyToMany
+	if many {
+		if refType.Kind() != reflect.Slice {
+			return nil, true, errs.NewErrInvalidRelation(fd.Name)
+		}
+		refType = refType.Elem()
+	}
+	if refType.Kind() == reflect.Pointer {
+		refType = refType.Elem()
+	}
+	if refType.Kind() != reflect.Struct {
+		return nil, true, errs.NewErrInvalidRelation(fd.Name)
+	}
+
+	res := &Relation{
+		Kind:           kind,
+		GoName:         fd.Name,
+		Typ:            fd.Type,
+		Offset:         fd.Offset,
+		RefType:        refType,
+		ForeignKey:     tags[tagForeignKey],
+		References:     tags[tagReferences],
+		JoinTable:      tags[string(ManyToMany)],
+		JoinForeignKey: tags[tagJoinForeignKey],
+		JoinReferences: tags[tagJoinReferences],
+	}
+	if kind == ManyToMany {
+		if res.JoinTable == "" {
+			return nil, true, errs.NewErrInvalidRelation(fd.Name)
+		}
+		if res.JoinReferences == "" {
+			res.JoinReferences = underscoreName(refType.Name()) + "_id"
+		}
+	}
+	return res, true, nil
+}
+
+// relationDefaults 填充依赖当前模型主键的默认值，并校验引用的字段存在
+func (r *registry) relationDefaults(typ reflect.Type, fieldMap map[string]*Field, pks []*Field, relations map[string]*Relation) error {
+	for _, rel := range relations {
+		if rel.Kind == BelongsTo {
+			if rel.ForeignKey == "" {
+				rel.ForeignKey = rel.GoName + "Id"
+			}
+			if _, ok := fieldMap[rel.ForeignKey]; !ok {
+				return errs.NewErrInvalidRelation(rel.GoName)
+			}
+			continue
+		}
+		if rel.References == "" {
+			if len(pks) != 1 {
+				return errs.NewErrInvalidRelation(rel.GoName)
+			}
+			rel.References = pks[0].GoName
+		}
+		ref, ok := fieldMap[rel.References]
+		if !ok {
+			return errs.NewErrInvalidRelation(rel.GoName)
+		}
+		switch rel.Kind {
+		case ManyToMany:
+			if rel.JoinForeignKey == "" {
+				rel.JoinForeignKey = underscoreName(typ.Name()) + "_" + ref.ColName
+			}
+		default:
+			if rel.ForeignKey == "" {
+				rel.ForeignKey = typ.Name() + ref.GoName
+			}
+		}
+	}
+	return nil
+}
+
+type indexField struct {
+	name   string
+	unique bool
+	field  *Field
+}
+
+// indexes 合并同名索引，没有指定索引名时使用 idx_表名_列名 或 uk_表名_列名
+func (r *registry) indexes(tableName string, idxFields []indexField) []*Index {
+	var res []*Index
+	named := make(map[string]*Index, len(idxFields))
+	for _, f := range idxFields {
+		name := f.name
+		if name == "" {
+			prefix := "idx_"
+			if f.unique {
+				prefix = "uk_"
+			}
+			name = prefix + tableName + "_" + f.field.ColName
+		}
+		idx, ok := named[name]
+		if !ok {
+			idx = &Index{
+				Name:   name,
+				Unique: f.unique,
+			}
+			named[name] = idx
+			res = append(res, idx)
+		}
+		idx.Fields = append(idx.Fields, f.field)
+	}
+	return res
+}
+
+var valuerType = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	timePtrType  = reflect.TypeOf(&time.Time{})
+	nullTimeType = reflect.TypeOf(sql.NullTime{})
+)
+
+// isTimeType 是否可以自动写入时间
+// 支持 time.Time, *time.Time, sql.NullTime 和整数类型（Unix 秒）
+func isTimeType(typ reflect.Type) bool {
+	switch typ {
+	case timeType, timePtrType, nullTimeType:
+		return true
+	default:
+		return isInteger(typ)
+	}
+}
+
+// conventionTimeField 按约定的字段名查找时间字段，类型不支持时不使用
+func conventionTimeField(fieldMap map[string]*Field, name string) *Field {
+	fd, ok := fieldMap[name]
+	if !ok || !isTimeType(fd.Typ) {
+		return nil
+	}
+	return fd
+}
+
+// nullable 指针，以及 sql.NullString 这类实现了 driver.Valuer 的结构体允许 NULL
+func nullable(typ reflect.Type) bool {
+	switch typ.Kind() {
+	case reflect.Pointer:
+		return true
+	case reflect.Struct:
+		return typ.Implements(valuerType) || reflect.PointerTo(typ).Implements(valuerType)
+	default:
+		return false
+	}
+}
+
+func isInteger(typ reflect.Type) bool {
+	switch typ.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	default:
+		return false
+	}
+}
+
+// parseTag 解析 orm 标签，格式为 key1=val1,key2
+// 不支持引号转义，值中不能包含 ',' 和 '='
 func (r *registry) parseTag(tag reflect.StructTag) (map[string]string, error) {
 	ormTag, ok := tag.Lookup("orm")
 	if !ok || ormTag == "" {
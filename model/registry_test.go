@@ -3,6 +3,7 @@ package model
 import (
 	"github.com/KNICEX/go-orm/internal/errs"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"reflect"
 	"testing"
 )
@@ -40,10 +41,12 @@ func TestRegistry_Get(t *testing.T) {
 			},
 			fields: []*Field{
 				{
-					ColName: "id",
-					GoName:  "Id",
-					Typ:     reflect.TypeOf(int64(0)),
-					Offset:  0,
+					ColName:       "id",
+					GoName:        "Id",
+					Typ:           reflect.TypeOf(int64(0)),
+					Offset:        0,
+					PrimaryKey:    true,
+					AutoIncrement: true,
 				},
 				{
 					ColName: "first_name",
@@ -72,10 +75,12 @@ func TestRegistry_Get(t *testing.T) {
 			},
 			fields: []*Field{
 				{
-					ColName: "id",
-					GoName:  "Id",
-					Typ:     reflect.TypeOf(int64(0)),
-					Offset:  0,
+					ColName:       "id",
+					GoName:        "Id",
+					Typ:           reflect.TypeOf(int64(0)),
+					Offset:        0,
+					PrimaryKey:    true,
+					AutoIncrement: true,
 				},
 				{
 					ColName: "first_name_t",
@@ -121,10 +126,16 @@ func TestRegistry_Get(t *testing.T) {
 			}
 			fieldMap := make(map[string]*Field)
 			colMap := make(map[string]*Field)
+			var pks []*Field
 			for _, f := range tc.fields {
 				fieldMap[f.GoName] = f
 				colMap[f.ColName] = f
+				if f.PrimaryKey {
+					pks = append(pks, f)
+				}
 			}
+			tc.wantModel.typ = reflect.TypeOf(tc.entity).Elem()
+			tc.wantModel.PrimaryKeys = pks
 			tc.wantModel.Fields = tc.fields
 			tc.wantModel.FieldMap = fieldMap
 			tc.wantModel.ColMap = colMap
@@ -170,10 +181,12 @@ func TestRegistry_Register(t *testing.T) {
 			},
 			fields: []*Field{
 				{
-					ColName: "id",
-					GoName:  "Id",
-					Typ:     reflect.TypeOf(int64(0)),
-					Offset:  0,
+					ColName:       "id",
+					GoName:        "Id",
+					Typ:           reflect.TypeOf(int64(0)),
+					Offset:        0,
+					PrimaryKey:    true,
+					AutoIncrement: true,
 				},
 				{
 					ColName: "first_name",
@@ -200,10 +213,12 @@ func TestRegistry_Register(t *testing.T) {
 			},
 			fields: []*Field{
 				{
-					ColName: "id",
-					GoName:  "Id",
-					Typ:     reflect.TypeOf(int64(0)),
-					Offset:  0,
+					ColName:       "id",
+					GoName:        "Id",
+					Typ:           reflect.TypeOf(int64(0)),
+					Offset:        0,
+					PrimaryKey:    true,
+					AutoIncrement: true,
 				},
 				{
 					ColName: "first_name",
@@ -231,10 +246,12 @@ func TestRegistry_Register(t *testing.T) {
 			},
 			fields: []*Field{
 				{
-					ColName: "id",
-					GoName:  "Id",
-					Typ:     reflect.TypeOf(int64(0)),
-					Offset:  0,
+					ColName:       "id",
+					GoName:        "Id",
+					Typ:           reflect.TypeOf(int64(0)),
+					Offset:        0,
+					PrimaryKey:    true,
+					AutoIncrement: true,
 				},
 				{
 					ColName: "first_name_t",
@@ -250,6 +267,248 @@ func TestRegistry_Register(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:   "composite primary key",
+			entity: &TestModelCompositeKey{},
+			wantModel: &Model{
+				TableName: "test_model_composite_key",
+			},
+			fields: []*Field{
+				{
+					ColName:    "order_id",
+					GoName:     "OrderId",
+					Typ:        reflect.TypeOf(int64(0)),
+					Offset:     0,
+					PrimaryKey: true,
+				},
+				{
+					ColName:    "item_id",
+					GoName:     "ItemId",
+					Typ:        reflect.TypeOf(int64(0)),
+					Offset:     8,
+					PrimaryKey: true,
+				},
+				{
+					ColName: "id",
+					GoName:  "Id",
+					Typ:     reflect.TypeOf(int64(0)),
+					Offset:  16,
+				},
+			},
+		},
+		{
+			name:   "primary key tag",
+			entity: &TestModelPrimaryKeyTag{},
+			wantModel: &Model{
+				TableName: "test_model_primary_key_tag",
+			},
+			fields: []*Field{
+				{
+					ColName:    "code",
+					GoName:     "Code",
+					Typ:        reflect.TypeOf(""),
+					Offset:     0,
+					PrimaryKey: true,
+				},
+				{
+					ColName:       "seq",
+					GoName:        "Seq",
+					Typ:           reflect.TypeOf(int64(0)),
+					Offset:        16,
+					AutoIncrement: true,
+				},
+			},
+		},
+		{
+			name:   "soft delete tag",
+			entity: &TestModelSoftDelete{},
+			wantModel: &Model{
+				TableName: "test_model_soft_delete",
+				SoftDelete: &Field{
+					ColName: "removed_at",
+					GoName:  "RemovedAt",
+					Typ:     reflect.TypeOf(int64(0)),
+					Offset:  8,
+				},
+			},
+			fields: []*Field{
+				{
+					ColName:       "id",
+					GoName:        "Id",
+					Typ:           reflect.TypeOf(int64(0)),
+					Offset:        0,
+					PrimaryKey:    true,
+					AutoIncrement: true,
+				},
+				{
+					ColName: "removed_at",
+					GoName:  "RemovedAt",
+					Typ:     reflect.TypeOf(int64(0)),
+					Offset:  8,
+				},
+			},
+		},
+		{
+			name:   "string created at",
+			entity: &TestModelStringCreatedAt{},
+			wantModel: &Model{
+				TableName: "test_model_string_created_at",
+			},
+			fields: []*Field{
+				{
+					ColName:       "id",
+					GoName:        "Id",
+					Typ:           reflect.TypeOf(int64(0)),
+					Offset:        0,
+					PrimaryKey:    true,
+					AutoIncrement: true,
+				},
+				{
+					ColName: "created_at",
+					GoName:  "CreatedAt",
+					Typ:     reflect.TypeOf(""),
+					Offset:  8,
+				},
+			},
+		},
+		{
+			name:   "disable auto increment",
+			entity: &TestModelNoAutoIncrement{},
+			wantModel: &Model{
+				TableName: "test_model_no_auto_increment",
+			},
+			fields: []*Field{
+				{
+					ColName:    "id",
+					GoName:     "ID",
+					Typ:        reflect.TypeOf(int64(0)),
+					Offset:     0,
+					PrimaryKey: true,
+				},
+			},
+		},
+		{
+			name:   "column and index tags",
+			entity: &TestModelIndex{},
+			wantModel: &Model{
+				TableName: "test_model_index",
+				Indexes: []*Index{
+					{
+						Name:   "uk_test_model_index_email",
+						Unique: true,
+						Fields: []*Field{
+							{ColName: "email", GoName: "Email", Typ: reflect.TypeOf(""), Offset: 8, Size: 64},
+						},
+					},
+					{
+						Name: "idx_name_age",
+						Fields: []*Field{
+							{ColName: "name", GoName: "Name", Typ: reflect.TypeOf(new(string)), Offset: 24, Nullable: true, SQLType: "TEXT"},
+							{ColName: "age", GoName: "Age", Typ: reflect.TypeOf(int8(0)), Offset: 32, Default: "18"},
+						},
+					},
+				},
+			},
+			fields: []*Field{
+				{
+					ColName:       "id",
+					GoName:        "Id",
+					Typ:           reflect.TypeOf(int64(0)),
+					Offset:        0,
+					PrimaryKey:    true,
+					AutoIncrement: true,
+				},
+				{ColName: "email", GoName: "Email", Typ: reflect.TypeOf(""), Offset: 8, Size: 64},
+				{ColName: "name", GoName: "Name", Typ: reflect.TypeOf(new(string)), Offset: 24, Nullable: true, SQLType: "TEXT"},
+				{ColName: "age", GoName: "Age", Typ: reflect.TypeOf(int8(0)), Offset: 32, Default: "18"},
+			},
+		},
+		{
+			name:   "relations",
+			entity: &TestModelRelation{},
+			wantModel: &Model{
+				TableName: "test_model_relation",
+				Relations: map[string]*Relation{
+					"Parent": {
+						Kind:       BelongsTo,
+						GoName:     "Parent",
+						Typ:        reflect.TypeOf(&TestModel{}),
+						Offset:     16,
+						RefType:    reflect.TypeOf(TestModel{}),
+						ForeignKey: "ParentId",
+					},
+					"Children": {
+						Kind:       HasMany,
+						GoName:     "Children",
+						Typ:        reflect.TypeOf([]TestModel{}),
+						Offset:     24,
+						RefType:    reflect.TypeOf(TestModel{}),
+						ForeignKey: "TestModelRelationId",
+						References: "Id",
+					},
+					"Tags": {
+						Kind:           ManyToMany,
+						GoName:         "Tags",
+						Typ:            reflect.TypeOf([]*TestModel{}),
+						Offset:         48,
+						RefType:        reflect.TypeOf(TestModel{}),
+						References:     "Id",
+						JoinTable:      "relation_tag",
+						JoinForeignKey: "test_model_relation_id",
+						JoinReferences: "tag_id",
+					},
+				},
+			},
+			fields: []*Field{
+				{
+					ColName:       "id",
+					GoName:        "Id",
+					Typ:           reflect.TypeOf(int64(0)),
+					Offset:        0,
+					PrimaryKey:    true,
+					AutoIncrement: true,
+				},
+				{ColName: "parent_id", GoName: "ParentId", Typ: reflect.TypeOf(int64(0)), Offset: 8},
+			},
+		},
+		{
+			name:    "invalid relation",
+			entity:  &TestModelInvalidRelation{},
+			wantErr: errs.NewErrInvalidRelation("Parent"),
+		},
+		{
+			name:   "string deleted at",
+			entity: &TestModelStringDeletedAt{},
+			wantModel: &Model{
+				TableName: "test_model_string_deleted_at",
+			},
+			fields: []*Field{
+				{
+					ColName:       "id",
+					GoName:        "Id",
+					Typ:           reflect.TypeOf(int64(0)),
+					Offset:        0,
+					PrimaryKey:    true,
+					AutoIncrement: true,
+				},
+				{
+					ColName: "deleted_at",
+					GoName:  "DeletedAt",
+					Typ:     reflect.TypeOf(""),
+					Offset:  8,
+				},
+			},
+		},
+		{
+			name:    "invalid soft delete tag",
+			entity:  &TestModelInvalidSoftDelete{},
+			wantErr: errs.NewErrInvalidSoftDelete("Removed"),
+		},
+		{
+			name:    "invalid size tag",
+			entity:  &TestModelInvalidSize{},
+			wantErr: errs.NewErrInvalidTag("size=abc"),
+		},
 		{
 			name:   "test Model with ColNameOption unknown field",
 			entity: &TestModel{},
@@ -272,10 +531,16 @@ func TestRegistry_Register(t *testing.T) {
 			}
 			fieldMap := make(map[string]*Field)
 			colMap := make(map[string]*Field)
+			var pks []*Field
 			for _, f := range tc.fields {
 				fieldMap[f.GoName] = f
 				colMap[f.ColName] = f
+				if f.PrimaryKey {
+					pks = append(pks, f)
+				}
 			}
+			tc.wantModel.typ = reflect.TypeOf(tc.entity).Elem()
+			tc.wantModel.PrimaryKeys = pks
 			tc.wantModel.Fields = tc.fields
 			tc.wantModel.FieldMap = fieldMap
 			tc.wantModel.ColMap = colMap
@@ -284,6 +549,86 @@ func TestRegistry_Register(t *testing.T) {
 	}
 }
 
+type TestModelCompositeKey struct {
+	OrderId int64 `orm:"pk"`
+	ItemId  int64 `orm:"pk"`
+	Id      int64
+}
+
+type TestModelPrimaryKeyTag struct {
+	Code string `orm:"pk"`
+	Seq  int64  `orm:"auto_increment"`
+}
+
+type TestModelIndex struct {
+	Id    int64
+	Email string  `orm:"size=64,unique_index"`
+	Name  *string `orm:"type=TEXT,index=idx_name_age"`
+	Age   int8    `orm:"default=18,index=idx_name_age"`
+}
+
+func TestRegistry_GetAfterRegister(t *testing.T) {
+	r := NewRegistry()
+	registered, err := r.Register(&TestModel{}, WithTableName("accounts"))
+	require.NoError(t, err)
+
+	// Get 返回已注册的模型，不会重新解析
+	m, err := r.Get(&TestModel{})
+	require.NoError(t, err)
+	assert.Same(t, registered, m)
+	assert.Equal(t, "accounts", m.TableName)
+
+	// 没有注册过的模型只解析一次
+	first, err := r.Get(&TestModelSoftDelete{})
+	require.NoError(t, err)
+	second, err := r.Get(&TestModelSoftDelete{})
+	require.NoError(t, err)
+	assert.Same(t, first, second)
+}
+
+type TestModelRelation struct {
+	Id       int64
+	ParentId int64
+	Parent   *TestModel   `orm:"belongs_to"`
+	Children []TestModel  `orm:"has_many"`
+	Tags     []*TestModel `orm:"many_to_many=relation_tag,join_references=tag_id"`
+}
+
+type TestModelInvalidRelation struct {
+	Id     int64
+	Parent *TestModel `orm:"belongs_to,foreign_key=Unknown"`
+}
+
+type TestModelInvalidSize struct {
+	Name string `orm:"size=abc"`
+}
+
+type TestModelSoftDelete struct {
+	Id        int64
+	RemovedAt int64 `orm:"soft_delete"`
+}
+
+// 类型不支持的 CreatedAt 不会作为创建时间
+type TestModelStringCreatedAt struct {
+	Id        int64
+	CreatedAt string
+}
+
+// 类型不支持的 DeletedAt 不会作为软删除字段
+type TestModelStringDeletedAt struct {
+	Id        int64
+	DeletedAt string
+}
+
+type TestModelInvalidSoftDelete struct {
+	Id      int64
+	Removed bool `orm:"soft_delete"`
+}
+
+type TestModelNoAutoIncrement struct {
+	ID int64 `orm:"column=id,auto_increment=false"`
+}
+
 type User struct {
 	ID int `geeorm:"column=id"`
 }
@@ -0,0 +1,216 @@
+package orm
+
+import "github.com/KNICEX/go-orm/internal/errs"
+
+// multiTable 多表 UPDATE 和 DELETE
+// JOIN 最左边的表是被更新或者删除的表，其余的表只用于过滤和取值
+type multiTable struct {
+	join   Join
+	target Table
+	// 除目标表以外的表和连接条件，只有全部是带 ON 的 INNER JOIN 时有效
+	others []TableReference
+	on     []Predicate
+	inner  bool
+
+	where []Predicate
+	// set 构造赋值列表，qualifier 不为空时用它限定列名
+	set func(qualifier string) error
+}
+
+func (b *builder) newMultiTable(j Join) (*multiTable, error) {
+	mt := &multiTable{join: j, inner: true}
+	if err := mt.split(j); err != nil {
+		return nil, err
+	}
+	m, err := b.r.Get(mt.target.entity)
+	if err != nil {
+		return nil, err
+	}
+	if m.TableName != b.model.TableName {
+		return nil, errs.ErrJoinTarget
+	}
+	// 多表之间可能有同名的列，所有列都需要限定
+	b.qualifier = mt.qualifier(b)
+	return mt, nil
+}
+
+// split 拆分出最左边的目标表，其余的表和连接条件按 JOIN 的顺序排列
+func (mt *multiTable) split(j Join) error {
+	switch left := j.left.(type) {
+	case Join:
+		if err := mt.split(left); err != nil {
+			return err
+		}
+	case Table:
+		mt.target = left
+	default:
+		return errs.ErrJoinTarget
+	}
+	if j.typ != innerJoin || len(j.on) == 0 {
+		mt.inner = false
+	}
+	mt.others = append(mt.others, j.right)
+	mt.on = append(mt.on, j.on...)
+	return nil
+}
+
+// qualifier 限定目标表的列，没有别名时使用表名
+func (mt *multiTable) qualifier(b *builder) string {
+	if mt.target.alias != "" {
+		return mt.target.alias
+	}
+	return b.model.TableName
+}
+
+// buildOthers 构造除目标表以外的表，用逗号分隔
+func (b *builder) buildOthers(mt *multiTable) error {
+	for i, t := range mt.others {
+		if i > 0 {
+			b.sb.WriteByte(',')
+		}
+		if err := b.buildTable(t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// buildJoinWhere 构造 WHERE 连接条件 AND 过滤条件
+func (b *builder) buildJoinWhere(mt *multiTable) error {
+	ps := make([]Predicate, 0, len(mt.on)+len(mt.where))
+	ps = append(ps, mt.on...)
+	ps = append(ps, mt.where...)
+	if len(ps) == 0 {
+		return nil
+	}
+	b.sb.WriteString(" WHERE ")
+	return b.buildPredicate(ps)
+}
+
+// buildExists 构造 WHERE EXISTS (SELECT 1 FROM 其他表 WHERE 连接条件 AND 过滤条件)
+func (b *builder) buildExists(mt *multiTable) error {
+	b.sb.WriteString(" WHERE EXISTS (SELECT 1 FROM ")
+	if err := b.buildOthers(mt); err != nil {
+		return err
+	}
+	if err := b.buildJoinWhere(mt); err != nil {
+		return err
+	}
+	b.sb.WriteByte(')')
+	return nil
+}
+
+// buildCorrelated 构造关联子查询 (SELECT expr FROM 其他表 WHERE 连接条件 AND 过滤条件)，用于给目标表的列赋值
+// 和 EXISTS 使用相同的条件，保证取值的行和更新的行一致
+func (b *builder) buildCorrelated(e Expression) error {
+	mt := b.correlated
+	b.correlated = nil
+	defer func() {
+		b.correlated = mt
+	}()
+	b.sb.WriteString("(SELECT ")
+	if err := b.buildExpression(e); err != nil {
+		return err
+	}
+	b.sb.WriteString(" FROM ")
+	if err := b.buildOthers(mt); err != nil {
+		return err
+	}
+	if err := b.buildJoinWhere(mt); err != nil {
+		return err
+	}
+	b.sb.WriteByte(')')
+	return nil
+}
+
+// buildUpdateJoin 不支持多表更新时，使用关联子查询
+// UPDATE t SET c = (SELECT ... ) WHERE EXISTS (SELECT 1 FROM ...)
+func (s *standardSQL) buildUpdateJoin(b *builder, mt *multiTable) error {
+	if !mt.inner {
+		return errs.ErrMultiTableJoin
+	}
+	b.sb.WriteString("UPDATE ")
+	if err := b.buildTable(mt.target); err != nil {
+		return err
+	}
+	b.sb.WriteString(" SET ")
+	b.correlated = mt
+	err := mt.set("")
+	b.correlated = nil
+	if err != nil {
+		return err
+	}
+	return b.buildExists(mt)
+}
+
+// buildDeleteJoin DELETE FROM t WHERE EXISTS (SELECT 1 FROM ...)
+func (s *standardSQL) buildDeleteJoin(b *builder, mt *multiTable) error {
+	if !mt.inner {
+		return errs.ErrMultiTableJoin
+	}
+	b.sb.WriteString("DELETE FROM ")
+	if err := b.buildTable(mt.target); err != nil {
+		return err
+	}
+	return b.buildExists(mt)
+}
+
+// buildUpdateJoin UPDATE t JOIN u ON ... SET t.c = ? WHERE ...
+func (s *mysqlDialect) buildUpdateJoin(b *builder, mt *multiTable) error {
+	b.sb.WriteString("UPDATE ")
+	if err := b.buildJoin(mt.join); err != nil {
+		return err
+	}
+	b.sb.WriteString(" SET ")
+	if err := mt.set(mt.qualifier(b)); err != nil {
+		return err
+	}
+	return b.buildJoinWhere(&multiTable{where: mt.where})
+}
+
+// buildDeleteJoin DELETE t FROM t JOIN u ON ... WHERE ...
+func (s *mysqlDialect) buildDeleteJoin(b *builder, mt *multiTable) error {
+	b.sb.WriteString("DELETE ")
+	b.quote(mt.qualifier(b))
+	b.sb.WriteString(" FROM ")
+	if err := b.buildJoin(mt.join); err != nil {
+		return err
+	}
+	return b.buildJoinWhere(&multiTable{where: mt.where})
+}
+
+// buildUpdateJoin UPDATE t SET c = $1 FROM u WHERE 连接条件 AND ...
+func (p *postgresDialect) buildUpdateJoin(b *builder, mt *multiTable) error {
+	if !mt.inner {
+		return errs.ErrMultiTableJoin
+	}
+	b.sb.WriteString("UPDATE ")
+	if err := b.buildTable(mt.target); err != nil {
+		return err
+	}
+	b.sb.WriteString(" SET ")
+	if err := mt.set(""); err != nil {
+		return err
+	}
+	b.sb.WriteString(" FROM ")
+	if err := b.buildOthers(mt); err != nil {
+		return err
+	}
+	return b.buildJoinWhere(mt)
+}
+
+// buildDeleteJoin DELETE FROM t USING u WHERE 连接条件 AND ...
+func (p *postgresDialect) buildDeleteJoin(b *builder, mt *multiTable) error {
+	if !mt.inner {
+		return errs.ErrMultiTableJoin
+	}
+	b.sb.WriteString("DELETE FROM ")
+	if err := b.buildTable(mt.target); err != nil {
+		return err
+	}
+	b.sb.WriteString(" USING ")
+	if err := b.buildOthers(mt); err != nil {
+		return err
+	}
+	return b.buildJoinWhere(mt)
+}
@@ -0,0 +1,230 @@
+package orm
+
+import (
+	"context"
+	"github.com/KNICEX/go-orm/internal/errs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+type JoinOrder struct {
+	Id     int64
+	UserId int64
+	Status string
+	Note   string
+}
+
+type JoinUser struct {
+	Id     int64
+	Name   string
+	Banned bool
+}
+
+func TestMultiTable_Build(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	db, err := OpenDB(nil, DBWithDialect(DialectMySQL))
+	require.NoError(t, err)
+	pg, err := OpenDB(nil, DBWithDialect(DialectPostgres), DBWithClock(func() time.Time {
+		return now
+	}))
+	require.NoError(t, err)
+	sqlite, err := OpenDB(nil, DBWithDialect(DialectSQLite3))
+	require.NoError(t, err)
+
+	o := TableOf(&JoinOrder{}).As("o")
+	usr := TableOf(&JoinUser{}).As("u")
+	join := o.Join(usr).On(o.Col("UserId").Eq(usr.Col("Id")))
+	soft := TableOf(&SoftDeleteModel{}).As("s")
+	softJoin := soft.Join(usr).On(soft.Col("Id").Eq(usr.Col("Id")))
+
+	testCases := []struct {
+		name      string
+		builder   SqlBuilder
+		wantQuery *Query
+		wantErr   error
+	}{
+		{
+			name: "mysql update",
+			builder: NewUpdater[JoinOrder](db).From(join).
+				Set(Assign("Status", "closed"), Assign("Note", usr.Col("Name"))).
+				Where(usr.Col("Banned").Eq(true)),
+			wantQuery: &Query{
+				SQL: "UPDATE (`join_order` AS `o` INNER JOIN `join_user` AS `u` ON `o`.`user_id` = `u`.`id`) " +
+					"SET `o`.`status` = ?,`o`.`note` = `u`.`name` WHERE `u`.`banned` = ?;",
+				Args: []any{"closed", true},
+			},
+		},
+		{
+			name: "mysql delete",
+			builder: NewDeleter[JoinOrder](db).Using(join).
+				Where(usr.Col("Banned").Eq(true)),
+			wantQuery: &Query{
+				SQL: "DELETE `o` FROM (`join_order` AS `o` INNER JOIN `join_user` AS `u` ON `o`.`user_id` = `u`.`id`) " +
+					"WHERE `u`.`banned` = ?;",
+				Args: []any{true},
+			},
+		},
+		{
+			name: "mysql delete left join without alias",
+			builder: func() SqlBuilder {
+				ord := TableOf(&JoinOrder{})
+				u := TableOf(&JoinUser{})
+				return NewDeleter[JoinOrder](db).
+					Using(ord.LeftJoin(u).On(ord.Col("UserId").Eq(u.Col("Id")))).
+					Where(u.Col("Id").IsNull())
+			}(),
+			wantQuery: &Query{
+				SQL: "DELETE `join_order` FROM (`join_order` LEFT JOIN `join_user` ON `join_order`.`user_id` = `join_user`.`id`) " +
+					"WHERE `join_user`.`id` IS NULL;",
+			},
+		},
+		{
+			name: "postgres update",
+			builder: NewUpdater[JoinOrder](pg).From(join).
+				Set(Assign("Status", "closed"), Assign("Note", usr.Col("Name"))).
+				Where(usr.Col("Banned").Eq(true)),
+			wantQuery: &Query{
+				SQL: `UPDATE "join_order" AS "o" SET "status" = $1,"note" = "u"."name" FROM "join_user" AS "u" ` +
+					`WHERE ("o"."user_id" = "u"."id") AND ("u"."banned" = $2);`,
+				Args: []any{"closed", true},
+			},
+		},
+		{
+			name: "postgres delete",
+			builder: NewDeleter[JoinOrder](pg).Using(join).
+				Where(usr.Col("Banned").Eq(true)),
+			wantQuery: &Query{
+				SQL: `DELETE FROM "join_order" AS "o" USING "join_user" AS "u" ` +
+					`WHERE ("o"."user_id" = "u"."id") AND ("u"."banned" = $1);`,
+				Args: []any{true},
+			},
+		},
+		{
+			name: "sqlite update",
+			builder: NewUpdater[JoinOrder](sqlite).From(join).
+				Set(Assign("Status", "closed"), Assign("Note", usr.Col("Name"))).
+				Where(usr.Col("Banned").Eq(true)),
+			wantQuery: &Query{
+				SQL: "UPDATE `join_order` AS `o` SET `status` = ?," +
+					"`note` = (SELECT `u`.`name` FROM `join_user` AS `u` WHERE (`o`.`user_id` = `u`.`id`) AND (`u`.`banned` = ?)) " +
+					"WHERE EXISTS (SELECT 1 FROM `join_user` AS `u` WHERE (`o`.`user_id` = `u`.`id`) AND (`u`.`banned` = ?));",
+				Args: []any{"closed", true, true},
+			},
+		},
+		{
+			name: "sqlite update target column",
+			builder: NewUpdater[JoinOrder](sqlite).From(join).
+				Set(Assign("Note", Col("Status").Upper())).
+				Where(Col("Id").Gt(1)),
+			wantQuery: &Query{
+				SQL: "UPDATE `join_order` AS `o` " +
+					"SET `note` = (SELECT UPPER(`o`.`status`) FROM `join_user` AS `u` WHERE (`o`.`user_id` = `u`.`id`) AND (`o`.`id` > ?)) " +
+					"WHERE EXISTS (SELECT 1 FROM `join_user` AS `u` WHERE (`o`.`user_id` = `u`.`id`) AND (`o`.`id` > ?));",
+				Args: []any{1, 1},
+			},
+		},
+		{
+			name: "mysql update target column without alias",
+			builder: func() SqlBuilder {
+				ord := TableOf(&JoinOrder{})
+				u := TableOf(&JoinUser{})
+				return NewUpdater[JoinOrder](db).
+					From(ord.Join(u).On(ord.Col("UserId").Eq(u.Col("Id")))).
+					Set(Assign("Note", u.Col("Name"))).
+					Where(Col("Id").Gt(1))
+			}(),
+			wantQuery: &Query{
+				SQL: "UPDATE (`join_order` INNER JOIN `join_user` ON `join_order`.`user_id` = `join_user`.`id`) " +
+					"SET `join_order`.`note` = `join_user`.`name` WHERE `join_order`.`id` > ?;",
+				Args: []any{1},
+			},
+		},
+		{
+			name: "sqlite delete",
+			builder: NewDeleter[JoinOrder](sqlite).Using(join).
+				Where(usr.Col("Banned").Eq(true)),
+			wantQuery: &Query{
+				SQL: "DELETE FROM `join_order` AS `o` " +
+					"WHERE EXISTS (SELECT 1 FROM `join_user` AS `u` WHERE (`o`.`user_id` = `u`.`id`) AND (`u`.`banned` = ?));",
+				Args: []any{true},
+			},
+		},
+		{
+			name: "soft delete",
+			builder: NewDeleter[SoftDeleteModel](pg).Using(softJoin).
+				Where(usr.Col("Banned").Eq(true)),
+			wantQuery: &Query{
+				SQL: `UPDATE "soft_delete_model" AS "s" SET "deleted_at" = $1 FROM "join_user" AS "u" ` +
+					`WHERE (("s"."id" = "u"."id") AND ("u"."banned" = $2)) AND ("s"."deleted_at" IS NULL);`,
+				Args: []any{&now, true},
+			},
+		},
+		{
+			name: "left join unsupported",
+			builder: NewUpdater[JoinOrder](pg).
+				From(o.LeftJoin(usr).On(o.Col("UserId").Eq(usr.Col("Id")))).
+				Set(Assign("Status", "closed")),
+			wantErr: errs.ErrMultiTableJoin,
+		},
+		{
+			name: "join target",
+			builder: NewUpdater[JoinOrder](db).
+				From(usr.Join(o).On(o.Col("UserId").Eq(usr.Col("Id")))).
+				Set(Assign("Status", "closed")),
+			wantErr: errs.ErrJoinTarget,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			q, err := tc.builder.Build()
+			assert.Equal(t, tc.wantErr, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.wantQuery, q)
+		})
+	}
+}
+
+func TestMultiTable_SQLite(t *testing.T) {
+	db := memoryWithDB("multi_table", t, DBWithDialect(DialectSQLite3))
+	ctx := context.Background()
+	require.NoError(t, db.AutoMigrate(ctx, &JoinOrder{}, &JoinUser{}))
+	require.NoError(t, NewInserter[JoinUser](db).Values(
+		&JoinUser{Id: 1, Name: "tom"},
+		&JoinUser{Id: 2, Name: "jerry", Banned: true},
+	).Exec(ctx).Err())
+	require.NoError(t, NewInserter[JoinOrder](db).Values(
+		&JoinOrder{Id: 1, UserId: 1, Status: "open"},
+		&JoinOrder{Id: 2, UserId: 2, Status: "open"},
+		&JoinOrder{Id: 3, UserId: 2, Status: "open"},
+		&JoinOrder{Id: 4, UserId: 1, Status: "open"},
+	).Exec(ctx).Err())
+
+	o := TableOf(&JoinOrder{}).As("o")
+	usr := TableOf(&JoinUser{}).As("u")
+	join := o.Join(usr).On(o.Col("UserId").Eq(usr.Col("Id")))
+
+	affected, err := NewUpdater[JoinOrder](db).From(join).
+		Set(Assign("Status", "closed"), Assign("Note", usr.Col("Name"))).
+		Where(usr.Col("Banned").Eq(true)).Exec(ctx).RowsAffected()
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), affected)
+	closed, err := NewSelector[JoinOrder](db).Where(Col("Status").Eq("closed")).OrderBy(Col("Id")).GetMulti(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []*JoinOrder{
+		{Id: 2, UserId: 2, Status: "closed", Note: "jerry"},
+		{Id: 3, UserId: 2, Status: "closed", Note: "jerry"},
+	}, closed)
+
+	affected, err = NewDeleter[JoinOrder](db).Using(join).
+		Where(usr.Col("Name").Eq("tom").And(Col("Id").Gt(1))).Exec(ctx).RowsAffected()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), affected)
+	cnt, err := NewSelector[JoinOrder](db).Count(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), cnt)
+}
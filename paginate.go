@@ -0,0 +1,240 @@
+package orm
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"github.com/KNICEX/go-orm/internal/errs"
+	"github.com/KNICEX/go-orm/model"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// Page 游标分页的一页结果
+type Page[T any] struct {
+	Items []*T
+	// 下一页的游标，没有下一页时为空
+	Next string
+	// 上一页的游标，第一页时为空
+	Prev string
+}
+
+type pageOrder struct {
+	field *model.Field
+	desc  bool
+}
+
+type pageCursor struct {
+	// 是否向前翻页
+	Prev   bool              `json:"p,omitempty"`
+	Values []json.RawMessage `json:"v"`
+}
+
+// Paginate 按 OrderBy 的列做游标分页，cursor 为空时返回第一页
+// 排序列中没有主键时会追加主键，保证排序唯一，排序列的值不应该为 NULL
+// 所有排序列方向一致且方言支持时使用 (a, b) > (?, ?)，否则展开为 a > ? OR (a = ? AND b > ?)
+// 游标使用 DBWithCursorSecret 指定的密钥签名，被篡改或者用于其他表、其他排序方式时返回 errs.ErrInvalidCursor
+// 签名不包含查询条件，同一张表和排序方式下换了条件的查询仍然可以使用该游标
+func (s *Selector[T]) Paginate(ctx context.Context, cursor string, size int) (*Page[T], error) {
+	if size <= 0 {
+		return nil, errs.NewErrInvalidBatchSize(size)
+	}
+	m, err := s.r.Get(new(T))
+	if err != nil {
+		return nil, err
+	}
+	orders, err := s.pageOrders(m)
+	if err != nil {
+		return nil, err
+	}
+
+	var cur pageCursor
+	var vals []any
+	if cursor != "" {
+		if cur, vals, err = s.decodeCursor(m, orders, cursor); err != nil {
+			return nil, err
+		}
+	}
+
+	names := make([]string, 0, len(orders))
+	for _, o := range orders {
+		names = append(names, o.field.GoName)
+	}
+	ps := s.derive(withFields(s.columns, names...))
+	if vals != nil {
+		ps.where = append(ps.where, s.cursorPredicate(orders, vals, cur.Prev))
+	}
+	obs := make([]OrderAble, 0, len(orders))
+	for _, o := range orders {
+		// 向前翻页时反向排序，查询后再反转
+		obs = append(obs, Column{name: o.field.GoName, desc: o.desc != cur.Prev})
+	}
+	items, err := ps.OrderBy(obs...).Limit(size + 1).GetMulti(ctx)
+	if err != nil && !errors.Is(err, ErrNoRows) {
+		return nil, err
+	}
+	hasMore := len(items) > size
+	if hasMore {
+		items = items[:size]
+	}
+	if cur.Prev {
+		slices.Reverse(items)
+	}
+
+	res := &Page[T]{Items: items}
+	if len(items) == 0 {
+		return res, nil
+	}
+	// 向后翻页时，有更多数据才有下一页，带游标说明不是第一页
+	// 向前翻页时，一定有下一页，有更多数据才有上一页
+	if hasMore || cur.Prev {
+		if res.Next, err = s.encodeCursor(m, orders, items[len(items)-1], false); err != nil {
+			return nil, err
+		}
+	}
+	if (cursor != "" && !cur.Prev) || (cur.Prev && hasMore) {
+		if res.Prev, err = s.encodeCursor(m, orders, items[0], true); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// pageOrders 分页使用的排序列，没有主键时追加主键，方向和最后一个排序列一致
+func (s *Selector[T]) pageOrders(m *model.Model) ([]pageOrder, error) {
+	res := make([]pageOrder, 0, len(s.orderBys)+1)
+	for _, ob := range s.orderBys {
+		c, ok := ob.(Column)
+		if !ok {
+			return nil, errs.NewErrUnsupportedOrderBy(ob)
+		}
+		fd, ok := m.FieldMap[c.name]
+		if !ok {
+			return nil, errs.NewErrUnknownField(c.name)
+		}
+		res = append(res, pageOrder{field: fd, desc: c.desc})
+	}
+	desc := len(res) > 0 && res[len(res)-1].desc
+	for _, pk := range m.PrimaryKeys {
+		if !slices.ContainsFunc(res, func(o pageOrder) bool { return o.field == pk }) {
+			res = append(res, pageOrder{field: pk, desc: desc})
+		}
+	}
+	if len(res) == 0 {
+		return nil, errs.ErrNoPrimaryKey
+	}
+	return res, nil
+}
+
+// cursorPredicate 构造游标之后的条件
+func (s *Selector[T]) cursorPredicate(orders []pageOrder, vals []any, backward bool) Predicate {
+	cmp := func(i int) op {
+		if orders[i].desc == backward {
+			return opGt
+		}
+		return opLt
+	}
+	sameDirection := true
+	for _, o := range orders[1:] {
+		if o.desc != orders[0].desc {
+			sameDirection = false
+		}
+	}
+	if len(orders) > 1 && sameDirection && s.dialect.supportRowValues() {
+		cols := make([]Expression, 0, len(orders))
+		args := make([]Expression, 0, len(orders))
+		for i, o := range orders {
+			cols = append(cols, Col(o.field.GoName))
+			args = append(args, valueOf(vals[i]))
+		}
+		return Predicate{left: rowValue{exprs: cols}, op: cmp(0), right: rowValue{exprs: args}}
+	}
+
+	// a > ? OR (a = ? AND b > ?) OR ...
+	var res Predicate
+	for i := range orders {
+		p := Predicate{left: Col(orders[i].field.GoName), op: cmp(i), right: valueOf(vals[i])}
+		for j := i - 1; j >= 0; j-- {
+			p = Col(orders[j].field.GoName).Eq(vals[j]).And(p)
+		}
+		if i == 0 {
+			res = p
+			continue
+		}
+		res = res.Or(p)
+	}
+	return res
+}
+
+func (s *Selector[T]) encodeCursor(m *model.Model, orders []pageOrder, entity *T, prev bool) (string, error) {
+	val := s.creator(m, entity)
+	cur := pageCursor{Prev: prev, Values: make([]json.RawMessage, 0, len(orders))}
+	for _, o := range orders {
+		v, err := val.Field(o.field.GoName)
+		if err != nil {
+			return "", err
+		}
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		cur.Values = append(cur.Values, raw)
+	}
+	payload, err := json.Marshal(cur)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(payload) + "." +
+		base64.RawURLEncoding.EncodeToString(s.cursorMAC(m, orders, payload)), nil
+}
+
+func (s *Selector[T]) decodeCursor(m *model.Model, orders []pageOrder, cursor string) (pageCursor, []any, error) {
+	var cur pageCursor
+	payloadStr, macStr, ok := strings.Cut(cursor, ".")
+	if !ok {
+		return cur, nil, errs.ErrInvalidCursor
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(payloadStr)
+	if err != nil {
+		return cur, nil, errs.ErrInvalidCursor
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(macStr)
+	if err != nil || !hmac.Equal(mac, s.cursorMAC(m, orders, payload)) {
+		return cur, nil, errs.ErrInvalidCursor
+	}
+	if err = json.Unmarshal(payload, &cur); err != nil || len(cur.Values) != len(orders) {
+		return cur, nil, errs.ErrInvalidCursor
+	}
+	vals := make([]any, 0, len(orders))
+	for i, o := range orders {
+		v := reflect.New(o.field.Typ)
+		if err = json.Unmarshal(cur.Values[i], v.Interface()); err != nil {
+			return cur, nil, errs.ErrInvalidCursor
+		}
+		vals = append(vals, v.Elem().Interface())
+	}
+	return cur, vals, nil
+}
+
+// cursorMAC 签名包含表名和排序方式，游标不能用于其他表或者其他排序方式
+func (s *Selector[T]) cursorMAC(m *model.Model, orders []pageOrder, payload []byte) []byte {
+	var sb bytes.Buffer
+	sb.WriteString(m.TableName)
+	for _, o := range orders {
+		sb.WriteByte(',')
+		sb.WriteString(o.field.ColName)
+		if o.desc {
+			sb.WriteString(" DESC")
+		}
+	}
+	sb.WriteByte('\n')
+	sb.Write(payload)
+	h := hmac.New(sha256.New, s.cursorSecret)
+	h.Write(sb.Bytes())
+	return h.Sum(nil)
+}
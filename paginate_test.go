@@ -0,0 +1,213 @@
+package orm
+
+import (
+	"context"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/KNICEX/go-orm/internal/errs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+type PageModel struct {
+	Id    int64
+	Score int64
+	Name  string
+}
+
+func TestSelector_Paginate_SQLite(t *testing.T) {
+	db, err := Open("sqlite3", "file:paginate.db?cache=shared&mode=memory",
+		DBWithDialect(DialectSQLite3), DBWithCursorSecret([]byte("secret")))
+	require.NoError(t, err)
+	ctx := context.Background()
+	require.NoError(t, db.AutoMigrate(ctx, &PageModel{}))
+	require.NoError(t, NewInserter[PageModel](db).Values(
+		&PageModel{Id: 1, Score: 90, Name: "a"},
+		&PageModel{Id: 2, Score: 80, Name: "b"},
+		&PageModel{Id: 3, Score: 90, Name: "c"},
+		&PageModel{Id: 4, Score: 70, Name: "d"},
+		&PageModel{Id: 5, Score: 80, Name: "e"},
+	).Exec(ctx).Err())
+
+	names := func(p *Page[PageModel]) []string {
+		res := make([]string, 0, len(p.Items))
+		for _, item := range p.Items {
+			res = append(res, item.Name)
+		}
+		return res
+	}
+
+	testCases := []struct {
+		name     string
+		orderBys []OrderAble
+		pages    [][]string
+	}{
+		{
+			name:     "mixed direction",
+			orderBys: []OrderAble{Col("Score").Desc(), Col("Id").Asc()},
+			pages:    [][]string{{"a", "c"}, {"b", "e"}, {"d"}},
+		},
+		{
+			name:     "same direction",
+			orderBys: []OrderAble{Col("Score").Desc(), Col("Id").Desc()},
+			pages:    [][]string{{"c", "a"}, {"e", "b"}, {"d"}},
+		},
+		{
+			name:     "primary key appended",
+			orderBys: []OrderAble{Col("Score")},
+			pages:    [][]string{{"d", "b"}, {"e", "a"}, {"c"}},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			newSelector := func() *Selector[PageModel] {
+				return NewSelector[PageModel](db).OrderBy(tc.orderBys...)
+			}
+			// 向后翻页
+			var pages []*Page[PageModel]
+			cursor := ""
+			for {
+				page, err := newSelector().Paginate(ctx, cursor, 2)
+				require.NoError(t, err)
+				pages = append(pages, page)
+				if page.Next == "" {
+					break
+				}
+				cursor = page.Next
+			}
+			require.Len(t, pages, len(tc.pages))
+			for i, p := range pages {
+				assert.Equal(t, tc.pages[i], names(p))
+			}
+			assert.Empty(t, pages[0].Prev)
+
+			// 从最后一页向前翻页
+			page := pages[len(pages)-1]
+			for i := len(tc.pages) - 2; i >= 0; i-- {
+				require.NotEmpty(t, page.Prev)
+				page, err = newSelector().Paginate(ctx, page.Prev, 2)
+				require.NoError(t, err)
+				assert.Equal(t, tc.pages[i], names(page))
+				assert.NotEmpty(t, page.Next)
+			}
+			assert.Empty(t, page.Prev)
+		})
+	}
+
+	// 保留查询条件
+	page, err := NewSelector[PageModel](db).Where(Col("Score").Ge(80)).OrderBy(Col("Score").Desc()).Paginate(ctx, "", 3)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c", "a", "e"}, names(page))
+	page, err = NewSelector[PageModel](db).Where(Col("Score").Ge(80)).OrderBy(Col("Score").Desc()).Paginate(ctx, page.Next, 3)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b"}, names(page))
+	assert.Empty(t, page.Next)
+}
+
+func TestSelector_Paginate_Cursor(t *testing.T) {
+	db, err := OpenDB(nil, DBWithDialect(DialectMySQL), DBWithCursorSecret([]byte("secret")))
+	require.NoError(t, err)
+	s := NewSelector[PageModel](db).OrderBy(Col("Score").Desc())
+	m, err := db.r.Get(&PageModel{})
+	require.NoError(t, err)
+	orders, err := s.pageOrders(m)
+	require.NoError(t, err)
+
+	cursor, err := s.encodeCursor(m, orders, &PageModel{Id: 3, Score: 90}, false)
+	require.NoError(t, err)
+	cur, vals, err := s.decodeCursor(m, orders, cursor)
+	require.NoError(t, err)
+	assert.False(t, cur.Prev)
+	assert.Equal(t, []any{int64(90), int64(3)}, vals)
+
+	testCases := []struct {
+		name   string
+		cursor string
+		orders []OrderAble
+		db     *DB
+	}{
+		{name: "tampered", cursor: "eyJ2IjpbOTksM119." + cursor[len(cursor)-43:]},
+		{name: "malformed", cursor: "abc"},
+		{name: "other order", cursor: cursor, orders: []OrderAble{Col("Score").Asc()}},
+		{
+			name:   "other secret",
+			cursor: cursor,
+			db: func() *DB {
+				res, err := OpenDB(nil, DBWithDialect(DialectMySQL), DBWithCursorSecret([]byte("other")))
+				require.NoError(t, err)
+				return res
+			}(),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := db
+			if tc.db != nil {
+				d = tc.db
+			}
+			orderBys := tc.orders
+			if orderBys == nil {
+				orderBys = []OrderAble{Col("Score").Desc()}
+			}
+			_, err := NewSelector[PageModel](d).OrderBy(orderBys...).Paginate(context.Background(), tc.cursor, 10)
+			assert.Equal(t, errs.ErrInvalidCursor, err)
+		})
+	}
+}
+
+func TestSelector_Paginate_Query(t *testing.T) {
+	testCases := []struct {
+		name     string
+		dialect  Dialect
+		orderBys []OrderAble
+		prev     bool
+		wantSQL  string
+	}{
+		{
+			name:     "row values",
+			dialect:  DialectMySQL,
+			orderBys: []OrderAble{Col("Score").Desc()},
+			wantSQL:  "SELECT * FROM `page_model` WHERE (`name` = ?) AND ((`score`,`id`) < (?,?)) ORDER BY `score` DESC,`id` DESC LIMIT 3;",
+		},
+		{
+			name:     "row values prev",
+			dialect:  DialectPostgres,
+			orderBys: []OrderAble{Col("Score").Asc()},
+			prev:     true,
+			wantSQL:  `SELECT * FROM "page_model" WHERE ("name" = $1) AND (("score","id") < ($2,$3)) ORDER BY "score" DESC,"id" DESC LIMIT 3;`,
+		},
+		{
+			name:     "expanded",
+			dialect:  DialectMySQL,
+			orderBys: []OrderAble{Col("Score").Desc(), Col("Id").Asc()},
+			wantSQL:  "SELECT * FROM `page_model` WHERE (`name` = ?) AND ((`score` < ?) OR ((`score` = ?) AND (`id` > ?))) ORDER BY `score` DESC,`id` ASC LIMIT 3;",
+		},
+		{
+			name:     "no row values",
+			dialect:  &standardSQL{},
+			orderBys: []OrderAble{Col("Score").Desc()},
+			wantSQL:  "SELECT * FROM `page_model` WHERE (`name` = ?) AND ((`score` < ?) OR ((`score` = ?) AND (`id` < ?))) ORDER BY `score` DESC,`id` DESC LIMIT 3;",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			require.NoError(t, err)
+			db, err := OpenDB(mockDB, DBWithDialect(tc.dialect))
+			require.NoError(t, err)
+			s := NewSelector[PageModel](db).OrderBy(tc.orderBys...)
+			m, err := db.r.Get(&PageModel{})
+			require.NoError(t, err)
+			orders, err := s.pageOrders(m)
+			require.NoError(t, err)
+			cursor, err := s.encodeCursor(m, orders, &PageModel{Id: 3, Score: 90}, tc.prev)
+			require.NoError(t, err)
+
+			mock.ExpectQuery(tc.wantSQL).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			_, err = NewSelector[PageModel](db).Where(Col("Name").Eq("x")).
+				OrderBy(tc.orderBys...).Paginate(context.Background(), cursor, 2)
+			require.NoError(t, err)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
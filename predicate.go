@@ -14,6 +14,17 @@ const (
 	opLike  op = "LIKE"
 	opIn    op = "IN"
 	opNotIn op = "NOT IN"
+
+	opNeq        op = "<>"
+	opNotLike    op = "NOT LIKE"
+	opILike      op = "ILIKE"
+	opBetween    op = "BETWEEN"
+	opNotBetween op = "NOT BETWEEN"
+	opExists     op = "EXISTS"
+	opNotExists  op = "NOT EXISTS"
+
+	opIsNull    op = "IS NULL"
+	opIsNotNull op = "IS NOT NULL"
 )
 
 func (o op) String() string {
@@ -58,3 +69,17 @@ type value struct {
 }
 
 func (v value) expr() {}
+
+// Exists 子查询有结果时为真，子查询的参数按出现的位置合并到外层语句
+// 用法： Exists(NewSelector[Order](db).Where(Col("UserId").Eq(u.Col("Id"))))，u 为外层查询中带别名的表
+func Exists(sub SqlBuilder) Predicate {
+	return Predicate{
+		left: prefixExpr{op: opExists, right: subQueryExpr{s: sub}},
+	}
+}
+
+func NotExists(sub SqlBuilder) Predicate {
+	return Predicate{
+		left: prefixExpr{op: opNotExists, right: subQueryExpr{s: sub}},
+	}
+}
@@ -0,0 +1,214 @@
+package orm
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+type PredicateOrder struct {
+	Id     int64
+	UserId int64
+	Amount int64
+}
+
+func TestPredicate_Build(t *testing.T) {
+	db, err := OpenDB(nil, DBWithDialect(DialectMySQL))
+	require.NoError(t, err)
+	pg, err := OpenDB(nil, DBWithDialect(DialectPostgres))
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name      string
+		builder   SqlBuilder
+		wantQuery *Query
+		wantErr   error
+	}{
+		{
+			name:    "neq",
+			builder: NewSelector[TestModel](db).Where(Col("Id").Neq(1)),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `test_model` WHERE `id` <> ?;",
+				Args: []any{1},
+			},
+		},
+		{
+			name:    "is not null",
+			builder: NewSelector[TestModel](db).Where(Col("FirstName").IsNotNull().And(Col("LastName").IsNull())),
+			wantQuery: &Query{
+				SQL: "SELECT * FROM `test_model` WHERE (`first_name` IS NOT NULL) AND (`last_name` IS NULL);",
+			},
+		},
+		{
+			name:    "between",
+			builder: NewSelector[TestModel](db).Where(Col("Id").Between(1, 10).Or(Col("Id").NotBetween(20, 30))),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `test_model` WHERE (`id` BETWEEN ? AND ?) OR (`id` NOT BETWEEN ? AND ?);",
+				Args: []any{1, 10, 20, 30},
+			},
+		},
+		{
+			name:    "not like",
+			builder: NewSelector[TestModel](db).Where(Col("FirstName").NotLike("a%")),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `test_model` WHERE `first_name` NOT LIKE ?;",
+				Args: []any{"a%"},
+			},
+		},
+		{
+			name:    "ilike",
+			builder: NewSelector[TestModel](db).Where(Col("FirstName").ILike("A%")),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `test_model` WHERE LOWER(`first_name`) LIKE LOWER(?);",
+				Args: []any{"A%"},
+			},
+		},
+		{
+			name:    "postgres ilike",
+			builder: NewSelector[TestModel](pg).Where(Col("FirstName").ILike("A%")),
+			wantQuery: &Query{
+				SQL:  `SELECT * FROM "test_model" WHERE "first_name" ILIKE $1;`,
+				Args: []any{"A%"},
+			},
+		},
+		{
+			name:    "in",
+			builder: NewSelector[TestModel](db).Where(Col("Id").In(1, 2, 3)),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `test_model` WHERE `id` IN (?,?,?);",
+				Args: []any{1, 2, 3},
+			},
+		},
+		{
+			name:    "in slice",
+			builder: NewSelector[TestModel](pg).Where(Col("Id").In([]int64{1, 2}).And(Col("FirstName").NotIn("a"))),
+			wantQuery: &Query{
+				SQL:  `SELECT * FROM "test_model" WHERE ("id" IN ($1,$2)) AND ("first_name" NOT IN ($3));`,
+				Args: []any{int64(1), int64(2), "a"},
+			},
+		},
+		{
+			name:    "in bytes",
+			builder: NewSelector[TestModel](db).Where(Col("FirstName").In([]byte("a"))),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `test_model` WHERE `first_name` IN (?);",
+				Args: []any{[]byte("a")},
+			},
+		},
+		{
+			name:    "in empty",
+			builder: NewSelector[TestModel](db).Where(Col("Id").In([]any{}...)),
+			wantQuery: &Query{
+				SQL: "SELECT * FROM `test_model` WHERE (1 = 0);",
+			},
+		},
+		{
+			name:    "not in empty",
+			builder: NewSelector[TestModel](db).Where(Col("Id").NotIn([]int64{}).And(Col("Id").Eq(1))),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `test_model` WHERE ((1 = 1)) AND (`id` = ?);",
+				Args: []any{1},
+			},
+		},
+		{
+			name: "in query",
+			builder: NewSelector[TestModel](pg).Where(Col("FirstName").Eq("a").
+				And(Col("Id").InQuery(NewSelector[PredicateOrder](pg).Select(Col("UserId")).Where(Col("Amount").Gt(100)))).
+				And(Col("LastName").Eq("b"))),
+			wantQuery: &Query{
+				SQL:  `SELECT * FROM "test_model" WHERE (("first_name" = $1) AND ("id" IN (SELECT "user_id" FROM "predicate_order" WHERE "amount" > $2))) AND ("last_name" = $3);`,
+				Args: []any{"a", 100, "b"},
+			},
+		},
+		{
+			name: "not in nested query",
+			builder: NewSelector[TestModel](db).Where(Col("Id").NotInQuery(
+				NewSelector[PredicateOrder](db).Select(Col("UserId")).Where(Col("Id").InQuery(
+					NewSelector[PredicateOrder](db).Select(Col("Id")).Where(Col("Amount").Lt(5)),
+				)),
+			)),
+			wantQuery: &Query{
+				SQL: "SELECT * FROM `test_model` WHERE `id` NOT IN (SELECT `user_id` FROM `predicate_order` " +
+					"WHERE `id` IN (SELECT `id` FROM `predicate_order` WHERE `amount` < ?));",
+				Args: []any{5},
+			},
+		},
+		{
+			name: "exists",
+			builder: func() SqlBuilder {
+				u := TableOf(&TestModel{}).As("u")
+				o := TableOf(&PredicateOrder{}).As("o")
+				return NewSelector[TestModel](db).From(u).Where(Exists(
+					NewSelector[PredicateOrder](db).From(o).Select(Raw("1")).
+						Where(o.Col("UserId").Eq(u.Col("Id")).And(o.Col("Amount").Gt(10))),
+				).And(NotExists(NewSelector[PredicateOrder](db).Where(Col("Amount").Lt(0)))))
+			}(),
+			wantQuery: &Query{
+				SQL: "SELECT * FROM `test_model` AS `u` WHERE (EXISTS (SELECT 1 FROM `predicate_order` AS `o` " +
+					"WHERE (`o`.`user_id` = `u`.`id`) AND (`o`.`amount` > ?))) " +
+					"AND (NOT EXISTS (SELECT * FROM `predicate_order` WHERE `amount` < ?));",
+				Args: []any{10, 0},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			q, err := tc.builder.Build()
+			assert.Equal(t, tc.wantErr, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.wantQuery, q)
+		})
+	}
+}
+
+func TestPredicate_SQLite(t *testing.T) {
+	db := memoryWithDB("predicate", t, DBWithDialect(DialectSQLite3))
+	ctx := context.Background()
+	require.NoError(t, db.AutoMigrate(ctx, &TestModel{}, &PredicateOrder{}))
+	require.NoError(t, NewInserter[TestModel](db).Values(
+		&TestModel{Id: 1, FirstName: "Tom", LastName: "a"},
+		&TestModel{Id: 2, FirstName: "jerry", LastName: "b"},
+		&TestModel{Id: 3, FirstName: "TOMMY", LastName: "c"},
+	).Exec(ctx).Err())
+	require.NoError(t, NewInserter[PredicateOrder](db).Values(
+		&PredicateOrder{Id: 1, UserId: 1, Amount: 50},
+		&PredicateOrder{Id: 2, UserId: 3, Amount: 5},
+	).Exec(ctx).Err())
+
+	ids := func(list []*TestModel) []int64 {
+		res := make([]int64, 0, len(list))
+		for _, m := range list {
+			res = append(res, m.Id)
+		}
+		return res
+	}
+
+	list, err := NewSelector[TestModel](db).Where(Col("FirstName").ILike("tom%")).OrderBy(Col("Id")).GetMulti(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1, 3}, ids(list))
+
+	list, err = NewSelector[TestModel](db).Where(Col("Id").In([]int64{2, 3})).OrderBy(Col("Id")).GetMulti(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{2, 3}, ids(list))
+
+	_, err = NewSelector[TestModel](db).Where(Col("Id").In()).GetMulti(ctx)
+	assert.Equal(t, ErrNoRows, err)
+
+	list, err = NewSelector[TestModel](db).Where(Col("Id").InQuery(
+		NewSelector[PredicateOrder](db).Select(Col("UserId")).Where(Col("Amount").Between(10, 100)),
+	)).GetMulti(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1}, ids(list))
+
+	u := TableOf(&TestModel{}).As("u")
+	o := TableOf(&PredicateOrder{}).As("o")
+	list, err = NewSelector[TestModel](db).From(u).Where(NotExists(
+		NewSelector[PredicateOrder](db).From(o).Where(o.Col("UserId").Eq(u.Col("Id"))),
+	)).GetMulti(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{2}, ids(list))
+}
@@ -0,0 +1,462 @@
+package orm
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"github.com/KNICEX/go-orm/internal/errs"
+	"github.com/KNICEX/go-orm/model"
+	"reflect"
+	"strings"
+)
+
+// Preload 预加载关联字段，多级关联使用 . 分隔，比如 Preload("Orders", "Orders.Items")
+// 每一级关联执行一次 IN 查询，参数超过方言的上限时分多次查询
+// 关联实体同样会调用 AfterFind
+func (s *Selector[T]) Preload(relations ...string) *Selector[T] {
+	s.preloads = append(s.preloads, relations...)
+	return s
+}
+
+func (s *Selector[T]) preload(ctx context.Context, entities ...*T) error {
+	if len(s.preloads) == 0 {
+		return nil
+	}
+	list := make([]any, 0, len(entities))
+	for _, e := range entities {
+		list = append(list, e)
+	}
+	return preload(ctx, s.sess, s.model, newPreloadTree(s.preloads), list)
+}
+
+// preloadNode 预加载路径组成的树，Orders.Items 会同时加载 Orders
+// 按第一次出现的顺序加载，保证查询顺序稳定
+type preloadNode struct {
+	name     string
+	children []*preloadNode
+}
+
+func (n *preloadNode) child(name string) *preloadNode {
+	for _, c := range n.children {
+		if c.name == name {
+			return c
+		}
+	}
+	c := &preloadNode{name: name}
+	n.children = append(n.children, c)
+	return c
+}
+
+func newPreloadTree(paths []string) []*preloadNode {
+	root := &preloadNode{}
+	for _, path := range paths {
+		node := root
+		for _, name := range strings.Split(path, ".") {
+			node = node.child(name)
+		}
+	}
+	return root.children
+}
+
+// preload 加载 entities 的关联字段，entities 为同一模型的结构体指针
+func preload(ctx context.Context, sess Session, m *model.Model, nodes []*preloadNode, entities []any) error {
+	if len(entities) == 0 {
+		return nil
+	}
+	for _, node := range nodes {
+		rel, ok := m.Relations[node.name]
+		if !ok {
+			return errs.NewErrUnknownField(node.name)
+		}
+		c := sess.getCore()
+		refModel, err := c.r.Get(reflect.New(rel.RefType).Interface())
+		if err != nil {
+			return err
+		}
+		var refs []any
+		var assign func() error
+		switch rel.Kind {
+		case model.HasOne, model.HasMany:
+			refs, assign, err = preloadHasMany(ctx, sess, m, refModel, rel, entities)
+		case model.BelongsTo:
+			refs, assign, err = preloadBelongsTo(ctx, sess, m, refModel, rel, entities)
+		case model.ManyToMany:
+			refs, assign, err = preloadManyToMany(ctx, sess, m, refModel, rel, entities)
+		}
+		if err != nil {
+			return err
+		}
+		// 关联实体加载完下一级并调用 AfterFind 之后再写回，关联字段不是指针时写回的是副本
+		if err = preload(ctx, sess, refModel, node.children, refs); err != nil {
+			return err
+		}
+		for _, r := range refs {
+			if h, ok := r.(AfterFindHook); ok {
+				if err = h.AfterFind(ctx); err != nil {
+					return err
+				}
+			}
+		}
+		if err = assign(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// preloadHasMany 按关联模型的外键查询，外键等于当前模型被引用字段的记录属于当前实体
+func preloadHasMany(ctx context.Context, sess Session, m, refModel *model.Model, rel *model.Relation, entities []any) ([]any, func() error, error) {
+	fk, ok := refModel.FieldMap[rel.ForeignKey]
+	if !ok {
+		return nil, nil, errs.NewErrInvalidRelation(rel.GoName)
+	}
+	vals, keys, err := fieldValues(sess, m, rel.References, entities)
+	if err != nil {
+		return nil, nil, err
+	}
+	refs, err := queryIn(ctx, sess, refModel, rel.RefType, fk.ColName, vals)
+	if err != nil {
+		return nil, nil, err
+	}
+	_, refKeys, err := fieldValues(sess, refModel, fk.GoName, refs)
+	if err != nil {
+		return nil, nil, err
+	}
+	grouped := make(map[string][]any, len(keys))
+	for i, ref := range refs {
+		grouped[refKeys[i]] = append(grouped[refKeys[i]], ref)
+	}
+	return refs, func() error {
+		return assignRelation(sess, m, rel, entities, keys, grouped)
+	}, nil
+}
+
+// preloadBelongsTo 按当前模型的外键查询关联模型被引用的字段
+func preloadBelongsTo(ctx context.Context, sess Session, m, refModel *model.Model, rel *model.Relation, entities []any) ([]any, func() error, error) {
+	ref, err := referenceField(refModel, rel)
+	if err != nil {
+		return nil, nil, err
+	}
+	vals, keys, err := fieldValues(sess, m, rel.ForeignKey, entities)
+	if err != nil {
+		return nil, nil, err
+	}
+	refs, err := queryIn(ctx, sess, refModel, rel.RefType, ref.ColName, vals)
+	if err != nil {
+		return nil, nil, err
+	}
+	_, refKeys, err := fieldValues(sess, refModel, ref.GoName, refs)
+	if err != nil {
+		return nil, nil, err
+	}
+	grouped := make(map[string][]any, len(refs))
+	for i, r := range refs {
+		grouped[refKeys[i]] = append(grouped[refKeys[i]], r)
+	}
+	return refs, func() error {
+		return assignRelation(sess, m, rel, entities, keys, grouped)
+	}, nil
+}
+
+// preloadManyToMany 先查询连接表，再按关联模型的主键查询
+func preloadManyToMany(ctx context.Context, sess Session, m, refModel *model.Model, rel *model.Relation, entities []any) ([]any, func() error, error) {
+	ref, err := referenceField(refModel, rel)
+	if err != nil {
+		return nil, nil, err
+	}
+	vals, keys, err := fieldValues(sess, m, rel.References, entities)
+	if err != nil {
+		return nil, nil, err
+	}
+	pairs, err := queryJoinTable(ctx, sess, m, rel, vals)
+	if err != nil {
+		return nil, nil, err
+	}
+	refKeyVals := make([]any, 0, len(pairs))
+	for _, p := range pairs {
+		refKeyVals = append(refKeyVals, p[1])
+	}
+	refs, err := queryIn(ctx, sess, refModel, rel.RefType, ref.ColName, refKeyVals)
+	if err != nil {
+		return nil, nil, err
+	}
+	_, refKeys, err := fieldValues(sess, refModel, ref.GoName, refs)
+	if err != nil {
+		return nil, nil, err
+	}
+	byKey := make(map[string]any, len(refs))
+	for i, r := range refs {
+		byKey[refKeys[i]] = r
+	}
+	grouped := make(map[string][]any, len(keys))
+	for _, p := range pairs {
+		if r, ok := byKey[keyOf(p[1])]; ok {
+			k := keyOf(p[0])
+			grouped[k] = append(grouped[k], r)
+		}
+	}
+	return refs, func() error {
+		return assignRelation(sess, m, rel, entities, keys, grouped)
+	}, nil
+}
+
+// referenceField belongs_to 和 many_to_many 关联模型中被引用的字段，默认为主键
+func referenceField(refModel *model.Model, rel *model.Relation) (*model.Field, error) {
+	name := rel.References
+	if rel.Kind == model.ManyToMany || name == "" {
+		if len(refModel.PrimaryKeys) != 1 {
+			return nil, errs.NewErrInvalidRelation(rel.GoName)
+		}
+		return refModel.PrimaryKeys[0], nil
+	}
+	fd, ok := refModel.FieldMap[name]
+	if !ok {
+		return nil, errs.NewErrInvalidRelation(rel.GoName)
+	}
+	return fd, nil
+}
+
+// assignRelation 将分组后的关联实体写回当前实体的关联字段，keys 与 entities 一一对应
+func assignRelation(sess Session, m *model.Model, rel *model.Relation, entities []any, keys []string, grouped map[string][]any) error {
+	c := sess.getCore()
+	many := rel.Kind == model.HasMany || rel.Kind == model.ManyToMany
+	for i, e := range entities {
+		refs := grouped[keys[i]]
+		var val reflect.Value
+		if many {
+			val = reflect.MakeSlice(rel.Typ, 0, len(refs))
+			for _, r := range refs {
+				val = reflect.Append(val, relationElem(rel.Typ.Elem(), r))
+			}
+		} else if len(refs) > 0 {
+			val = relationElem(rel.Typ, refs[0])
+		} else {
+			val = reflect.Zero(rel.Typ)
+		}
+		if err := c.creator(m, e).SetField(rel.GoName, val.Interface()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// relationElem 关联字段为结构体而不是指针时，复制结构体的值
+func relationElem(typ reflect.Type, ref any) reflect.Value {
+	val := reflect.ValueOf(ref)
+	if typ.Kind() != reflect.Pointer {
+		return val.Elem()
+	}
+	return val
+}
+
+// fieldValues 读取每个实体字段的值，以及匹配用的键，NULL 的键为空字符串
+func fieldValues(sess Session, m *model.Model, field string, entities []any) ([]any, []string, error) {
+	c := sess.getCore()
+	vals := make([]any, 0, len(entities))
+	keys := make([]string, 0, len(entities))
+	for _, e := range entities {
+		val, err := c.creator(m, e).Field(field)
+		if err != nil {
+			return nil, nil, err
+		}
+		vals = append(vals, val)
+		keys = append(keys, keyOf(val))
+	}
+	return vals, keys, nil
+}
+
+// keyOf 统一 int64, *int64, sql.NullInt64 和驱动返回的 []byte 等不同类型的值，用于匹配
+func keyOf(val any) string {
+	if v, ok := val.(driver.Valuer); ok {
+		var err error
+		if val, err = v.Value(); err != nil {
+			return ""
+		}
+	}
+	rv := reflect.ValueOf(val)
+	for rv.IsValid() && rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return ""
+		}
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() {
+		return ""
+	}
+	if b, ok := rv.Interface().([]byte); ok {
+		return string(b)
+	}
+	return fmt.Sprint(rv.Interface())
+}
+
+// uniqueValues 去重，并去掉 NULL
+func uniqueValues(vals []any) []any {
+	seen := make(map[string]struct{}, len(vals))
+	res := make([]any, 0, len(vals))
+	for _, v := range vals {
+		k := keyOf(v)
+		if k == "" {
+			continue
+		}
+		if _, ok := seen[k]; ok {
+			continue
+		}
+		seen[k] = struct{}{}
+		res = append(res, v)
+	}
+	return res
+}
+
+// queryIn 查询 col IN (vals) 的关联实体，返回结构体指针
+// 参数超过方言的上限时分多次查询
+func queryIn(ctx context.Context, sess Session, m *model.Model, typ reflect.Type, col string, vals []any) ([]any, error) {
+	var res []any
+	for _, keys := range chunkValues(sess, uniqueValues(vals)) {
+		c := sess.getCore()
+		c.model = m
+		q := &preloadQuery{
+			builder: builder{
+				core:   c,
+				quoter: c.dialect.quoter(),
+			},
+			col:  col,
+			keys: keys,
+		}
+		dest := reflect.New(reflect.SliceOf(reflect.PointerTo(typ)))
+		err := get(ctx, q, sess, c, SELECT, dest.Interface())
+		if errors.Is(err, ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		list := dest.Elem()
+		for i := 0; i < list.Len(); i++ {
+			res = append(res, list.Index(i).Interface())
+		}
+	}
+	return res, nil
+}
+
+// queryJoinTable 查询连接表，返回 [当前模型的键, 关联模型的键]
+// 和其他查询一样经过中间件，Context 中的 Model 为当前模型
+func queryJoinTable(ctx context.Context, sess Session, m *model.Model, rel *model.Relation, vals []any) ([][2]any, error) {
+	var res [][2]any
+	for _, keys := range chunkValues(sess, uniqueValues(vals)) {
+		c := sess.getCore()
+		b := &builder{
+			core:   c,
+			quoter: c.dialect.quoter(),
+		}
+		b.sb.WriteString("SELECT ")
+		b.quote(rel.JoinForeignKey)
+		b.sb.WriteByte(',')
+		b.quote(rel.JoinReferences)
+		b.sb.WriteString(" FROM ")
+		b.quote(rel.JoinTable)
+		b.sb.WriteString(" WHERE ")
+		b.quote(rel.JoinForeignKey)
+		b.buildInArgs(keys)
+		b.sb.WriteByte(';')
+
+		var root Handler = func(ctx *Context) *Result {
+			pairs, err := scanJoinTable(ctx, sess)
+			return &Result{
+				Res: pairs,
+				Err: err,
+			}
+		}
+		for i := len(c.middlewares) - 1; i >= 0; i-- {
+			root = c.middlewares[i](root)
+		}
+		r := root(&Context{
+			Type: SELECT,
+			Query: &Query{
+				SQL:  c.dialect.rebind(b.sb.String()),
+				Args: b.args,
+			},
+			Model: m,
+			Ctx:   ctx,
+		})
+		if r.Err != nil {
+			return nil, r.Err
+		}
+		pairs, _ := r.Res.([][2]any)
+		res = append(res, pairs...)
+	}
+	return res, nil
+}
+
+func scanJoinTable(ctx *Context, sess Session) ([][2]any, error) {
+	rows, err := sess.queryContext(ctx.Ctx, ctx.Query.SQL, ctx.Query.Args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var res [][2]any
+	for rows.Next() {
+		var p [2]any
+		if err = rows.Scan(&p[0], &p[1]); err != nil {
+			return nil, err
+		}
+		res = append(res, p)
+	}
+	return res, rows.Err()
+}
+
+// chunkValues 按方言的参数上限拆分 IN 的参数
+func chunkValues(sess Session, vals []any) [][]any {
+	if len(vals) == 0 {
+		return nil
+	}
+	size := sess.getCore().dialect.maxParams()
+	if size <= 0 || len(vals) <= size {
+		return [][]any{vals}
+	}
+	res := make([][]any, 0, (len(vals)+size-1)/size)
+	for len(vals) > size {
+		res = append(res, vals[:size])
+		vals = vals[size:]
+	}
+	return append(res, vals)
+}
+
+// preloadQuery 预加载关联实体的查询 SELECT * FROM table WHERE col IN (...)
+type preloadQuery struct {
+	builder
+	col  string
+	keys []any
+}
+
+func (p *preloadQuery) Build() (*Query, error) {
+	p.sb.WriteString("SELECT * FROM ")
+	p.quote(p.model.TableName)
+	p.sb.WriteString(" WHERE ")
+	p.quote(p.col)
+	p.buildInArgs(p.keys)
+	if scope, ok := p.softDeleteScope(nil); ok {
+		p.sb.WriteString(" AND ")
+		if err := p.buildExpression(scope); err != nil {
+			return nil, err
+		}
+	}
+	p.sb.WriteByte(';')
+	return &Query{
+		SQL:  p.dialect.rebind(p.sb.String()),
+		Args: p.args,
+	}, nil
+}
+
+// buildInArgs 构造 IN (?,?,?)
+func (b *builder) buildInArgs(args []any) {
+	b.sb.WriteString(" IN (")
+	for i, arg := range args {
+		if i > 0 {
+			b.sb.WriteByte(',')
+		}
+		b.sb.WriteByte('?')
+		b.addArgs(arg)
+	}
+	b.sb.WriteByte(')')
+}
@@ -0,0 +1,229 @@
+package orm
+
+import (
+	"context"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/KNICEX/go-orm/internal/errs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+type PreloadUser struct {
+	Id      int64
+	Name    string
+	Profile *PreloadProfile `orm:"has_one,foreign_key=UserId"`
+	Orders  []*PreloadOrder `orm:"has_many,foreign_key=UserId"`
+	Roles   []PreloadRole   `orm:"many_to_many=preload_user_role,join_foreign_key=user_id,join_references=role_id"`
+}
+
+type PreloadProfile struct {
+	Id     int64
+	UserId int64
+	Bio    string
+}
+
+type PreloadOrder struct {
+	Id         int64
+	UserId     int64
+	Amount     int64
+	CouponCode *string
+	User       *PreloadUser   `orm:"belongs_to"`
+	Items      []*PreloadItem `orm:"has_many,foreign_key=OrderId"`
+	Coupon     PreloadCoupon  `orm:"belongs_to,foreign_key=CouponCode,references=Code"`
+}
+
+type PreloadItem struct {
+	Id        int64
+	OrderId   int64
+	Sku       string
+	DeletedAt *time.Time
+}
+
+type PreloadCoupon struct {
+	Id   int64
+	Code string
+}
+
+type PreloadRole struct {
+	Id   int64
+	Name string
+}
+
+type PreloadUserRole struct {
+	UserId int64 `orm:"pk"`
+	RoleId int64 `orm:"pk"`
+}
+
+func TestSelector_Preload_SQLite(t *testing.T) {
+	db, err := Open("sqlite3", "file:preload.db?cache=shared&mode=memory", DBWithDialect(DialectSQLite3))
+	require.NoError(t, err)
+	ctx := context.Background()
+	require.NoError(t, db.AutoMigrate(ctx, &PreloadUser{}, &PreloadProfile{}, &PreloadOrder{},
+		&PreloadItem{}, &PreloadCoupon{}, &PreloadRole{}, &PreloadUserRole{}))
+
+	code := "NEW"
+	require.NoError(t, NewInserter[PreloadUser](db).Values(
+		&PreloadUser{Id: 1, Name: "tom"}, &PreloadUser{Id: 2, Name: "jerry"}, &PreloadUser{Id: 3, Name: "spike"}).Exec(ctx).Err())
+	require.NoError(t, NewInserter[PreloadProfile](db).Values(&PreloadProfile{Id: 1, UserId: 2, Bio: "mouse"}).Exec(ctx).Err())
+	require.NoError(t, NewInserter[PreloadOrder](db).Values(
+		&PreloadOrder{Id: 1, UserId: 1, Amount: 10, CouponCode: &code},
+		&PreloadOrder{Id: 2, UserId: 1, Amount: 20},
+		&PreloadOrder{Id: 3, UserId: 2, Amount: 30}).Exec(ctx).Err())
+	require.NoError(t, NewInserter[PreloadItem](db).Values(
+		&PreloadItem{Id: 1, OrderId: 1, Sku: "apple"},
+		&PreloadItem{Id: 2, OrderId: 1, Sku: "banana"},
+		&PreloadItem{Id: 3, OrderId: 3, Sku: "cheese"},
+		&PreloadItem{Id: 4, OrderId: 3, Sku: "deleted", DeletedAt: &time.Time{}}).Exec(ctx).Err())
+	require.NoError(t, NewInserter[PreloadCoupon](db).Values(&PreloadCoupon{Id: 1, Code: "NEW"}).Exec(ctx).Err())
+	require.NoError(t, NewInserter[PreloadRole](db).Values(&PreloadRole{Id: 1, Name: "admin"}, &PreloadRole{Id: 2, Name: "guest"}).Exec(ctx).Err())
+	require.NoError(t, NewInserter[PreloadUserRole](db).Values(
+		&PreloadUserRole{UserId: 1, RoleId: 1}, &PreloadUserRole{UserId: 1, RoleId: 2}, &PreloadUserRole{UserId: 2, RoleId: 2}).Exec(ctx).Err())
+
+	users, err := NewSelector[PreloadUser](db).
+		OrderBy(Col("Id").Asc()).
+		Preload("Profile", "Orders.Items", "Orders.Coupon", "Roles").
+		GetMulti(ctx)
+	require.NoError(t, err)
+	require.Len(t, users, 3)
+
+	tom, jerry, spike := users[0], users[1], users[2]
+	assert.Nil(t, tom.Profile)
+	require.NotNil(t, jerry.Profile)
+	assert.Equal(t, "mouse", jerry.Profile.Bio)
+
+	require.Len(t, tom.Orders, 2)
+	assert.Equal(t, []string{"apple", "banana"}, []string{tom.Orders[0].Items[0].Sku, tom.Orders[0].Items[1].Sku})
+	assert.Equal(t, "NEW", tom.Orders[0].Coupon.Code)
+	assert.Empty(t, tom.Orders[1].Items)
+	assert.Equal(t, PreloadCoupon{}, tom.Orders[1].Coupon)
+	require.Len(t, jerry.Orders, 1)
+	// 软删除的记录不会被预加载
+	require.Len(t, jerry.Orders[0].Items, 1)
+	assert.Equal(t, "cheese", jerry.Orders[0].Items[0].Sku)
+	assert.Empty(t, spike.Orders)
+
+	assert.Equal(t, []PreloadRole{{Id: 1, Name: "admin"}, {Id: 2, Name: "guest"}}, tom.Roles)
+	assert.Equal(t, []PreloadRole{{Id: 2, Name: "guest"}}, jerry.Roles)
+	assert.Empty(t, spike.Roles)
+
+	order, err := NewSelector[PreloadOrder](db).Where(Col("Id").Eq(3)).Preload("User.Roles").Get(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, order.User)
+	assert.Equal(t, "jerry", order.User.Name)
+	assert.Equal(t, []PreloadRole{{Id: 2, Name: "guest"}}, order.User.Roles)
+
+	_, err = NewSelector[PreloadUser](db).Preload("Unknown").GetMulti(ctx)
+	assert.Equal(t, errs.NewErrUnknownField("Unknown"), err)
+}
+
+func TestSelector_Preload_Query(t *testing.T) {
+	mockDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	db, err := OpenDB(mockDB, DBWithDialect(DialectPostgres))
+	require.NoError(t, err)
+
+	mock.ExpectQuery(`SELECT * FROM "preload_user";`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "tom").AddRow(2, "jerry"))
+	mock.ExpectQuery(`SELECT * FROM "preload_order" WHERE "user_id" IN ($1,$2);`).
+		WithArgs(int64(1), int64(2)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id"}).AddRow(1, 2).AddRow(2, 2).AddRow(3, 1))
+	mock.ExpectQuery(`SELECT * FROM "preload_item" WHERE "order_id" IN ($1,$2,$3) AND "deleted_at" IS NULL;`).
+		WithArgs(int64(1), int64(2), int64(3)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "order_id"}).AddRow(1, 3))
+
+	users, err := NewSelector[PreloadUser](db).Preload("Orders.Items").GetMulti(context.Background())
+	require.NoError(t, err)
+	require.Len(t, users[0].Orders, 1)
+	assert.Equal(t, int64(3), users[0].Orders[0].Id)
+	assert.Len(t, users[0].Orders[0].Items, 1)
+	assert.Len(t, users[1].Orders, 2)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// smallParamsDialect 参数上限为 2 的方言，用于测试拆分 IN 查询
+type smallParamsDialect struct {
+	*postgresDialect
+}
+
+func (s smallParamsDialect) maxParams() int {
+	return 2
+}
+
+func TestSelector_Preload_Chunk(t *testing.T) {
+	mockDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	var queries []string
+	db, err := OpenDB(mockDB, DBWithDialect(smallParamsDialect{postgresDialect: DialectPostgres}),
+		DBWithMiddlewares(func(next Handler) Handler {
+			return func(c *Context) *Result {
+				queries = append(queries, c.Query.SQL)
+				return next(c)
+			}
+		}))
+	require.NoError(t, err)
+
+	mock.ExpectQuery(`SELECT * FROM "preload_user";`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "tom").AddRow(2, "jerry").AddRow(3, "spike"))
+	mock.ExpectQuery(`SELECT "user_id","role_id" FROM "preload_user_role" WHERE "user_id" IN ($1,$2);`).
+		WithArgs(int64(1), int64(2)).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "role_id"}).AddRow(1, 1).AddRow(2, 2))
+	mock.ExpectQuery(`SELECT "user_id","role_id" FROM "preload_user_role" WHERE "user_id" IN ($1);`).
+		WithArgs(int64(3)).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "role_id"}).AddRow(3, 3))
+	mock.ExpectQuery(`SELECT * FROM "preload_role" WHERE "id" IN ($1,$2);`).
+		WithArgs(int64(1), int64(2)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "admin").AddRow(2, "guest"))
+	mock.ExpectQuery(`SELECT * FROM "preload_role" WHERE "id" IN ($1);`).
+		WithArgs(int64(3)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(3, "owner"))
+
+	users, err := NewSelector[PreloadUser](db).Preload("Roles").GetMulti(context.Background())
+	require.NoError(t, err)
+	require.Len(t, users, 3)
+	assert.Equal(t, []PreloadRole{{Id: 1, Name: "admin"}}, users[0].Roles)
+	assert.Equal(t, []PreloadRole{{Id: 2, Name: "guest"}}, users[1].Roles)
+	assert.Equal(t, []PreloadRole{{Id: 3, Name: "owner"}}, users[2].Roles)
+	// 连接表的查询同样经过中间件
+	assert.Equal(t, []string{
+		`SELECT * FROM "preload_user";`,
+		`SELECT "user_id","role_id" FROM "preload_user_role" WHERE "user_id" IN ($1,$2);`,
+		`SELECT "user_id","role_id" FROM "preload_user_role" WHERE "user_id" IN ($1);`,
+		`SELECT * FROM "preload_role" WHERE "id" IN ($1,$2);`,
+		`SELECT * FROM "preload_role" WHERE "id" IN ($1);`,
+	}, queries)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+type PreloadHookOwner struct {
+	Id    int64
+	Hooks []HookModel `orm:"has_many,foreign_key=Id"`
+}
+
+func TestSelector_Preload_AfterFind(t *testing.T) {
+	mockDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	db, err := OpenDB(mockDB, DBWithDialect(DialectPostgres))
+	require.NoError(t, err)
+
+	mock.ExpectQuery(`SELECT * FROM "preload_hook_owner" LIMIT 1;`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery(`SELECT * FROM "hook_model" WHERE "id" IN ($1);`).
+		WithArgs(int64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "tom"))
+	owner, err := NewSelector[PreloadHookOwner](db).Preload("Hooks").Get(context.Background())
+	require.NoError(t, err)
+	// 关联字段不是指针时，写回的副本也经过了 AfterFind
+	require.Len(t, owner.Hooks, 1)
+	assert.Equal(t, "found:tom", owner.Hooks[0].Name)
+
+	mock.ExpectQuery(`SELECT * FROM "preload_hook_owner";`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery(`SELECT * FROM "hook_model" WHERE "id" IN ($1);`).
+		WithArgs(int64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "bad"))
+	_, err = NewSelector[PreloadHookOwner](db).Preload("Hooks").GetMulti(context.Background())
+	assert.Equal(t, errInvalidName, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -44,6 +44,9 @@ func (r *RawQuerier[T]) Get(ctx context.Context) (*T, error) {
 		return nil, err
 
 	}
+	if err = afterFind(ctx, resEntity); err != nil {
+		return nil, err
+	}
 	return resEntity, nil
 }
 
@@ -60,5 +63,8 @@ func (r *RawQuerier[T]) GetMulti(ctx context.Context) ([]*T, error) {
 	if err != nil {
 		return nil, err
 	}
+	if err = afterFind(ctx, *resEntity...); err != nil {
+		return nil, err
+	}
 	return *resEntity, nil
 }
@@ -24,3 +24,25 @@ func (r ExecResult) RowsAffected() (int64, error) {
 func (r ExecResult) Err() error {
 	return r.err
 }
+
+// multiResult 分批执行的结果，RowsAffected 为各批之和，LastInsertId 为第一批的结果
+type multiResult []sql.Result
+
+func (m multiResult) LastInsertId() (int64, error) {
+	if len(m) == 0 {
+		return 0, nil
+	}
+	return m[0].LastInsertId()
+}
+
+func (m multiResult) RowsAffected() (int64, error) {
+	var res int64
+	for _, r := range m {
+		n, err := r.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		res += n
+	}
+	return res, nil
+}
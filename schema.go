@@ -0,0 +1,269 @@
+package orm
+
+import (
+	"context"
+	"github.com/KNICEX/go-orm/internal/errs"
+	"github.com/KNICEX/go-orm/model"
+	"strings"
+)
+
+// TableInfo 数据库中的表结构
+type TableInfo struct {
+	Name    string
+	Columns []ColumnInfo
+	Indexes []IndexInfo
+}
+
+// ColumnInfo 数据库中的列
+type ColumnInfo struct {
+	Name string
+	// 数据库返回的类型，比如 varchar(32)
+	Type     string
+	Nullable bool
+}
+
+// IndexInfo 数据库中除主键以外的索引
+type IndexInfo struct {
+	Name    string
+	Unique  bool
+	Columns []string
+}
+
+func (t *TableInfo) column(name string) (ColumnInfo, bool) {
+	for _, c := range t.Columns {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return ColumnInfo{}, false
+}
+
+func (t *TableInfo) index(name string) (IndexInfo, bool) {
+	for _, idx := range t.Indexes {
+		if idx.Name == name {
+			return idx, true
+		}
+	}
+	return IndexInfo{}, false
+}
+
+type DiffKind string
+
+const (
+	DiffMissingTable   DiffKind = "missing_table"
+	DiffMissingColumn  DiffKind = "missing_column"
+	DiffExtraColumn    DiffKind = "extra_column"
+	DiffColumnType     DiffKind = "column_type"
+	DiffColumnNullable DiffKind = "column_nullable"
+	DiffMissingIndex   DiffKind = "missing_index"
+	DiffExtraIndex     DiffKind = "extra_index"
+	DiffIndexChanged   DiffKind = "index_changed"
+)
+
+// SchemaDiff 模型和数据库表结构的一处差异
+type SchemaDiff struct {
+	Kind  DiffKind
+	Table string
+	// 列名或者索引名，表缺失时为空
+	Name string
+	// 模型中的定义，多出来的列和索引为空
+	Want string
+	// 数据库中的定义，缺失的表、列和索引为空
+	Got string
+}
+
+func (d SchemaDiff) String() string {
+	var sb strings.Builder
+	sb.WriteString(string(d.Kind))
+	sb.WriteByte(' ')
+	sb.WriteString(d.Table)
+	if d.Name != "" {
+		sb.WriteByte('.')
+		sb.WriteString(d.Name)
+	}
+	if d.Want != "" || d.Got != "" {
+		sb.WriteString(": want ")
+		sb.WriteString(d.Want)
+		sb.WriteString(", got ")
+		sb.WriteString(d.Got)
+	}
+	return sb.String()
+}
+
+// Inspect 读取 entity 对应的表结构，表不存在时返回错误
+func (m *Migrator) Inspect(ctx context.Context, entity any) (*TableInfo, error) {
+	md, err := m.r.Get(entity)
+	if err != nil {
+		return nil, err
+	}
+	exist, err := m.hasTable(ctx, md)
+	if err != nil {
+		return nil, err
+	}
+	if !exist {
+		return nil, errs.NewErrTableNotExist(md.TableName)
+	}
+	return m.inspect(ctx, md.TableName)
+}
+
+// Diff 对比模型和数据库中的表结构，没有差异时返回 nil
+// 列类型按方言统一形式后比较，只比较通过标签声明的索引
+func (m *Migrator) Diff(ctx context.Context, entities ...any) ([]SchemaDiff, error) {
+	var res []SchemaDiff
+	for _, entity := range entities {
+		md, err := m.r.Get(entity)
+		if err != nil {
+			return nil, err
+		}
+		exist, err := m.hasTable(ctx, md)
+		if err != nil {
+			return nil, err
+		}
+		if !exist {
+			res = append(res, SchemaDiff{Kind: DiffMissingTable, Table: md.TableName})
+			continue
+		}
+		info, err := m.inspect(ctx, md.TableName)
+		if err != nil {
+			return nil, err
+		}
+		diffs, err := m.diff(md, info)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, diffs...)
+	}
+	return res, nil
+}
+
+func (m *Migrator) diff(md *model.Model, info *TableInfo) ([]SchemaDiff, error) {
+	var res []SchemaDiff
+	for _, fd := range md.Fields {
+		want, err := m.columnOf(md, fd)
+		if err != nil {
+			return nil, err
+		}
+		got, ok := info.column(fd.ColName)
+		if !ok {
+			res = append(res, SchemaDiff{Kind: DiffMissingColumn, Table: md.TableName, Name: fd.ColName, Want: want.Type})
+			continue
+		}
+		if m.dialect.normalizeType(want.Type) != m.dialect.normalizeType(got.Type) {
+			res = append(res, SchemaDiff{Kind: DiffColumnType, Table: md.TableName, Name: fd.ColName, Want: want.Type, Got: got.Type})
+		}
+		if want.Nullable != got.Nullable {
+			res = append(res, SchemaDiff{Kind: DiffColumnNullable, Table: md.TableName, Name: fd.ColName,
+				Want: nullableString(want.Nullable), Got: nullableString(got.Nullable)})
+		}
+	}
+	for _, c := range info.Columns {
+		if _, ok := md.ColMap[c.Name]; !ok {
+			res = append(res, SchemaDiff{Kind: DiffExtraColumn, Table: md.TableName, Name: c.Name, Got: c.Type})
+		}
+	}
+
+	declared := make(map[string]struct{}, len(md.Indexes))
+	for _, idx := range md.Indexes {
+		declared[idx.Name] = struct{}{}
+		want := indexOf(idx)
+		got, ok := info.index(idx.Name)
+		if !ok {
+			res = append(res, SchemaDiff{Kind: DiffMissingIndex, Table: md.TableName, Name: idx.Name, Want: want.String()})
+			continue
+		}
+		if want.String() != got.String() {
+			res = append(res, SchemaDiff{Kind: DiffIndexChanged, Table: md.TableName, Name: idx.Name, Want: want.String(), Got: got.String()})
+		}
+	}
+	for _, idx := range info.Indexes {
+		if _, ok := declared[idx.Name]; !ok {
+			res = append(res, SchemaDiff{Kind: DiffExtraIndex, Table: md.TableName, Name: idx.Name, Got: idx.String()})
+		}
+	}
+	return res, nil
+}
+
+// columnOf 模型字段按 AutoMigrate 建表时的定义对应的列
+func (m *Migrator) columnOf(md *model.Model, fd *model.Field) (ColumnInfo, error) {
+	var typ string
+	if isAutoIncrementPK(md, fd) {
+		typ, _, _ = m.dialect.autoIncrement(fd)
+	} else {
+		typ = m.dialect.DataTypeOf(fd)
+	}
+	if typ == "" {
+		return ColumnInfo{}, errs.NewErrUnsupportedColumnType(fd.GoName, fd.Typ)
+	}
+	return ColumnInfo{
+		Name:     fd.ColName,
+		Type:     typ,
+		Nullable: columnNullable(fd),
+	}, nil
+}
+
+func indexOf(idx *model.Index) IndexInfo {
+	cols := make([]string, 0, len(idx.Fields))
+	for _, fd := range idx.Fields {
+		cols = append(cols, fd.ColName)
+	}
+	return IndexInfo{
+		Name:    idx.Name,
+		Unique:  idx.Unique,
+		Columns: cols,
+	}
+}
+
+func (i IndexInfo) String() string {
+	s := "(" + strings.Join(i.Columns, ",") + ")"
+	if i.Unique {
+		return "UNIQUE " + s
+	}
+	return s
+}
+
+func nullableString(nullable bool) string {
+	if nullable {
+		return "NULL"
+	}
+	return "NOT NULL"
+}
+
+func (m *Migrator) inspect(ctx context.Context, table string) (*TableInfo, error) {
+	res := &TableInfo{Name: table}
+	q := m.dialect.columnsSQL(table)
+	rows, err := m.sess.queryContext(ctx, m.dialect.rebind(q.SQL), q.Args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var c ColumnInfo
+		if err = rows.Scan(&c.Name, &c.Type, &c.Nullable); err != nil {
+			return nil, err
+		}
+		res.Columns = append(res.Columns, c)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	q = m.dialect.indexesSQL(table)
+	idxRows, err := m.sess.queryContext(ctx, m.dialect.rebind(q.SQL), q.Args...)
+	if err != nil {
+		return nil, err
+	}
+	defer idxRows.Close()
+	for idxRows.Next() {
+		var name, col string
+		var unique bool
+		if err = idxRows.Scan(&name, &unique, &col); err != nil {
+			return nil, err
+		}
+		if n := len(res.Indexes); n > 0 && res.Indexes[n-1].Name == name {
+			res.Indexes[n-1].Columns = append(res.Indexes[n-1].Columns, col)
+			continue
+		}
+		res.Indexes = append(res.Indexes, IndexInfo{Name: name, Unique: unique, Columns: []string{col}})
+	}
+	return res, idxRows.Err()
+}
@@ -0,0 +1,130 @@
+package orm
+
+import (
+	"context"
+	"database/sql"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/KNICEX/go-orm/internal/errs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+type DriftUser struct {
+	Id    int64
+	Name  string `orm:"index"`
+	Email string `orm:"unique_index"`
+}
+
+type DriftAccount struct {
+	Id        int64
+	Nickname  *string `orm:"size=32"`
+	Balance   sql.NullFloat64
+	Enabled   bool
+	CreatedAt time.Time
+}
+
+func TestMigrator_Diff_SQLite(t *testing.T) {
+	db, err := Open("sqlite3", "file:schema_diff.db?cache=shared&mode=memory", DBWithDialect(DialectSQLite3))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	for _, ddl := range []string{
+		"CREATE TABLE drift_user (id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT, name VARCHAR(32), legacy TEXT);",
+		"CREATE INDEX idx_legacy ON drift_user (legacy, name);",
+	} {
+		require.NoError(t, RawQuery[any](db, ddl).Exec(ctx).Err())
+	}
+
+	info, err := NewMigrator(db).Inspect(ctx, &DriftUser{})
+	require.NoError(t, err)
+	assert.Equal(t, &TableInfo{
+		Name: "drift_user",
+		Columns: []ColumnInfo{
+			{Name: "id", Type: "INTEGER"},
+			{Name: "name", Type: "VARCHAR(32)", Nullable: true},
+			{Name: "legacy", Type: "TEXT", Nullable: true},
+		},
+		Indexes: []IndexInfo{
+			{Name: "idx_legacy", Columns: []string{"legacy", "name"}},
+		},
+	}, info)
+	_, err = NewMigrator(db).Inspect(ctx, &DriftAccount{})
+	assert.Equal(t, errs.NewErrTableNotExist("drift_account"), err)
+
+	diffs, err := NewMigrator(db).Diff(ctx, &DriftUser{}, &DriftAccount{})
+	require.NoError(t, err)
+	assert.Equal(t, []SchemaDiff{
+		{Kind: DiffColumnType, Table: "drift_user", Name: "name", Want: "TEXT", Got: "VARCHAR(32)"},
+		{Kind: DiffColumnNullable, Table: "drift_user", Name: "name", Want: "NOT NULL", Got: "NULL"},
+		{Kind: DiffMissingColumn, Table: "drift_user", Name: "email", Want: "TEXT"},
+		{Kind: DiffExtraColumn, Table: "drift_user", Name: "legacy", Got: "TEXT"},
+		{Kind: DiffMissingIndex, Table: "drift_user", Name: "idx_drift_user_name", Want: "(name)"},
+		{Kind: DiffMissingIndex, Table: "drift_user", Name: "uk_drift_user_email", Want: "UNIQUE (email)"},
+		{Kind: DiffExtraIndex, Table: "drift_user", Name: "idx_legacy", Got: "(legacy,name)"},
+		{Kind: DiffMissingTable, Table: "drift_account"},
+	}, diffs)
+	assert.Equal(t, "column_type drift_user.name: want TEXT, got VARCHAR(32)", diffs[0].String())
+	assert.Equal(t, "missing_table drift_account", diffs[7].String())
+
+	// AutoMigrate 创建的表和模型没有差异
+	require.NoError(t, db.AutoMigrate(ctx, &DriftAccount{}))
+	diffs, err = NewMigrator(db).Diff(ctx, &DriftAccount{})
+	require.NoError(t, err)
+	assert.Empty(t, diffs)
+}
+
+func TestMigrator_Diff_MySQL(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	db, err := OpenDB(mockDB, DBWithDialect(DialectMySQL))
+	require.NoError(t, err)
+
+	mock.ExpectQuery("SELECT TABLE_NAME FROM information_schema.TABLES .*").
+		WithArgs("drift_account").
+		WillReturnRows(sqlmock.NewRows([]string{"TABLE_NAME"}).AddRow("drift_account"))
+	mock.ExpectQuery("SELECT COLUMN_NAME, COLUMN_TYPE, IS_NULLABLE = 'YES' FROM information_schema.COLUMNS .*").
+		WithArgs("drift_account").
+		WillReturnRows(sqlmock.NewRows([]string{"COLUMN_NAME", "COLUMN_TYPE", "NULLABLE"}).
+			AddRow("id", "bigint(20)", 0).
+			AddRow("nickname", "varchar(32)", 1).
+			AddRow("balance", "double", 1).
+			AddRow("enabled", "tinyint(1)", 0).
+			AddRow("created_at", "datetime(3)", 1))
+	mock.ExpectQuery("SELECT INDEX_NAME, NON_UNIQUE = 0, COLUMN_NAME FROM information_schema.STATISTICS .*").
+		WithArgs("drift_account").
+		WillReturnRows(sqlmock.NewRows([]string{"INDEX_NAME", "UNIQUE", "COLUMN_NAME"}).
+			AddRow("uk_nickname", 1, "nickname"))
+
+	diffs, err := NewMigrator(db).Diff(context.Background(), &DriftAccount{})
+	require.NoError(t, err)
+	assert.Equal(t, []SchemaDiff{
+		{Kind: DiffColumnType, Table: "drift_account", Name: "created_at", Want: "DATETIME", Got: "datetime(3)"},
+		{Kind: DiffExtraIndex, Table: "drift_account", Name: "uk_nickname", Got: "UNIQUE (nickname)"},
+	}, diffs)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDialect_normalizeType(t *testing.T) {
+	testCases := []struct {
+		name    string
+		dialect Dialect
+		want    string
+		got     string
+	}{
+		{name: "mysql boolean", dialect: DialectMySQL, want: "BOOLEAN", got: "tinyint(1)"},
+		{name: "mysql int width", dialect: DialectMySQL, want: "INT UNSIGNED", got: "int(10) unsigned"},
+		{name: "mysql varchar", dialect: DialectMySQL, want: "VARCHAR(255)", got: "varchar(255)"},
+		{name: "postgres varchar", dialect: DialectPostgres, want: "VARCHAR(32)", got: "character varying(32)"},
+		{name: "postgres serial", dialect: DialectPostgres, want: "BIGSERIAL", got: "bigint"},
+		{name: "postgres timestamptz", dialect: DialectPostgres, want: "TIMESTAMPTZ", got: "timestamp with time zone"},
+		{name: "postgres numeric", dialect: DialectPostgres, want: "DECIMAL(10, 2)", got: "numeric(10,2)"},
+		{name: "sqlite", dialect: DialectSQLite3, want: "TEXT", got: "text"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.dialect.normalizeType(tc.want), tc.dialect.normalizeType(tc.got))
+		})
+	}
+}
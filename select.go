@@ -2,7 +2,6 @@ package orm
 
 import (
 	"context"
-	"github.com/KNICEX/go-orm/internal/errs"
 )
 
 // Selectable 标记接口
@@ -30,6 +29,14 @@ type Selector[T any] struct {
 	count  bool
 	offset int
 	limit  int
+	// 不追加软删除过滤条件
+	unscoped bool
+	// 需要预加载的关联字段
+	preloads []string
+	// WITH 子句
+	ctes []cte
+	// 行锁
+	lock rowLock
 
 	builder
 	sess Session
@@ -47,12 +54,27 @@ func NewSelector[T any](sess Session) *Selector[T] {
 }
 
 func (s *Selector[T]) Build() (*Query, error) {
-	m, err := s.r.Register(new(T))
+	q, err := s.build()
+	if err != nil {
+		return nil, err
+	}
+	q.SQL = s.dialect.rebind(q.SQL)
+	return q, nil
+}
+
+func (s *Selector[T]) build() (*Query, error) {
+	m, err := s.r.Get(new(T))
 	if err != nil {
 		return nil, err
 	}
 	s.model = m
+	// 作为 CTE 或子查询时可能被多次构造
+	s.sb.Reset()
+	s.args = nil
 
+	if err = s.buildWith(s.ctes); err != nil {
+		return nil, err
+	}
 	s.sb.WriteString("SELECT ")
 
 	if s.count {
@@ -69,65 +91,23 @@ func (s *Selector[T]) Build() (*Query, error) {
 	}
 
 	// 条件构造
-	if len(s.where) > 0 {
+	where := s.whereWithScope(s.where, s.table, s.unscoped)
+	if len(where) > 0 {
 		s.sb.WriteString(" WHERE ")
-		if err := s.buildPredicate(s.where); err != nil {
+		if err := s.buildPredicate(where); err != nil {
 			return nil, err
 		}
 	}
 
-	// 排序
-	if len(s.orderBys) > 0 {
-		s.sb.WriteString(" ORDER BY ")
-		for i, ob := range s.orderBys {
-			if i > 0 {
-				s.sb.WriteByte(',')
-			}
-			switch o := ob.(type) {
-			case Column:
-				fd, ok := s.model.FieldMap[o.name]
-				if !ok {
-					return nil, errs.NewErrUnknownField(o.name)
-				}
-				s.quote(fd.ColName)
-				if o.desc {
-					s.sb.WriteString(" DESC")
-				} else {
-					s.sb.WriteString(" ASC")
-				}
-			case RawExpr:
-				s.sb.WriteByte('(')
-				s.sb.WriteString(o.raw)
-				s.sb.WriteByte(')')
-			}
-		}
+	// 分组，之后的子句可以引用查询列的别名
+	s.selected = s.columns
+	if err = s.buildGroupBy(s.groupBys, s.having); err != nil {
+		return nil, err
 	}
 
-	// 分组
-	if len(s.groupBys) > 0 {
-		s.sb.WriteString(" GROUP BY ")
-		for i, gb := range s.groupBys {
-			if i > 0 {
-				s.sb.WriteByte(',')
-			}
-			switch g := gb.(type) {
-			case Column:
-				if err = s.buildColumn(g); err != nil {
-					return nil, err
-				}
-			case RawExpr:
-				s.sb.WriteString(g.raw)
-			}
-		}
-
-		if len(s.having) > 0 {
-			// having
-			s.sb.WriteString(" HAVING ")
-			if err = s.buildPredicate(s.having); err != nil {
-				return nil, err
-			}
-		}
-
+	// 排序
+	if err = s.buildOrderBy(s.orderBys); err != nil {
+		return nil, err
 	}
 
 	// limit offset
@@ -135,6 +115,11 @@ func (s *Selector[T]) Build() (*Query, error) {
 		return nil, err
 	}
 
+	// 行锁
+	if err = s.buildLock(s.sess, s.lock); err != nil {
+		return nil, err
+	}
+
 	s.sb.WriteByte(';')
 	return &Query{
 		SQL:  s.sb.String(),
@@ -159,6 +144,7 @@ func (s *Selector[T]) AsSubQuery(alias string) SubQuery {
 	return SubQuery{
 		s:     s,
 		table: tab,
+		cols:  s.columns,
 		alias: alias,
 	}
 }
@@ -220,6 +206,12 @@ func (s *Selector[T]) Get(ctx context.Context) (*T, error) {
 	if err != nil {
 		return nil, err
 	}
+	if err = s.preload(ctx, resEntity); err != nil {
+		return nil, err
+	}
+	if err = afterFind(ctx, resEntity); err != nil {
+		return nil, err
+	}
 	return resEntity, nil
 }
 
@@ -229,6 +221,12 @@ func (s *Selector[T]) GetMulti(ctx context.Context) ([]*T, error) {
 	if err != nil {
 		return nil, err
 	}
+	if err = s.preload(ctx, *resEntity...); err != nil {
+		return nil, err
+	}
+	if err = afterFind(ctx, *resEntity...); err != nil {
+		return nil, err
+	}
 	return *resEntity, nil
 
 }
@@ -283,6 +281,12 @@ func (s *Selector[T]) Where(p Predicate) *Selector[T] {
 	return s
 }
 
+// Unscoped 查询包括已软删除的行
+func (s *Selector[T]) Unscoped() *Selector[T] {
+	s.unscoped = true
+	return s
+}
+
 func (s *Selector[T]) Offset(offset int) *Selector[T] {
 	s.offset = offset
 	return s
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/KNICEX/go-orm/internal/errs"
+	"github.com/KNICEX/go-orm/model"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"testing"
@@ -16,6 +17,26 @@ type TestModel struct {
 	LastName  string
 }
 
+// 构造语句使用已注册的模型，不会覆盖注册时的配置
+func TestSelector_RegisteredModel(t *testing.T) {
+	db, err := OpenDB(nil, DBWithDialect(DialectMySQL))
+	require.NoError(t, err)
+	_, err = db.r.Register(&TestModel{}, model.WithTableName("accounts"))
+	require.NoError(t, err)
+
+	q, err := NewSelector[TestModel](db).Where(Col("Id").Eq(1)).Build()
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM `accounts` WHERE `id` = ?;", q.SQL)
+
+	q, err = NewDeleter[TestModel](db).Where(Col("Id").Eq(1)).Build()
+	require.NoError(t, err)
+	assert.Equal(t, "DELETE FROM `accounts` WHERE `id` = ?;", q.SQL)
+
+	q, err = Union(NewSelector[TestModel](db), NewSelector[TestModel](db)).Build()
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM `accounts` UNION SELECT * FROM `accounts`;", q.SQL)
+}
+
 func TestSelector_Join(t *testing.T) {
 	db := memoryDB(t)
 	type Order struct {
@@ -268,6 +289,71 @@ func TestSelector_Build(t *testing.T) {
 	}
 }
 
+func TestSelector_Postgres_Build(t *testing.T) {
+	db, err := OpenDB(nil, DBWithDialect(DialectPostgres))
+	require.NoError(t, err)
+
+	type OrderDetail struct {
+		OrderId int
+		ItemId  int
+	}
+
+	testCases := []struct {
+		name      string
+		s         func() SqlBuilder
+		wantQuery *Query
+		wantErr   error
+	}{
+		{
+			name: "where",
+			s: func() SqlBuilder {
+				return NewSelector[TestModel](db).Where(Col("Id").Eq(18).Or(Col("LastName").Eq("hello"))).Limit(10)
+			},
+			wantQuery: &Query{
+				SQL:  `SELECT * FROM "test_model" WHERE ("id" = $1) OR ("last_name" = $2) LIMIT 10;`,
+				Args: []any{18, "hello"},
+			},
+		},
+		{
+			name: "raw with quoted question mark",
+			s: func() SqlBuilder {
+				return NewSelector[TestModel](db).Where(Raw("first_name = '?' AND id = ?", 1).AsPredicate())
+			},
+			wantQuery: &Query{
+				SQL:  `SELECT * FROM "test_model" WHERE (first_name = '?' AND id = $1);`,
+				Args: []any{1},
+			},
+		},
+		{
+			name: "sub query join",
+			s: func() SqlBuilder {
+				sub := NewSelector[OrderDetail](db).Where(Col("ItemId").Gt(3)).AsSubQuery("sub")
+				t1 := TableOf(&TestModel{}).As("t1")
+				return NewSelector[TestModel](db).Select(t1.Col("Id")).
+					From(t1.Join(sub).On(t1.Col("Id").Eq(sub.Col("OrderId")), sub.Col("ItemId").Lt(10))).
+					Where(t1.Col("FirstName").Eq("tom"))
+			},
+			wantQuery: &Query{
+				SQL: `SELECT "t1"."id" FROM ("test_model" AS "t1" INNER JOIN ` +
+					`(SELECT * FROM "order_detail" WHERE "item_id" > $1) AS "sub" ` +
+					`ON ("t1"."id" = "sub"."order_id") AND ("sub"."item_id" < $2)) WHERE "t1"."first_name" = $3;`,
+				Args: []any{3, 10, "tom"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			q, err := tc.s().Build()
+			assert.Equal(t, tc.wantErr, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.wantQuery, q)
+		})
+	}
+}
+
 // 必须一次运行所有测试用例，因为 mock.ExpectQuery() 会按照调用顺序匹配
 func TestSelector_Get(t *testing.T) {
 	mockDB, mock, err := sqlmock.New()
@@ -592,3 +678,18 @@ func TestSelector_SubQuery(t *testing.T) {
 		})
 	}
 }
+
+// GROUP BY 和 HAVING 需要在 ORDER BY 之前
+func TestSelector_GroupByOrderBy(t *testing.T) {
+	db, err := OpenDB(nil, DBWithDialect(DialectMySQL))
+	require.NoError(t, err)
+	q, err := NewSelector[TestModel](db).Select(Col("FirstName"), Sum("Id")).
+		GroupBy(Col("FirstName")).
+		Having(Sum("Id").Gt(1)).
+		OrderBy(Col("FirstName").Desc()).Build()
+	require.NoError(t, err)
+	assert.Equal(t, &Query{
+		SQL:  "SELECT `first_name`,SUM(`id`) FROM `test_model` GROUP BY `first_name` HAVING SUM(`id`) > ? ORDER BY `first_name` DESC;",
+		Args: []any{1},
+	}, q)
+}
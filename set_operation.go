@@ -0,0 +1,271 @@
+package orm
+
+import (
+	"context"
+	"github.com/KNICEX/go-orm/internal/errs"
+	"strings"
+)
+
+type setOp = string
+
+const (
+	setUnion     setOp = "UNION"
+	setIntersect setOp = "INTERSECT"
+	setExcept    setOp = "EXCEPT"
+)
+
+// setOperand 构造前可以校验的集合运算两边的查询
+type setOperand interface {
+	// columnCount 查询列数量，无法得知时返回 -1
+	columnCount() (int, error)
+	// checkOperand 检查不能出现在集合运算两边的子句
+	checkOperand() error
+}
+
+// SetSelector 集合运算 UNION, INTERSECT, EXCEPT
+// 参与运算的查询不能包含 ORDER BY, LIMIT, WITH 和行锁，排序和分页在集合运算的结果上进行
+// 用法： Union(s1, s2).All().OrderBy(Col("Id").Desc()).Limit(10)
+type SetSelector[T any] struct {
+	op      setOp
+	all     bool
+	first   *Selector[T]
+	queries []SqlBuilder
+
+	orderBys []OrderAble
+	offset   int
+	limit    int
+
+	builder
+	sess Session
+}
+
+// Union 合并查询结果并去重，调用 All 保留重复行
+func Union[T any](s *Selector[T], others ...SqlBuilder) *SetSelector[T] {
+	return newSetSelector(setUnion, s, others)
+}
+
+// Intersect 两边查询结果的交集
+func Intersect[T any](s *Selector[T], others ...SqlBuilder) *SetSelector[T] {
+	return newSetSelector(setIntersect, s, others)
+}
+
+// Except 在第一个查询结果中，但不在其他查询结果中的行
+func Except[T any](s *Selector[T], others ...SqlBuilder) *SetSelector[T] {
+	return newSetSelector(setExcept, s, others)
+}
+
+func newSetSelector[T any](op setOp, s *Selector[T], others []SqlBuilder) *SetSelector[T] {
+	queries := make([]SqlBuilder, 0, len(others)+1)
+	queries = append(queries, s)
+	queries = append(queries, others...)
+	return &SetSelector[T]{
+		op:      op,
+		first:   s,
+		queries: queries,
+		sess:    s.sess,
+		builder: builder{
+			core:   s.core,
+			quoter: s.quoter,
+		},
+	}
+}
+
+// All 保留重复行
+func (s *SetSelector[T]) All() *SetSelector[T] {
+	s.all = true
+	return s
+}
+
+// OrderBy 对集合运算的结果排序，只能引用第一个查询的列或者别名
+func (s *SetSelector[T]) OrderBy(orderBys ...OrderAble) *SetSelector[T] {
+	s.orderBys = orderBys
+	return s
+}
+
+func (s *SetSelector[T]) Offset(offset int) *SetSelector[T] {
+	s.offset = offset
+	return s
+}
+
+func (s *SetSelector[T]) Limit(limit int) *SetSelector[T] {
+	s.limit = limit
+	return s
+}
+
+func (s *SetSelector[T]) Build() (*Query, error) {
+	q, err := s.build()
+	if err != nil {
+		return nil, err
+	}
+	q.SQL = s.dialect.rebind(q.SQL)
+	return q, nil
+}
+
+func (s *SetSelector[T]) build() (*Query, error) {
+	m, err := s.r.Get(new(T))
+	if err != nil {
+		return nil, err
+	}
+	s.model = m
+
+	if err = s.buildSetOperation(s.op, s.all, s.queries); err != nil {
+		return nil, err
+	}
+
+	// 结果列的名字由第一个查询决定
+	s.selected = s.first.columns
+	if err = s.buildOrderBy(s.orderBys); err != nil {
+		return nil, err
+	}
+	if err = s.dialect.buildOffsetLimit(&s.builder, s.offset, s.limit); err != nil {
+		return nil, err
+	}
+
+	s.sb.WriteByte(';')
+	return &Query{
+		SQL:  s.sb.String(),
+		Args: s.args,
+	}, nil
+}
+
+func (s *SetSelector[T]) columnCount() (int, error) {
+	return s.first.columnCount()
+}
+
+// checkOperand 嵌套的集合运算不能排序和分页
+func (s *SetSelector[T]) checkOperand() error {
+	if len(s.orderBys) > 0 {
+		return errs.NewErrSetOperandClause("ORDER BY")
+	}
+	if s.offset > 0 || s.limit > 0 {
+		return errs.NewErrSetOperandClause("LIMIT")
+	}
+	return nil
+}
+
+func (s *SetSelector[T]) AsSubQuery(alias string) SubQuery {
+	sub := s.first.AsSubQuery(alias)
+	sub.s = s
+	return sub
+}
+
+func (s *SetSelector[T]) Get(ctx context.Context) (*T, error) {
+	s.limit = 1
+	resEntity := new(T)
+	if err := get(ctx, s, s.sess, s.core, SELECT, resEntity); err != nil {
+		return nil, err
+	}
+	if err := afterFind(ctx, resEntity); err != nil {
+		return nil, err
+	}
+	return resEntity, nil
+}
+
+func (s *SetSelector[T]) GetMulti(ctx context.Context) ([]*T, error) {
+	resEntity := new([]*T)
+	if err := get(ctx, s, s.sess, s.core, SELECT, resEntity); err != nil {
+		return nil, err
+	}
+	if err := afterFind(ctx, *resEntity...); err != nil {
+		return nil, err
+	}
+	return *resEntity, nil
+}
+
+// buildSetOperation 用 op 连接多个查询，参数按查询的顺序追加
+func (b *builder) buildSetOperation(op setOp, all bool, queries []SqlBuilder) error {
+	if !b.dialect.supportSetOp(op, all) {
+		return errs.NewErrUnsupportedSetOp(op, all)
+	}
+	want := -1
+	for i, query := range queries {
+		if so, ok := query.(setOperand); ok {
+			if err := so.checkOperand(); err != nil {
+				return err
+			}
+			cnt, err := so.columnCount()
+			if err != nil {
+				return err
+			}
+			if want < 0 {
+				want = cnt
+			} else if cnt >= 0 && cnt != want {
+				return errs.NewErrSetColumnCount(want, cnt)
+			}
+		}
+		if i > 0 {
+			b.sb.WriteByte(' ')
+			b.sb.WriteString(op)
+			if all {
+				b.sb.WriteString(" ALL")
+			}
+			b.sb.WriteByte(' ')
+		}
+		q, err := b.buildNested(query)
+		if err != nil {
+			return err
+		}
+		b.sb.WriteString(strings.TrimSuffix(q.SQL, ";"))
+		b.addArgs(q.Args...)
+	}
+	return nil
+}
+
+// columnCount 查询列数量，没有指定查询列时为表的字段数量
+func (s *Selector[T]) columnCount() (int, error) {
+	if s.count {
+		return 1, nil
+	}
+	if len(s.columns) == 0 {
+		var entity any = new(T)
+		switch t := s.table.(type) {
+		case nil:
+		case Table:
+			entity = t.entity
+		default:
+			// JOIN, 子查询和 CTE 的列无法得知
+			return -1, nil
+		}
+		m, err := s.r.Get(entity)
+		if err != nil {
+			return 0, err
+		}
+		return len(m.Fields), nil
+	}
+	for _, col := range s.columns {
+		// 原生表达式可能包含多列
+		if _, ok := col.(RawExpr); ok {
+			return -1, nil
+		}
+	}
+	return len(s.columns), nil
+}
+
+// checkOperand 排序, 分页, WITH 和行锁只能作用于整个集合运算
+func (s *Selector[T]) checkOperand() error {
+	switch {
+	case len(s.orderBys) > 0:
+		return errs.NewErrSetOperandClause("ORDER BY")
+	case s.offset > 0 || s.limit > 0:
+		return errs.NewErrSetOperandClause("LIMIT")
+	case len(s.ctes) > 0:
+		return errs.NewErrSetOperandClause("WITH")
+	case s.lock != rowLock{}:
+		return errs.NewErrSetOperandClause("FOR UPDATE or FOR SHARE")
+	}
+	return nil
+}
+
+func (s *standardSQL) supportSetOp(op setOp, all bool) bool {
+	return true
+}
+
+// supportSetOp MySQL 8.0.31 开始支持 INTERSECT 和 EXCEPT
+func (s *mysqlDialect) supportSetOp(op setOp, all bool) bool {
+	return op == setUnion || !s.v57
+}
+
+// supportSetOp SQLite 不支持 INTERSECT ALL 和 EXCEPT ALL
+func (s *sqlite3Dialect) supportSetOp(op setOp, all bool) bool {
+	return op == setUnion || !all
+}
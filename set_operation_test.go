@@ -0,0 +1,232 @@
+package orm
+
+import (
+	"context"
+	"github.com/KNICEX/go-orm/internal/errs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestSetSelector_Build(t *testing.T) {
+	db, err := OpenDB(nil, DBWithDialect(DialectMySQL))
+	require.NoError(t, err)
+	pg, err := OpenDB(nil, DBWithDialect(DialectPostgres))
+	require.NoError(t, err)
+	sqlite, err := OpenDB(nil, DBWithDialect(DialectSQLite3))
+	require.NoError(t, err)
+	mysql57, err := OpenDB(nil, DBWithDialect(DialectMySQL57))
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name      string
+		builder   SqlBuilder
+		wantQuery *Query
+		wantErr   error
+	}{
+		{
+			name: "union",
+			builder: Union(NewSelector[PredicateOrder](db).Where(Col("UserId").Eq(1)),
+				NewSelector[PredicateOrder](db).Where(Col("Amount").Gt(100))),
+			wantQuery: &Query{
+				SQL: "SELECT * FROM `predicate_order` WHERE `user_id` = ? " +
+					"UNION SELECT * FROM `predicate_order` WHERE `amount` > ?;",
+				Args: []any{1, 100},
+			},
+		},
+		{
+			name: "union all order by limit",
+			builder: Union(NewSelector[PredicateOrder](pg).Select(Col("Id"), Col("Amount").As("amt")).Where(Col("UserId").Eq(1)),
+				NewSelector[PredicateOrder](pg).Select(Col("Id"), Col("Amount")).Where(Col("UserId").Eq(2)),
+				NewSelector[PredicateOrder](pg).Select(Col("Id"), Col("Amount")).Where(Col("UserId").Eq(3))).
+				All().OrderBy(Col("amt").Desc(), Col("Id")).Offset(5).Limit(10),
+			wantQuery: &Query{
+				SQL: `SELECT "id","amount" AS "amt" FROM "predicate_order" WHERE "user_id" = $1 ` +
+					`UNION ALL SELECT "id","amount" FROM "predicate_order" WHERE "user_id" = $2 ` +
+					`UNION ALL SELECT "id","amount" FROM "predicate_order" WHERE "user_id" = $3 ` +
+					`ORDER BY "amt" DESC,"id" ASC LIMIT 10 OFFSET 5;`,
+				Args: []any{1, 2, 3},
+			},
+		},
+		{
+			name: "intersect",
+			builder: Intersect(NewSelector[PredicateOrder](sqlite).Select(Col("UserId")),
+				NewSelector[PredicateOrder](sqlite).Select(Col("UserId")).Where(Col("Amount").Gt(100))),
+			wantQuery: &Query{
+				SQL: "SELECT `user_id` FROM `predicate_order` " +
+					"INTERSECT SELECT `user_id` FROM `predicate_order` WHERE `amount` > ?;",
+				Args: []any{100},
+			},
+		},
+		{
+			name: "except all",
+			builder: Except(NewSelector[PredicateOrder](pg).Select(Col("UserId")),
+				NewSelector[PredicateOrder](pg).Select(Col("UserId")).Where(Col("Amount").Gt(100))).All(),
+			wantQuery: &Query{
+				SQL: `SELECT "user_id" FROM "predicate_order" ` +
+					`EXCEPT ALL SELECT "user_id" FROM "predicate_order" WHERE "amount" > $1;`,
+				Args: []any{100},
+			},
+		},
+		{
+			name: "as sub query",
+			builder: func() SqlBuilder {
+				sub := Union(NewSelector[PredicateOrder](pg).Select(Col("UserId"), Col("Amount")).Where(Col("Amount").Gt(1)),
+					NewSelector[PredicateOrder](pg).Select(Col("UserId"), Col("Amount")).Where(Col("Amount").Lt(-1))).
+					All().AsSubQuery("t")
+				return NewSelector[PredicateOrder](pg).Select(sub.Col("UserId"), sub.Sum("Amount").As("total")).
+					From(sub).GroupBy(sub.Col("UserId")).Having(Col("total").Gt(10))
+			}(),
+			wantQuery: &Query{
+				SQL: `SELECT "t"."user_id",SUM("t"."amount") AS "total" FROM (` +
+					`SELECT "user_id","amount" FROM "predicate_order" WHERE "amount" > $1 ` +
+					`UNION ALL SELECT "user_id","amount" FROM "predicate_order" WHERE "amount" < $2) AS "t" ` +
+					`GROUP BY "t"."user_id" HAVING SUM("t"."amount") > $3;`,
+				Args: []any{1, -1, 10},
+			},
+		},
+		{
+			name: "raw operand",
+			builder: Union(NewSelector[PredicateOrder](db).Select(Col("Id")),
+				RawQuery[PredicateOrder](db, "SELECT `id` FROM `order_archive` WHERE `amount` > ?", 1)),
+			wantQuery: &Query{
+				SQL:  "SELECT `id` FROM `predicate_order` UNION SELECT `id` FROM `order_archive` WHERE `amount` > ?;",
+				Args: []any{1},
+			},
+		},
+		{
+			name: "column count mismatch",
+			builder: Union(NewSelector[PredicateOrder](db).Select(Col("Id"), Col("UserId")),
+				NewSelector[PredicateOrder](db).Select(Col("Id"))),
+			wantErr: errs.NewErrSetColumnCount(2, 1),
+		},
+		{
+			name: "column count mismatch with star",
+			builder: Union(NewSelector[PredicateOrder](db),
+				NewSelector[PredicateOrder](db).Select(Col("Id"))),
+			wantErr: errs.NewErrSetColumnCount(3, 1),
+		},
+		{
+			name: "star from other table",
+			builder: Union(NewSelector[PredicateOrder](db).From(TableOf(&JoinOrder{})),
+				NewSelector[JoinOrder](db)),
+			wantQuery: &Query{
+				SQL: "SELECT * FROM `join_order` UNION SELECT * FROM `join_order`;",
+			},
+		},
+		{
+			name: "star from sub query",
+			builder: func() SqlBuilder {
+				sub := NewSelector[PredicateOrder](db).Select(Col("Id")).AsSubQuery("t")
+				return Union(NewSelector[PredicateOrder](db).From(sub),
+					NewSelector[PredicateOrder](db).Select(Col("Id")))
+			}(),
+			wantQuery: &Query{
+				SQL: "SELECT * FROM (SELECT `id` FROM `predicate_order`) AS `t` UNION SELECT `id` FROM `predicate_order`;",
+			},
+		},
+		{
+			name: "operand order by",
+			builder: Union(NewSelector[PredicateOrder](db).Select(Col("Id")),
+				NewSelector[PredicateOrder](db).Select(Col("Id")).OrderBy(Col("Id").Desc())),
+			wantErr: errs.NewErrSetOperandClause("ORDER BY"),
+		},
+		{
+			name: "operand limit",
+			builder: Union(NewSelector[PredicateOrder](db).Select(Col("Id")).Offset(10),
+				NewSelector[PredicateOrder](db).Select(Col("Id"))),
+			wantErr: errs.NewErrSetOperandClause("LIMIT"),
+		},
+		{
+			name: "operand with",
+			builder: Union(NewSelector[PredicateOrder](db).Select(Col("Id")),
+				NewSelector[PredicateOrder](db).Select(Col("Id")).
+					With("big", NewSelector[PredicateOrder](db).Where(Col("Amount").Gt(100)))),
+			wantErr: errs.NewErrSetOperandClause("WITH"),
+		},
+		{
+			name: "operand lock",
+			builder: Union(NewSelector[PredicateOrder](db).Select(Col("Id")).ForUpdate(),
+				NewSelector[PredicateOrder](db).Select(Col("Id"))),
+			wantErr: errs.NewErrSetOperandClause("FOR UPDATE or FOR SHARE"),
+		},
+		{
+			name: "operand set selector limit",
+			builder: Union(NewSelector[PredicateOrder](db).Select(Col("Id")),
+				Union(NewSelector[PredicateOrder](db).Select(Col("Id")),
+					NewSelector[PredicateOrder](db).Select(Col("Id"))).Limit(1)),
+			wantErr: errs.NewErrSetOperandClause("LIMIT"),
+		},
+		{
+			name: "unsupported intersect",
+			builder: Intersect(NewSelector[PredicateOrder](mysql57).Select(Col("Id")),
+				NewSelector[PredicateOrder](mysql57).Select(Col("Id"))),
+			wantErr: errs.NewErrUnsupportedSetOp("INTERSECT", false),
+		},
+		{
+			name: "unsupported except all",
+			builder: Except(NewSelector[PredicateOrder](sqlite).Select(Col("Id")),
+				NewSelector[PredicateOrder](sqlite).Select(Col("Id"))).All(),
+			wantErr: errs.NewErrUnsupportedSetOp("EXCEPT", true),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			q, err := tc.builder.Build()
+			assert.Equal(t, tc.wantErr, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.wantQuery, q)
+		})
+	}
+}
+
+func TestSetSelector_SQLite(t *testing.T) {
+	db := memoryWithDB("set_operation", t, DBWithDialect(DialectSQLite3))
+	ctx := context.Background()
+	require.NoError(t, db.AutoMigrate(ctx, &PredicateOrder{}, &CteCategory{}))
+	require.NoError(t, NewInserter[PredicateOrder](db).Values(
+		&PredicateOrder{Id: 1, UserId: 1, Amount: 10},
+		&PredicateOrder{Id: 2, UserId: 1, Amount: 300},
+		&PredicateOrder{Id: 3, UserId: 2, Amount: 200},
+		&PredicateOrder{Id: 4, UserId: 3, Amount: 5},
+	).Exec(ctx).Err())
+
+	list, err := Union(NewSelector[PredicateOrder](db).Where(Col("UserId").Eq(1)),
+		NewSelector[PredicateOrder](db).Where(Col("Amount").Gt(100))).
+		OrderBy(Col("Amount").Desc()).GetMulti(ctx)
+	require.NoError(t, err)
+	ids := make([]int64, 0, len(list))
+	for _, o := range list {
+		ids = append(ids, o.Id)
+	}
+	assert.Equal(t, []int64{2, 3, 1}, ids)
+
+	o, err := Except(NewSelector[PredicateOrder](db),
+		NewSelector[PredicateOrder](db).Where(Col("UserId").Lt(3))).Get(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &PredicateOrder{Id: 4, UserId: 3, Amount: 5}, o)
+
+	// 递归 CTE，查询 id = 2 的所有子孙节点
+	require.NoError(t, NewInserter[CteCategory](db).Values(
+		&CteCategory{Id: 1, ParentId: 0, Name: "root"},
+		&CteCategory{Id: 2, ParentId: 1, Name: "a"},
+		&CteCategory{Id: 3, ParentId: 2, Name: "b"},
+		&CteCategory{Id: 4, ParentId: 3, Name: "c"},
+	).Exec(ctx).Err())
+	tree := CTE("tree")
+	c := TableOf(&CteCategory{}).As("c")
+	cats, err := NewSelector[CteCategory](db).
+		WithRecursive("tree", Union(NewSelector[CteCategory](db).Where(Col("Id").Eq(2)),
+			NewSelector[CteCategory](db).Select(c.Col("Id"), c.Col("ParentId"), c.Col("Name")).
+				From(c.Join(tree).On(c.Col("ParentId").Eq(tree.Col("Id"))))).All()).
+		From(tree).OrderBy(tree.Col("Id")).GetMulti(ctx)
+	require.NoError(t, err)
+	names := make([]string, 0, len(cats))
+	for _, cat := range cats {
+		names = append(names, cat.Name)
+	}
+	assert.Equal(t, []string{"a", "b", "c"}, names)
+}
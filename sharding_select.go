@@ -5,7 +5,6 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
-	"github.com/KNICEX/go-orm/internal/errs"
 	"golang.org/x/sync/errgroup"
 )
 
@@ -114,13 +113,14 @@ func (s *ShardingSelector[T]) findDstByPredicate(p Predicate) ([]Dst, error) {
 	case opIn:
 	case opNotIn:
 	case opLike:
+	case opNeq, opNotLike, opILike, opBetween, opNotBetween, opIsNull, opIsNotNull:
 	default:
 		return nil, errors.New("orm: unsupported sharding select operator")
 	}
 	return res, nil
 }
 func (s *ShardingSelector[T]) Build() ([]*Query, error) {
-	m, err := s.r.Register(new(T))
+	m, err := s.r.Get(new(T))
 	if err != nil {
 		return nil, err
 	}
@@ -143,6 +143,9 @@ func (s *ShardingSelector[T]) Build() ([]*Query, error) {
 }
 func (s *ShardingSelector[T]) build(database, table string) (*Query, error) {
 	var err error
+	// 每张表都重新构造
+	s.sb.Reset()
+	s.args = nil
 	s.sb.WriteString("SELECT ")
 
 	if s.count {
@@ -164,58 +167,15 @@ func (s *ShardingSelector[T]) build(database, table string) (*Query, error) {
 		}
 	}
 
-	// 排序
-	if len(s.orderBys) > 0 {
-		s.sb.WriteString(" ORDER BY ")
-		for i, ob := range s.orderBys {
-			if i > 0 {
-				s.sb.WriteByte(',')
-			}
-			switch o := ob.(type) {
-			case Column:
-				fd, ok := s.model.FieldMap[o.name]
-				if !ok {
-					return nil, errs.NewErrUnknownField(o.name)
-				}
-				s.quote(fd.ColName)
-				if o.desc {
-					s.sb.WriteString(" DESC")
-				} else {
-					s.sb.WriteString(" ASC")
-				}
-			case RawExpr:
-				s.sb.WriteByte('(')
-				s.sb.WriteString(o.raw)
-				s.sb.WriteByte(')')
-			}
-		}
+	// 分组，之后的子句可以引用查询列的别名
+	s.selected = s.columns
+	if err = s.buildGroupBy(s.groupBys, s.having); err != nil {
+		return nil, err
 	}
 
-	// 分组
-	if len(s.groupBys) > 0 {
-		s.sb.WriteString(" GROUP BY ")
-		for i, gb := range s.groupBys {
-			if i > 0 {
-				s.sb.WriteByte(',')
-			}
-			switch g := gb.(type) {
-			case Column:
-				if err = s.buildColumn(g); err != nil {
-					return nil, err
-				}
-			case RawExpr:
-				s.sb.WriteString(g.raw)
-			}
-		}
-
-		if len(s.having) > 0 {
-			// having
-			s.sb.WriteString(" HAVING ")
-			if err = s.buildPredicate(s.having); err != nil {
-				return nil, err
-			}
-		}
-
+	// 排序
+	if err = s.buildOrderBy(s.orderBys); err != nil {
+		return nil, err
 	}
 
 	// limit offset
@@ -267,3 +227,129 @@ func (s *ShardingSelector[T]) Get(ctx context.Context) (T, error) {
 	}
 	return res, nil
 }
+
+// shardTable 分库中的一张表，作为集合运算的查询
+type shardTable[T any] struct {
+	s        *ShardingSelector[T]
+	database string
+	table    string
+}
+
+func (t shardTable[T]) Build() (*Query, error) {
+	return t.s.build(t.database, t.table)
+}
+
+// BuildMerged 同一个库中的多张表用 UNION ALL 合并为一条查询
+// 排序和分页在合并后的结果上进行
+func (s *ShardingSelector[T]) BuildMerged() ([]*Query, error) {
+	// 使用已注册的模型，保留分表函数
+	m, err := s.r.Get(new(T))
+	if err != nil {
+		return nil, err
+	}
+	s.model = m
+
+	dst, err := s.findDst()
+	if err != nil {
+		return nil, err
+	}
+	// 按库分组，保持目标表出现的顺序
+	databases := make([]string, 0, len(dst))
+	tables := make(map[string][]string, len(dst))
+	for _, d := range dst {
+		if _, ok := tables[d.Database]; !ok {
+			databases = append(databases, d.Database)
+		}
+		tables[d.Database] = append(tables[d.Database], d.Table)
+	}
+	queries := make([]*Query, 0, len(databases))
+	for _, database := range databases {
+		q, err := s.buildMerged(database, tables[database])
+		if err != nil {
+			return nil, err
+		}
+		queries = append(queries, q)
+	}
+	return queries, nil
+}
+
+func (s *ShardingSelector[T]) buildMerged(database string, tables []string) (*Query, error) {
+	// 参与合并的查询不能包含排序和分页
+	member := &ShardingSelector[T]{
+		table:    s.table,
+		where:    s.where,
+		columns:  s.columns,
+		groupBys: s.groupBys,
+		having:   s.having,
+		count:    s.count,
+		builder: builder{
+			core:   s.core,
+			quoter: s.quoter,
+		},
+		db: s.db,
+	}
+	members := make([]SqlBuilder, 0, len(tables))
+	for _, tbl := range tables {
+		members = append(members, shardTable[T]{s: member, database: database, table: tbl})
+	}
+
+	b := &builder{
+		core:   s.core,
+		quoter: s.quoter,
+	}
+	if err := b.buildSetOperation(setUnion, true, members); err != nil {
+		return nil, err
+	}
+	b.selected = s.columns
+	if err := b.buildOrderBy(s.orderBys); err != nil {
+		return nil, err
+	}
+	if err := b.dialect.buildOffsetLimit(b, s.offset, s.limit); err != nil {
+		return nil, err
+	}
+	b.sb.WriteByte(';')
+	return &Query{
+		SQL:      b.dialect.rebind(b.sb.String()),
+		Args:     b.args,
+		Database: database,
+	}, nil
+}
+
+// GetMulti 每个库执行一条合并后的查询，按库的顺序拼接结果
+// 跨库时无法保证整体的排序和分页，返回错误
+func (s *ShardingSelector[T]) GetMulti(ctx context.Context) ([]*T, error) {
+	qs, err := s.BuildMerged()
+	if err != nil {
+		return nil, err
+	}
+	if len(qs) > 1 && (len(s.orderBys) > 0 || s.offset > 0 || s.limit > 0) {
+		return nil, errors.New("orm: ORDER BY, OFFSET and LIMIT across databases are not supported")
+	}
+	eg := errgroup.Group{}
+	lists := make([][]*T, len(qs))
+	for i, q := range qs {
+		eg.Go(func() error {
+			db, ok := s.db.Shards[q.Database]
+			if !ok {
+				return errors.New("orm: unknown database")
+			}
+			rows, err := db.queryContext(ctx, q.SQL, q.Args...)
+			if err != nil {
+				return err
+			}
+			defer rows.Close()
+			if !rows.Next() {
+				return rows.Err()
+			}
+			return s.core.creator(s.core.model, &lists[i]).SetColumns(rows)
+		})
+	}
+	if err = eg.Wait(); err != nil {
+		return nil, err
+	}
+	res := make([]*T, 0, len(qs))
+	for _, list := range lists {
+		res = append(res, list...)
+	}
+	return res, nil
+}
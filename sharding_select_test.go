@@ -1,7 +1,12 @@
 package orm
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/KNICEX/go-orm/internal/valuer"
 	"github.com/KNICEX/go-orm/model"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -84,3 +89,67 @@ func TestShardingSelector_findDstByPredicate(t *testing.T) {
 		})
 	}
 }
+
+func TestShardingSelector_GetMulti(t *testing.T) {
+	mockDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	r := model.NewRegistry()
+	m, err := r.Get(&Order{})
+	require.NoError(t, err)
+	m.Sf = func(sk map[string]any) (database string, table string) {
+		uid := sk["UserId"].(int)
+		return fmt.Sprintf("order_db_%d", uid/100), fmt.Sprintf("order_table_%d", uid%10)
+	}
+	m.Sks = map[string]struct{}{
+		"UserId": {},
+	}
+	newSelector := func() *ShardingSelector[Order] {
+		return &ShardingSelector[Order]{
+			builder: builder{
+				core: &core{
+					r:       r,
+					dialect: DialectMySQL,
+					creator: valuer.NewUnsafeValue,
+				},
+				quoter: '`',
+			},
+			db: &ShardingDB{
+				Shards: map[string]*MasterSlaveDB{
+					"order_db_0": {Slaves: []*sql.DB{mockDB}},
+					"order_db_2": {Slaves: []*sql.DB{mockDB}},
+				},
+			},
+		}
+	}
+
+	// 同一个库的两张表合并为一条查询
+	s := newSelector()
+	s.where = []Predicate{Col("UserId").Eq(11).And(Col("UserId").Eq(13))}
+	s.orderBys = []OrderAble{Col("UserId").Desc()}
+	s.limit = 10
+	qs, err := s.BuildMerged()
+	require.NoError(t, err)
+	assert.Equal(t, []*Query{
+		{
+			SQL: "SELECT * FROM order_db_0.order_table_1 WHERE (`user_id` = ?) AND (`user_id` = ?) " +
+				"UNION ALL SELECT * FROM order_db_0.order_table_3 WHERE (`user_id` = ?) AND (`user_id` = ?) " +
+				"ORDER BY `user_id` DESC LIMIT 10;",
+			Args:     []any{11, 13, 11, 13},
+			Database: "order_db_0",
+		},
+	}, qs)
+
+	mock.ExpectQuery(qs[0].SQL).WithArgs(11, 13, 11, 13).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(13).AddRow(11))
+	list, err := s.GetMulti(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []*Order{{UserId: 13}, {UserId: 11}}, list)
+
+	// 跨库不支持排序
+	s = newSelector()
+	s.where = []Predicate{Col("UserId").Eq(11).And(Col("UserId").Eq(222))}
+	s.orderBys = []OrderAble{Col("UserId")}
+	_, err = s.GetMulti(context.Background())
+	assert.Equal(t, errors.New("orm: ORDER BY, OFFSET and LIMIT across databases are not supported"), err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
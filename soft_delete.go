@@ -0,0 +1,81 @@
+package orm
+
+import (
+	"database/sql"
+	"github.com/KNICEX/go-orm/model"
+	"reflect"
+	"time"
+)
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	timePtrType  = reflect.TypeOf(&time.Time{})
+	nullTimeType = reflect.TypeOf(sql.NullTime{})
+)
+
+// softDeleteScope 软删除过滤条件
+// 未删除的行软删除字段为 NULL，整数类型的软删除字段为 0
+// 只对查询当前模型的表生效，JOIN 和子查询需要自行添加条件
+func (b *builder) softDeleteScope(table TableReference) (Predicate, bool) {
+	fd := b.model.SoftDelete
+	if fd == nil {
+		return Predicate{}, false
+	}
+	var col Column
+	switch t := table.(type) {
+	case nil:
+		col = Col(fd.GoName)
+	case Table:
+		m, err := b.r.Get(t.entity)
+		if err != nil || m.TableName != b.model.TableName {
+			return Predicate{}, false
+		}
+		col = t.Col(fd.GoName)
+	default:
+		return Predicate{}, false
+	}
+	if isIntegerType(fd.Typ) {
+		return col.Eq(0), true
+	}
+	return col.IsNull(), true
+}
+
+// whereWithScope 在用户条件后追加软删除过滤条件
+func (b *builder) whereWithScope(where []Predicate, table TableReference, unscoped bool) []Predicate {
+	if unscoped {
+		return where
+	}
+	p, ok := b.softDeleteScope(table)
+	if !ok {
+		return where
+	}
+	res := make([]Predicate, 0, len(where)+1)
+	res = append(res, where...)
+	return append(res, p)
+}
+
+// softDeleteValue 软删除时写入的值
+func softDeleteValue(fd *model.Field, now time.Time) any {
+	switch fd.Typ {
+	case timeType:
+		return now
+	case timePtrType:
+		return &now
+	case nullTimeType:
+		return sql.NullTime{Time: now, Valid: true}
+	}
+	if isIntegerType(fd.Typ) {
+		return reflect.ValueOf(now.Unix()).Convert(fd.Typ).Interface()
+	}
+	return now
+}
+
+func isIntegerType(typ reflect.Type) bool {
+	switch typ.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,146 @@
+package orm
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+type SoftDeleteModel struct {
+	Id        int64
+	Name      string
+	DeletedAt *time.Time
+}
+
+type SoftDeleteUnixModel struct {
+	Id      int64
+	Name    string
+	Removed int64 `orm:"soft_delete"`
+}
+
+func TestSoftDelete_Build(t *testing.T) {
+	db, err := OpenDB(nil, DBWithDialect(DialectMySQL))
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name      string
+		builder   SqlBuilder
+		wantQuery *Query
+		wantErr   error
+	}{
+		{
+			name:    "select",
+			builder: NewSelector[SoftDeleteModel](db).Where(Col("Id").Eq(1)),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `soft_delete_model` WHERE (`id` = ?) AND (`deleted_at` IS NULL);",
+				Args: []any{1},
+			},
+		},
+		{
+			name:    "select without where",
+			builder: NewSelector[SoftDeleteModel](db),
+			wantQuery: &Query{
+				SQL: "SELECT * FROM `soft_delete_model` WHERE `deleted_at` IS NULL;",
+			},
+		},
+		{
+			name:    "select table alias",
+			builder: NewSelector[SoftDeleteModel](db).From(TableOf(&SoftDeleteModel{}).As("t1")),
+			wantQuery: &Query{
+				SQL: "SELECT * FROM `soft_delete_model` AS `t1` WHERE `t1`.`deleted_at` IS NULL;",
+			},
+		},
+		{
+			name: "select join",
+			builder: func() SqlBuilder {
+				t1 := TableOf(&SoftDeleteModel{}).As("t1")
+				t2 := TableOf(&TestModel{}).As("t2")
+				return NewSelector[SoftDeleteModel](db).From(t1.Join(t2).On(t1.Col("Id").Eq(t2.Col("Id"))))
+			}(),
+			wantQuery: &Query{
+				SQL: "SELECT * FROM (`soft_delete_model` AS `t1` INNER JOIN `test_model` AS `t2` ON `t1`.`id` = `t2`.`id`);",
+			},
+		},
+		{
+			name:    "select unscoped",
+			builder: NewSelector[SoftDeleteModel](db).Where(Col("Id").Eq(1)).Unscoped(),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `soft_delete_model` WHERE `id` = ?;",
+				Args: []any{1},
+			},
+		},
+		{
+			name:    "select integer soft delete",
+			builder: NewSelector[SoftDeleteUnixModel](db),
+			wantQuery: &Query{
+				SQL:  "SELECT * FROM `soft_delete_unix_model` WHERE `removed` = ?;",
+				Args: []any{0},
+			},
+		},
+		{
+			name: "count",
+			builder: func() SqlBuilder {
+				s := NewSelector[SoftDeleteModel](db)
+				s.count = true
+				return s
+			}(),
+			wantQuery: &Query{
+				SQL: "SELECT COUNT(*) FROM `soft_delete_model` WHERE `deleted_at` IS NULL;",
+			},
+		},
+		{
+			name:    "update",
+			builder: NewUpdater[SoftDeleteModel](db).Set(Assign("Name", "tom")).Where(Col("Id").Eq(1)),
+			wantQuery: &Query{
+				SQL:  "UPDATE `soft_delete_model` SET `name` = ? WHERE (`id` = ?) AND (`deleted_at` IS NULL);",
+				Args: []any{"tom", 1},
+			},
+		},
+		{
+			name:    "update unscoped",
+			builder: NewUpdater[SoftDeleteModel](db).Set(Assign("Name", "tom")).Unscoped(),
+			wantQuery: &Query{
+				SQL:  "UPDATE `soft_delete_model` SET `name` = ?;",
+				Args: []any{"tom"},
+			},
+		},
+		{
+			name:    "hard delete",
+			builder: NewDeleter[SoftDeleteModel](db).Where(Col("Id").Eq(1)).HardDelete(),
+			wantQuery: &Query{
+				SQL:  "DELETE FROM `soft_delete_model` WHERE `id` = ?;",
+				Args: []any{1},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			q, err := tc.builder.Build()
+			assert.Equal(t, tc.wantErr, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.wantQuery, q)
+		})
+	}
+}
+
+func TestDeleter_SoftDelete(t *testing.T) {
+	db, err := OpenDB(nil, DBWithDialect(DialectMySQL))
+	require.NoError(t, err)
+
+	q, err := NewDeleter[SoftDeleteModel](db).Where(Col("Id").Eq(1)).Build()
+	require.NoError(t, err)
+	assert.Equal(t, "UPDATE `soft_delete_model` SET `deleted_at` = ? WHERE (`id` = ?) AND (`deleted_at` IS NULL);", q.SQL)
+	require.Len(t, q.Args, 2)
+	assert.IsType(t, &time.Time{}, q.Args[0])
+	assert.Equal(t, 1, q.Args[1])
+
+	q, err = NewDeleter[SoftDeleteUnixModel](db).Unscoped().Build()
+	require.NoError(t, err)
+	assert.Equal(t, "UPDATE `soft_delete_unix_model` SET `removed` = ?;", q.SQL)
+	require.Len(t, q.Args, 1)
+	assert.IsType(t, int64(0), q.Args[0])
+}
@@ -0,0 +1,41 @@
+package orm
+
+import (
+	"github.com/KNICEX/go-orm/internal/valuer"
+	"github.com/KNICEX/go-orm/model"
+	"reflect"
+	"time"
+)
+
+// now 当前时间，没有指定时钟时使用 time.Now
+func (c *core) now() time.Time {
+	if c.clock == nil {
+		return time.Now()
+	}
+	return c.clock()
+}
+
+// timeValueOf 创建时间和更新时间的取值规则和软删除字段相同，整数类型使用 Unix 秒
+func timeValueOf(fd *model.Field, now time.Time) any {
+	return softDeleteValue(fd, now)
+}
+
+// fillAutoTime 插入前为零值的创建时间和更新时间赋值
+func (b *builder) fillAutoTime(val valuer.Value, now time.Time) error {
+	for _, fd := range []*model.Field{b.model.AutoCreateTime, b.model.AutoUpdateTime} {
+		if fd == nil {
+			continue
+		}
+		arg, err := val.Field(fd.GoName)
+		if err != nil {
+			return err
+		}
+		if !reflect.ValueOf(arg).IsZero() {
+			continue
+		}
+		if err = val.SetField(fd.GoName, timeValueOf(fd, now)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
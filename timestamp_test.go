@@ -0,0 +1,168 @@
+package orm
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+type TimestampModel struct {
+	Id        int64
+	Name      string
+	CreatedAt time.Time
+	UpdatedAt *time.Time
+}
+
+type TimestampUnixModel struct {
+	Id       int64
+	Name     string
+	Created  int64 `orm:"autoCreateTime"`
+	Modified int64 `orm:"autoUpdateTime"`
+}
+
+// CreatedAt 的类型不支持自动写入时间，按普通字段处理
+type TimestampStringModel struct {
+	Id        int64
+	CreatedAt string
+}
+
+func TestAutoTime_Build(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	before := now.Add(-time.Hour)
+	db, err := OpenDB(nil, DBWithDialect(DialectMySQL), DBWithClock(func() time.Time {
+		return now
+	}))
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name      string
+		builder   SqlBuilder
+		wantQuery *Query
+		wantErr   error
+	}{
+		{
+			name:    "insert fill zero",
+			builder: NewInserter[TimestampModel](db).Values(&TimestampModel{Name: "tom"}),
+			wantQuery: &Query{
+				SQL:  "INSERT INTO `timestamp_model` (`name`,`created_at`,`updated_at`) VALUES (?,?,?);",
+				Args: []any{"tom", now, &now},
+			},
+		},
+		{
+			name:    "insert keep non zero",
+			builder: NewInserter[TimestampModel](db).Values(&TimestampModel{Name: "tom", CreatedAt: before}),
+			wantQuery: &Query{
+				SQL:  "INSERT INTO `timestamp_model` (`name`,`created_at`,`updated_at`) VALUES (?,?,?);",
+				Args: []any{"tom", before, &now},
+			},
+		},
+		{
+			name:    "insert unix tag",
+			builder: NewInserter[TimestampUnixModel](db).Values(&TimestampUnixModel{Name: "tom"}),
+			wantQuery: &Query{
+				SQL:  "INSERT INTO `timestamp_unix_model` (`name`,`created`,`modified`) VALUES (?,?,?);",
+				Args: []any{"tom", now.Unix(), now.Unix()},
+			},
+		},
+		{
+			name:    "insert string created at",
+			builder: NewInserter[TimestampStringModel](db).Values(&TimestampStringModel{CreatedAt: "today"}),
+			wantQuery: &Query{
+				SQL:  "INSERT INTO `timestamp_string_model` (`created_at`) VALUES (?);",
+				Args: []any{"today"},
+			},
+		},
+		{
+			name:    "update",
+			builder: NewUpdater[TimestampModel](db).Set(Assign("Name", "tom")).Where(Col("Id").Eq(1)),
+			wantQuery: &Query{
+				SQL:  "UPDATE `timestamp_model` SET `name` = ?,`updated_at` = ? WHERE `id` = ?;",
+				Args: []any{"tom", &now, 1},
+			},
+		},
+		{
+			name:    "update assigned",
+			builder: NewUpdater[TimestampModel](db).Set(Assign("UpdatedAt", &before)),
+			wantQuery: &Query{
+				SQL:  "UPDATE `timestamp_model` SET `updated_at` = ?;",
+				Args: []any{&before},
+			},
+		},
+		{
+			name:    "update raw assigned",
+			builder: NewUpdater[TimestampModel](db).Set(Raw("`name` = UPPER(`name`), `updated_at` = NOW()")),
+			wantQuery: &Query{
+				SQL: "UPDATE `timestamp_model` SET `name` = UPPER(`name`), `updated_at` = NOW();",
+			},
+		},
+		{
+			name:    "update raw other column",
+			builder: NewUpdater[TimestampModel](db).Set(Raw("`name` = CONCAT(`name`, ?)", "_updated_at")),
+			wantQuery: &Query{
+				SQL:  "UPDATE `timestamp_model` SET `name` = CONCAT(`name`, ?),`updated_at` = ?;",
+				Args: []any{"_updated_at", &now},
+			},
+		},
+		{
+			name:    "update unix tag",
+			builder: NewUpdater[TimestampUnixModel](db).Set(Assign("Name", "tom")),
+			wantQuery: &Query{
+				SQL:  "UPDATE `timestamp_unix_model` SET `name` = ?,`modified` = ?;",
+				Args: []any{"tom", now.Unix()},
+			},
+		},
+		{
+			name:    "soft delete",
+			builder: NewDeleter[SoftDeleteModel](db).Where(Col("Id").Eq(1)),
+			wantQuery: &Query{
+				SQL:  "UPDATE `soft_delete_model` SET `deleted_at` = ? WHERE (`id` = ?) AND (`deleted_at` IS NULL);",
+				Args: []any{&now, 1},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			q, err := tc.builder.Build()
+			assert.Equal(t, tc.wantErr, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.wantQuery, q)
+		})
+	}
+}
+
+func TestInserter_AutoTimeWriteBack(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	db, err := OpenDB(nil, DBWithDialect(DialectMySQL), DBWithClock(func() time.Time {
+		return now
+	}))
+	require.NoError(t, err)
+
+	entity := &TimestampModel{Name: "tom"}
+	_, err = NewInserter[TimestampModel](db).Values(entity).Build()
+	require.NoError(t, err)
+	assert.Equal(t, now, entity.CreatedAt)
+	assert.Equal(t, &now, entity.UpdatedAt)
+}
+
+func TestRawAssigns(t *testing.T) {
+	testCases := []struct {
+		raw  string
+		want bool
+	}{
+		{raw: "updated_at = NOW()", want: true},
+		{raw: "`name` = ?, `t`.`updated_at` = NOW()", want: true},
+		{raw: `"updated_at" = now()`, want: true},
+		{raw: "`name` = COALESCE(`updated_at`, ?)", want: false},
+		{raw: "`name` = IF(`id` = 1, 'a', 'b')", want: false},
+		{raw: "`updated_at_2` = NOW()", want: false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.raw, func(t *testing.T) {
+			assert.Equal(t, tc.want, rawAssigns(tc.raw, "updated_at"))
+		})
+	}
+}
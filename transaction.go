@@ -25,10 +25,12 @@ type Tx struct {
 
 func (t *Tx) getCore() *core {
 	return &core{
-		dialect:     t.dialect,
-		creator:     t.creator,
-		r:           t.r,
-		middlewares: t.middlewares,
+		dialect:      t.dialect,
+		creator:      t.creator,
+		r:            t.r,
+		clock:        t.clock,
+		cursorSecret: t.cursorSecret,
+		middlewares:  t.middlewares,
 	}
 }
 
@@ -3,6 +3,8 @@ package orm
 import (
 	"context"
 	"github.com/KNICEX/go-orm/internal/errs"
+	"github.com/KNICEX/go-orm/model"
+	"strings"
 )
 
 type SetAble interface {
@@ -13,6 +15,16 @@ type Updater[T any] struct {
 	table string
 	set   []SetAble
 	where []Predicate
+	// 不追加软删除过滤条件
+	unscoped bool
+	// 携带的实体，Build 时从实体中读取 entityFields 的值，并调用更新钩子
+	entity       *T
+	entityFields []string
+	// 多表更新，JOIN 最左边的表是被更新的表
+	from *Join
+	// 只更新排序后的前 limit 行
+	orderBys []OrderAble
+	limit    int
 
 	builder
 	sess Session
@@ -36,52 +48,195 @@ func (u *Updater[T]) Build() (*Query, error) {
 	}
 	u.model = m
 
-	u.sb.WriteString("UPDATE ")
-	if u.table == "" {
-		u.quote(m.TableName)
+	set, err := u.setWithEntity(m)
+	if err != nil {
+		return nil, err
+	}
+	if len(set) == 0 {
+		return nil, errs.ErrUpdateNoSet
+	}
+
+	if u.from != nil {
+		if len(u.orderBys) > 0 || u.limit > 0 {
+			return nil, errs.ErrMultiTableLimit
+		}
+		if err = u.buildFrom(set); err != nil {
+			return nil, err
+		}
 	} else {
-		u.sb.WriteString(u.table)
+		u.sb.WriteString("UPDATE ")
+		if u.table == "" {
+			u.quote(m.TableName)
+		} else {
+			u.sb.WriteString(u.table)
+		}
+
+		u.sb.WriteString(" SET ")
+		if err = u.buildSet(set, ""); err != nil {
+			return nil, err
+		}
+
+		where := u.whereWithScope(u.where, nil, u.unscoped)
+		err = u.buildWhereLimit(where, u.orderBys, u.limit, func(pks []Selectable) SqlBuilder {
+			return limitSelector[T](u.sess, pks, u.where, u.orderBys, u.limit, u.unscoped)
+		})
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	u.sb.WriteString(" SET ")
-	if len(u.set) == 0 {
-		return nil, errs.ErrUpdateNoSet
+	u.sb.WriteByte(';')
+	return &Query{
+		SQL:  u.dialect.rebind(u.sb.String()),
+		Args: u.args,
+	}, nil
+
+}
+
+// buildFrom 多表更新，由方言决定 JOIN 的写法
+func (u *Updater[T]) buildFrom(set []SetAble) error {
+	mt, err := u.newMultiTable(*u.from)
+	if err != nil {
+		return err
 	}
+	mt.where = u.whereWithScope(u.where, mt.target, u.unscoped)
+	mt.set = func(qualifier string) error {
+		return u.buildSet(set, qualifier)
+	}
+	return u.dialect.buildUpdateJoin(&u.builder, mt)
+}
 
-	for i, s := range u.set {
+// buildSet 构造赋值列表，qualifier 不为空时用它限定列名
+func (b *builder) buildSet(set []SetAble, qualifier string) error {
+	m := b.model
+	for i, s := range set {
 		if i > 0 {
-			u.sb.WriteByte(',')
+			b.sb.WriteByte(',')
 		}
 		switch v := s.(type) {
 		case Assignment:
 			fd, ok := m.FieldMap[v.name]
 			if !ok {
-				return nil, errs.NewErrUnknownField(v.name)
+				return errs.NewErrUnknownField(v.name)
+			}
+			b.buildSetColumn(qualifier, fd.ColName)
+			if err := b.buildAssignValue(v.val); err != nil {
+				return err
+			}
+		case CaseExpr:
+			fd, ok := m.FieldMap[v.field]
+			if !ok {
+				return errs.NewErrUnknownField(v.field)
+			}
+			b.buildSetColumn(qualifier, fd.ColName)
+			if err := b.buildAssignValue(v); err != nil {
+				return err
 			}
-			u.quote(fd.ColName)
-			u.sb.WriteString(" = ?")
-			u.addArgs(v.val)
 		case RawExpr:
-			u.sb.WriteString(v.raw)
-			u.addArgs(v.args...)
+			b.sb.WriteString(v.raw)
+			b.addArgs(v.args...)
 		default:
-			return nil, errs.NewErrUnsupportedSetAble(s)
+			return errs.NewErrUnsupportedSetAble(s)
 		}
 	}
 
-	if len(u.where) > 0 {
-		u.sb.WriteString(" WHERE ")
-		if err = u.buildPredicate(u.where); err != nil {
+	// 调用方没有设置更新时间时，自动更新
+	if fd := m.AutoUpdateTime; fd != nil && !assigned(set, fd) {
+		b.sb.WriteByte(',')
+		b.buildSetColumn(qualifier, fd.ColName)
+		b.sb.WriteByte('?')
+		b.addArgs(timeValueOf(fd, b.now()))
+	}
+	return nil
+}
+
+// buildSetColumn 构造赋值语句左侧的列 [qualifier.]col =
+func (b *builder) buildSetColumn(qualifier, col string) {
+	if qualifier != "" {
+		b.quote(qualifier)
+		b.sb.WriteByte('.')
+	}
+	b.quote(col)
+	b.sb.WriteString(" = ")
+}
+
+// setWithEntity 合并 Set 指定的赋值和从实体中读取的赋值
+func (u *Updater[T]) setWithEntity(m *model.Model) ([]SetAble, error) {
+	if u.entity == nil {
+		return u.set, nil
+	}
+	set := make([]SetAble, 0, len(u.set)+len(u.entityFields))
+	set = append(set, u.set...)
+	val := u.creator(m, u.entity)
+	for _, fd := range u.entityFields {
+		if _, ok := m.FieldMap[fd]; !ok {
+			return nil, errs.NewErrUnknownField(fd)
+		}
+		arg, err := val.Field(fd)
+		if err != nil {
 			return nil, err
 		}
+		set = append(set, Assign(fd, arg))
 	}
+	return set, nil
+}
 
-	u.sb.WriteByte(';')
-	return &Query{
-		SQL:  u.sb.String(),
-		Args: u.args,
-	}, nil
+// assigned 字段是否已经通过 Assign 或者原生表达式设置
+func assigned(set []SetAble, fd *model.Field) bool {
+	for _, s := range set {
+		switch v := s.(type) {
+		case Assignment:
+			if v.name == fd.GoName {
+				return true
+			}
+		case CaseExpr:
+			if v.field == fd.GoName {
+				return true
+			}
+		case RawExpr:
+			if rawAssigns(v.raw, fd.ColName) {
+				return true
+			}
+		}
+	}
+	return false
+}
 
+// rawAssigns 原生的赋值语句 a = 1, t.b = NOW() 中是否给 col 赋值
+// 只检查括号外每个逗号分隔的片段中 = 左侧的列名
+func rawAssigns(raw, col string) bool {
+	depth, start := 0, 0
+	for i := 0; i <= len(raw); i++ {
+		if i < len(raw) {
+			switch raw[i] {
+			case '(':
+				depth++
+				continue
+			case ')':
+				depth--
+				continue
+			case ',':
+				if depth > 0 {
+					continue
+				}
+			default:
+				continue
+			}
+		}
+		left, _, ok := strings.Cut(raw[start:i], "=")
+		start = i + 1
+		if !ok {
+			continue
+		}
+		left = strings.TrimSpace(left)
+		if idx := strings.LastIndexByte(left, '.'); idx >= 0 {
+			left = left[idx+1:]
+		}
+		if strings.Trim(left, "`\"") == col {
+			return true
+		}
+	}
+	return false
 }
 
 func (u *Updater[T]) Set(assignments ...SetAble) *Updater[T] {
@@ -89,11 +244,63 @@ func (u *Updater[T]) Set(assignments ...SetAble) *Updater[T] {
 	return u
 }
 
+// From 多表更新，JOIN 最左边的表是被更新的表，其余的表用于过滤和取值
+// 用法： From(o.Join(usr).On(o.Col("UserId").Eq(usr.Col("Id")))).Where(usr.Col("Banned").Eq(true))
+func (u *Updater[T]) From(join Join) *Updater[T] {
+	u.from = &join
+	return u
+}
+
 func (u *Updater[T]) Where(predicates Predicate) *Updater[T] {
 	u.where = append(u.where, predicates)
 	return u
 }
 
+// OrderBy 和 Limit 一起使用，只更新排序后的前 limit 行
+func (u *Updater[T]) OrderBy(orderBys ...OrderAble) *Updater[T] {
+	u.orderBys = orderBys
+	return u
+}
+
+// Limit 最多更新 limit 行，方言不支持时改写为 WHERE pk IN (SELECT pk ... LIMIT limit)
+func (u *Updater[T]) Limit(limit int) *Updater[T] {
+	u.limit = limit
+	return u
+}
+
+// Unscoped 更新包括已软删除的行
+func (u *Updater[T]) Unscoped() *Updater[T] {
+	u.unscoped = true
+	return u
+}
+
 func (u *Updater[T]) Exec(ctx context.Context) ExecResult {
-	return exec(ctx, u, u.sess, u.core, UPDATE)
+	if u.entity == nil {
+		return exec(ctx, u, u.sess, u.core, UPDATE)
+	}
+	if h, ok := any(u.entity).(BeforeUpdateHook); ok {
+		hc, err := hookContext(ctx, u.core, UPDATE, u.entity)
+		if err != nil {
+			return ExecResult{
+				err: err,
+			}
+		}
+		if err = h.BeforeUpdate(ctx, hc); err != nil {
+			return ExecResult{
+				err: err,
+			}
+		}
+	}
+	return execWithHandler(ctx, u, u.core, UPDATE, func(ctx *Context) *Result {
+		res := execHandler(ctx, u.sess)
+		if res.Err != nil {
+			return res
+		}
+		if h, ok := any(u.entity).(AfterUpdateHook); ok {
+			if err := h.AfterUpdate(ctx.Ctx, ctx); err != nil {
+				return resultWithErr(res, err)
+			}
+		}
+		return res
+	})
 }
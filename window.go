@@ -0,0 +1,130 @@
+package orm
+
+import "github.com/KNICEX/go-orm/internal/errs"
+
+// Window 窗口定义 OVER (PARTITION BY ... ORDER BY ...)
+// 用法： PartitionBy(Col("UserId")).OrderBy(Col("CreatedAt").Desc())
+type Window struct {
+	partitionBy []Column
+	orderBy     []OrderAble
+}
+
+func PartitionBy(cols ...Column) Window {
+	return Window{partitionBy: cols}
+}
+
+func (w Window) OrderBy(orderBys ...OrderAble) Window {
+	w.orderBy = orderBys
+	return w
+}
+
+// WindowFunc 窗口函数，只能出现在查询列和 ORDER BY 中
+// 用法： RowNumber().Over(PartitionBy(Col("UserId")).OrderBy(Col("CreatedAt").Desc())).As("rn")
+// 取每组前 N 条时，用 AsSubQuery 包装后在外层按别名过滤
+type WindowFunc struct {
+	fn     string
+	args   []Expression
+	window Window
+	alias  string
+	desc   bool
+}
+
+func (w WindowFunc) expr()                  {}
+func (w WindowFunc) selectedAlias() string  { return w.alias }
+func (w WindowFunc) target() TableReference { return nil }
+func (w WindowFunc) fieldName() string      { return "" }
+func (w WindowFunc) orderAble()             {}
+
+func (w WindowFunc) Over(window Window) WindowFunc {
+	w.window = window
+	return w
+}
+
+func (w WindowFunc) As(alias string) WindowFunc {
+	w.alias = alias
+	return w
+}
+
+func (w WindowFunc) Asc() WindowFunc {
+	w.desc = false
+	return w
+}
+
+func (w WindowFunc) Desc() WindowFunc {
+	w.desc = true
+	return w
+}
+
+func RowNumber() WindowFunc {
+	return WindowFunc{fn: "ROW_NUMBER"}
+}
+
+func Rank() WindowFunc {
+	return WindowFunc{fn: "RANK"}
+}
+
+func DenseRank() WindowFunc {
+	return WindowFunc{fn: "DENSE_RANK"}
+}
+
+// Lag 当前行之前第 offset 行的值，def 为没有该行时的默认值
+func Lag(c Column, offset int, def ...any) WindowFunc {
+	return offsetFunc("LAG", c, offset, def)
+}
+
+// Lead 当前行之后第 offset 行的值，def 为没有该行时的默认值
+func Lead(c Column, offset int, def ...any) WindowFunc {
+	return offsetFunc("LEAD", c, offset, def)
+}
+
+func offsetFunc(fn string, c Column, offset int, def []any) WindowFunc {
+	args := []Expression{c, value{val: offset}}
+	if len(def) > 0 {
+		args = append(args, valueOf(def[0]))
+	}
+	return WindowFunc{fn: fn, args: args}
+}
+
+// Over 聚合函数作为窗口函数，例如累计求和
+// 用法： Sum("Amount").Over(PartitionBy(Col("UserId")).OrderBy(Col("Id"))).As("running_total")
+func (a Aggregate) Over(window Window) WindowFunc {
+	return WindowFunc{
+		fn:     a.fn,
+		args:   []Expression{Column{table: a.table, name: a.arg}},
+		window: window,
+		alias:  a.alias,
+	}
+}
+
+// buildWindowFunc 构造 fn(args) OVER (PARTITION BY ... ORDER BY ...)
+func (b *builder) buildWindowFunc(w WindowFunc) error {
+	if !b.dialect.supportWindow() {
+		return errs.ErrUnsupportedWindow
+	}
+	if err := buildCall(b, w.fn, w.args); err != nil {
+		return err
+	}
+	b.sb.WriteString(" OVER (")
+	if len(w.window.partitionBy) > 0 {
+		b.sb.WriteString("PARTITION BY ")
+		for i, c := range w.window.partitionBy {
+			if i > 0 {
+				b.sb.WriteByte(',')
+			}
+			if err := b.buildColumn(Column{table: c.table, name: c.name}); err != nil {
+				return err
+			}
+		}
+	}
+	if len(w.window.orderBy) > 0 {
+		if len(w.window.partitionBy) > 0 {
+			b.sb.WriteByte(' ')
+		}
+		b.sb.WriteString("ORDER BY ")
+		if err := b.buildOrderItems(w.window.orderBy); err != nil {
+			return err
+		}
+	}
+	b.sb.WriteByte(')')
+	return nil
+}
@@ -0,0 +1,129 @@
+package orm
+
+import (
+	"context"
+	"github.com/KNICEX/go-orm/internal/errs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestWindow_Build(t *testing.T) {
+	db, err := OpenDB(nil, DBWithDialect(DialectMySQL))
+	require.NoError(t, err)
+	pg, err := OpenDB(nil, DBWithDialect(DialectPostgres))
+	require.NoError(t, err)
+	mysql57, err := OpenDB(nil, DBWithDialect(DialectMySQL57))
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name      string
+		builder   SqlBuilder
+		wantQuery *Query
+		wantErr   error
+	}{
+		{
+			name: "row number",
+			builder: NewSelector[PredicateOrder](db).Select(Col("Id"),
+				RowNumber().Over(PartitionBy(Col("UserId")).OrderBy(Col("Amount").Desc(), Col("Id"))).As("rn")),
+			wantQuery: &Query{
+				SQL: "SELECT `id`,ROW_NUMBER() OVER (PARTITION BY `user_id` ORDER BY `amount` DESC,`id` ASC) AS `rn` " +
+					"FROM `predicate_order`;",
+			},
+		},
+		{
+			name: "rank without partition",
+			builder: NewSelector[PredicateOrder](db).Select(Col("Id"),
+				Rank().Over(Window{}.OrderBy(Col("Amount").Desc())).As("r"),
+				DenseRank().Over(Window{}).As("dr")).
+				OrderBy(Col("r")),
+			wantQuery: &Query{
+				SQL: "SELECT `id`,RANK() OVER (ORDER BY `amount` DESC) AS `r`,DENSE_RANK() OVER () AS `dr` " +
+					"FROM `predicate_order` ORDER BY `r` ASC;",
+			},
+		},
+		{
+			name: "aggregate over",
+			builder: NewSelector[PredicateOrder](pg).Select(Col("Id"),
+				Sum("Amount").Over(PartitionBy(Col("UserId")).OrderBy(Col("Id"))).As("running_total")).
+				Where(Col("Amount").Gt(1)),
+			wantQuery: &Query{
+				SQL: `SELECT "id",SUM("amount") OVER (PARTITION BY "user_id" ORDER BY "id" ASC) AS "running_total" ` +
+					`FROM "predicate_order" WHERE "amount" > $1;`,
+				Args: []any{1},
+			},
+		},
+		{
+			name: "lag lead",
+			builder: NewSelector[PredicateOrder](pg).Select(
+				Lag(Col("Amount"), 1, 0).Over(PartitionBy(Col("UserId")).OrderBy(Col("Id"))).As("prev"),
+				Lead(Col("Amount"), 2).Over(Window{}.OrderBy(Col("Id"))).As("next"),
+			),
+			wantQuery: &Query{
+				SQL: `SELECT LAG("amount",$1,$2) OVER (PARTITION BY "user_id" ORDER BY "id" ASC) AS "prev",` +
+					`LEAD("amount",$3) OVER (ORDER BY "id" ASC) AS "next" FROM "predicate_order";`,
+				Args: []any{1, 0, 2},
+			},
+		},
+		{
+			name: "top n per group",
+			builder: func() SqlBuilder {
+				sub := NewSelector[PredicateOrder](db).Select(Col("Id"), Col("UserId"),
+					RowNumber().Over(PartitionBy(Col("UserId")).OrderBy(Col("Amount").Desc())).As("rn")).
+					Where(Col("Amount").Gt(0)).
+					AsSubQuery("t")
+				return NewSelector[PredicateOrder](db).Select(sub.Col("Id"), sub.Col("UserId")).
+					From(sub).Where(sub.Col("rn").Le(2))
+			}(),
+			wantQuery: &Query{
+				SQL: "SELECT `t`.`id`,`t`.`user_id` FROM (SELECT `id`,`user_id`," +
+					"ROW_NUMBER() OVER (PARTITION BY `user_id` ORDER BY `amount` DESC) AS `rn` " +
+					"FROM `predicate_order` WHERE `amount` > ?) AS `t` WHERE `t`.`rn` <= ?;",
+				Args: []any{0, 2},
+			},
+		},
+		{
+			name:    "unsupported dialect",
+			builder: NewSelector[PredicateOrder](mysql57).Select(RowNumber().Over(Window{}).As("rn")),
+			wantErr: errs.ErrUnsupportedWindow,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			q, err := tc.builder.Build()
+			assert.Equal(t, tc.wantErr, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.wantQuery, q)
+		})
+	}
+}
+
+func TestWindow_SQLite(t *testing.T) {
+	db := memoryWithDB("window", t, DBWithDialect(DialectSQLite3))
+	ctx := context.Background()
+	require.NoError(t, db.AutoMigrate(ctx, &PredicateOrder{}))
+	require.NoError(t, NewInserter[PredicateOrder](db).Values(
+		&PredicateOrder{Id: 1, UserId: 1, Amount: 10},
+		&PredicateOrder{Id: 2, UserId: 1, Amount: 30},
+		&PredicateOrder{Id: 3, UserId: 1, Amount: 20},
+		&PredicateOrder{Id: 4, UserId: 2, Amount: 5},
+		&PredicateOrder{Id: 5, UserId: 2, Amount: 7},
+	).Exec(ctx).Err())
+
+	sub := NewSelector[PredicateOrder](db).Select(Col("Id"), Col("UserId"), Col("Amount"),
+		RowNumber().Over(PartitionBy(Col("UserId")).OrderBy(Col("Amount").Desc())).As("rn")).
+		AsSubQuery("t")
+	list, err := NewSelector[PredicateOrder](db).
+		Select(sub.Col("Id"), sub.Col("UserId"), sub.Col("Amount")).
+		From(sub).Where(sub.Col("rn").Le(2)).
+		OrderBy(sub.Col("Id")).GetMulti(ctx)
+	require.NoError(t, err)
+	ids := make([]int64, 0, len(list))
+	for _, o := range list {
+		ids = append(ids, o.Id)
+	}
+	assert.Equal(t, []int64{2, 3, 4, 5}, ids)
+}